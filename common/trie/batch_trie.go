@@ -12,6 +12,8 @@ import (
 var (
 	ErrCloneInBatch      = errors.New("cannot clone with a batch task unfinished")
 	ErrBeginAgainInBatch = errors.New("cannot begin with a batch task unfinished")
+	ErrNotInBatch        = errors.New("cannot make savepoint without a batch task")
+	ErrInvalidSavepoint  = errors.New("invalid savepoint")
 )
 
 // Action represents operation types in BatchTrie
@@ -195,6 +197,40 @@ func (bt *BatchTrie) Rollback() {
 	}
 }
 
+// Savepoint return a savepoint in the batch task, changes after it can be undone by RollbackToSavepoint
+func (bt *BatchTrie) Savepoint() (int, error) {
+	if !bt.batching {
+		return 0, ErrNotInBatch
+	}
+	return len(bt.changelog), nil
+}
+
+// RollbackToSavepoint undo the changes after the savepoint, savepoints made after it become invalid
+func (bt *BatchTrie) RollbackToSavepoint(savepoint int) error {
+	if !bt.batching {
+		return ErrNotInBatch
+	}
+	if savepoint < 0 || savepoint > len(bt.changelog) {
+		return ErrInvalidSavepoint
+	}
+	// undo in reverse order
+	for i := len(bt.changelog) - 1; i >= savepoint; i-- {
+		entry := bt.changelog[i]
+		switch entry.action {
+		case Insert:
+			if _, err := bt.trie.Del(entry.key); err != nil {
+				return err
+			}
+		case Update, Delete:
+			if _, err := bt.trie.Put(entry.key, entry.old); err != nil {
+				return err
+			}
+		}
+	}
+	bt.changelog = bt.changelog[:savepoint]
+	return nil
+}
+
 // HashDomains for each variable in contract
 // each domain will represented as 6 bytes, support 4 level domain at most
 // such as,
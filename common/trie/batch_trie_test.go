@@ -62,6 +62,51 @@ func TestBatchTrie_Batch(t *testing.T) {
 	assert.NotNil(t, err4)
 }
 
+func TestBatchTrie_Savepoint(t *testing.T) {
+	storage, _ := storage.NewMemoryStorage()
+	tr, _ := NewBatchTrie(nil, storage)
+
+	_, err := tr.Savepoint()
+	assert.Equal(t, ErrNotInBatch, err)
+
+	tr.Begin()
+	key1, _ := byteutils.FromHex("1f345678e9")
+	key2, _ := byteutils.FromHex("1f355678e9")
+	key3, _ := byteutils.FromHex("1f555678e9")
+	tr.Put(key1, []byte("value 1"))
+
+	sp1, err := tr.Savepoint()
+	assert.Nil(t, err)
+	tr.Put(key2, []byte("value 2"))
+	tr.Put(key1, []byte("value 11"))
+
+	// nested savepoint
+	sp2, err := tr.Savepoint()
+	assert.Nil(t, err)
+	tr.Put(key3, []byte("value 3"))
+	tr.Del(key2)
+
+	assert.Nil(t, tr.RollbackToSavepoint(sp2))
+	_, err = tr.Get(key3)
+	assert.NotNil(t, err)
+	val2, _ := tr.Get(key2)
+	assert.Equal(t, []byte("value 2"), val2)
+
+	assert.Nil(t, tr.RollbackToSavepoint(sp1))
+	val1, _ := tr.Get(key1)
+	assert.Equal(t, []byte("value 1"), val1)
+	_, err = tr.Get(key2)
+	assert.NotNil(t, err)
+
+	// savepoint made after sp1 is invalid now.
+	assert.Equal(t, ErrInvalidSavepoint, tr.RollbackToSavepoint(sp2))
+
+	// rollback the whole batch still works.
+	tr.Rollback()
+	_, err = tr.Get(key1)
+	assert.NotNil(t, err)
+}
+
 func TestBatchTrie_Iterator(t *testing.T) {
 	storage, _ := storage.NewMemoryStorage()
 	tr, _ := NewBatchTrie(nil, storage)
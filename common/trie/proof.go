@@ -91,6 +91,9 @@ func (t *Trie) Verify(rootHash []byte, key []byte, proof MerkleProof) error {
 		}
 		switch len(val) {
 		case 16: // Branch Node
+			if len(curRoute) == 0 {
+				return errors.New("wrong hash")
+			}
 			wantHash = val[curRoute[0]]
 			curRoute = curRoute[1:]
 			break
@@ -100,7 +103,7 @@ func (t *Trie) Verify(rootHash []byte, key []byte, proof MerkleProof) error {
 			}
 			if val[0][0] == byte(ext) {
 				extLen := len(val[1])
-				if !bytes.Equal(val[1], curRoute[:extLen]) {
+				if extLen > len(curRoute) || !bytes.Equal(val[1], curRoute[:extLen]) {
 					return errors.New("wrong hash")
 				}
 				wantHash = val[2]
@@ -119,3 +122,15 @@ func (t *Trie) Verify(rootHash []byte, key []byte, proof MerkleProof) error {
 	}
 	return nil
 }
+
+// Value return the value of the leaf node the proof ends at, nil if the proof doesn't end at a leaf
+func (proof MerkleProof) Value() []byte {
+	if len(proof) == 0 {
+		return nil
+	}
+	val := proof[len(proof)-1]
+	if len(val) != 3 || len(val[0]) == 0 || val[0][0] != byte(leaf) {
+		return nil
+	}
+	return val[2]
+}
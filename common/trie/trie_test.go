@@ -195,6 +195,9 @@ func TestTrie_Operation(t *testing.T) {
 	if err := tr.Verify(tr.rootHash, addr1, proof); err != nil {
 		t.Errorf("1 Trie.Verify() %v", err.Error())
 	}
+	if !reflect.DeepEqual(proof.Value(), val11) {
+		t.Errorf("1 MerkleProof.Value() = %v, want %v", proof.Value(), val11)
+	}
 	// get node "1f345678e9"
 	checkVal1, _ := tr.Get(addr1)
 	if !reflect.DeepEqual(checkVal1, val11) {
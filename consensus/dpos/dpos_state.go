@@ -32,6 +32,7 @@ import (
 
 	"github.com/nebulasio/go-nebulas/common/trie"
 	"github.com/nebulasio/go-nebulas/storage"
+	"github.com/nebulasio/go-nebulas/util"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
 	"github.com/nebulasio/go-nebulas/util/logging"
 	"github.com/sirupsen/logrus"
@@ -61,6 +62,8 @@ var (
 	ErrCloneMintCntTrie        = errors.New("Failed to clone mint count trie")
 	ErrNotBlockForgTime        = errors.New("now is not time to forg block")
 	ErrFoundNilProposer        = errors.New("found a nil proposer")
+	ErrCloneStakeTrie          = errors.New("Failed to clone stake trie")
+	ErrStakeNotInDynasty       = errors.New("the staked address in genesis block is not in the initial dynasty")
 )
 
 // State carry context in dpos consensus
@@ -69,6 +72,7 @@ type State struct {
 	proposer  byteutils.Hash // ToAdd comment, miner
 
 	dynastyTrie *trie.BatchTrie // key: delegatee, val: delegatee
+	stakeTrie   *trie.BatchTrie // key: delegatee, val: stake
 
 	chain     *core.BlockChain
 	consensus core.Consensus
@@ -76,20 +80,26 @@ type State struct {
 
 // NewState create a new dpos state
 func (dpos *Dpos) NewState(root *consensuspb.ConsensusRoot, stor storage.Storage) (state.ConsensusState, error) {
-	var dynastyRoot byteutils.Hash
+	var dynastyRoot, stakeRoot byteutils.Hash
 	if root != nil {
 		dynastyRoot = root.DynastyRoot
+		stakeRoot = root.StakeRoot
 	}
 	dynastyTrie, err := trie.NewBatchTrie(dynastyRoot, stor)
 	if err != nil {
 		return nil, err
 	}
+	stakeTrie, err := trie.NewBatchTrie(stakeRoot, stor)
+	if err != nil {
+		return nil, err
+	}
 
 	return &State{
 		timeStamp: root.Timestamp,
 		proposer:  root.Proposer,
 
 		dynastyTrie: dynastyTrie,
+		stakeTrie:   stakeTrie,
 
 		chain:     dpos.chain,
 		consensus: dpos,
@@ -134,11 +144,37 @@ func (dpos *Dpos) GenesisState(chain *core.BlockChain, conf *corepb.Genesis) (st
 			return nil, err
 		}
 	}
+	stakeTrie, err := trie.NewBatchTrie(nil, chain.Storage())
+	if err != nil {
+		return nil, err
+	}
+	for _, stake := range conf.Consensus.Dpos.Stakes {
+		member, err := core.AddressParse(stake.Address)
+		if err != nil {
+			return nil, err
+		}
+		v := member.Bytes()
+		if _, err := dynastyTrie.Get(v); err != nil {
+			return nil, ErrStakeNotInDynasty
+		}
+		value, err := util.NewUint128FromString(stake.Value)
+		if err != nil {
+			return nil, err
+		}
+		bytes, err := value.ToFixedSizeByteSlice()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = stakeTrie.Put(v, bytes); err != nil {
+			return nil, err
+		}
+	}
 	return &State{
 		timeStamp: core.GenesisTimestamp,
 		proposer:  nil, // ToCheck nil maybe have issues
 
 		dynastyTrie: dynastyTrie,
+		stakeTrie:   stakeTrie,
 
 		chain:     chain,
 		consensus: dpos,
@@ -148,16 +184,19 @@ func (dpos *Dpos) GenesisState(chain *core.BlockChain, conf *corepb.Genesis) (st
 // Begin a new transaction
 func (ds *State) Begin() {
 	ds.dynastyTrie.Begin()
+	ds.stakeTrie.Begin()
 }
 
 // Commit the transaction
 func (ds *State) Commit() {
 	ds.dynastyTrie.Commit()
+	ds.stakeTrie.Commit()
 }
 
 // Rollback the transaction
 func (ds *State) Rollback() {
 	ds.dynastyTrie.Rollback()
+	ds.stakeTrie.Rollback()
 }
 
 func (ds *State) String() string {
@@ -175,11 +214,16 @@ func (ds *State) Clone() (state.ConsensusState, error) {
 	if err != nil {
 		return nil, ErrCloneDynastyTrie
 	}
+	stakeTrie, err := ds.stakeTrie.Clone()
+	if err != nil {
+		return nil, ErrCloneStakeTrie
+	}
 	return &State{
 		timeStamp: ds.timeStamp,
 		proposer:  ds.proposer,
 
 		dynastyTrie: dynastyTrie,
+		stakeTrie:   stakeTrie,
 
 		chain:     ds.chain,
 		consensus: ds.consensus,
@@ -190,6 +234,7 @@ func (ds *State) Clone() (state.ConsensusState, error) {
 func (ds *State) RootHash() (*consensuspb.ConsensusRoot, error) { // ToRefine, change name
 	return &consensuspb.ConsensusRoot{
 		DynastyRoot: ds.dynastyTrie.RootHash(),
+		StakeRoot:   ds.stakeTrie.RootHash(),
 		Timestamp:   ds.timeStamp,
 		Proposer:    ds.proposer,
 	}, nil
@@ -205,6 +250,18 @@ func (ds *State) DynastyRoot() byteutils.Hash {
 	return ds.dynastyTrie.RootHash()
 }
 
+// Stake return the stake of the delegatee, zero if not staked
+func (ds *State) Stake(delegatee byteutils.Hash) (*util.Uint128, error) {
+	bytes, err := ds.stakeTrie.Get(delegatee)
+	if err == storage.ErrKeyNotFound {
+		return util.NewUint128(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return util.NewUint128FromFixedSizeByteSlice(bytes)
+}
+
 // FindProposer for now in given dynasty
 func FindProposer(now int64, validators []byteutils.Hash) (proposer byteutils.Hash, err error) {
 	offset := now % DynastyInterval
@@ -246,6 +303,10 @@ func (ds *State) NextState(elapsedSecond int64) (state.ConsensusState, error) {
 	if err != nil {
 		return nil, err
 	}
+	stakeTrie, err := ds.stakeTrie.Clone()
+	if err != nil {
+		return nil, err
+	}
 	validators, err := TraverseDynasty(dynastyTrie)
 	if err != nil {
 		return nil, err
@@ -255,6 +316,7 @@ func (ds *State) NextState(elapsedSecond int64) (state.ConsensusState, error) {
 		timeStamp: ds.timeStamp + elapsedSecond,
 
 		dynastyTrie: dynastyTrie,
+		stakeTrie:   stakeTrie,
 
 		chain:     ds.chain,
 		consensus: ds.consensus,
@@ -24,6 +24,7 @@ import (
 	"github.com/nebulasio/go-nebulas/consensus/pb"
 
 	"github.com/nebulasio/go-nebulas/core"
+	"github.com/nebulasio/go-nebulas/core/pb"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
 
 	"github.com/stretchr/testify/assert"
@@ -131,6 +132,56 @@ func TestNewGenesisBlock(t *testing.T) {
 	assert.Equal(t, dumpConf.TokenDistribution, conf.TokenDistribution)
 }
 
+func TestNewGenesisBlockWithStakes(t *testing.T) {
+	neb := mockNeb(t)
+	neb.genesis.Consensus.Dpos.Stakes = []*corepb.GenesisConsensusDposStake{
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[0], Value: "10000000000000000000000"},
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[1], Value: "2000"},
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[3], Value: "1"},
+	}
+	neb.storage, _ = storage.NewMemoryStorage()
+	chain, err := core.NewBlockChain(neb)
+	assert.Nil(t, err)
+	assert.Nil(t, chain.Setup(neb))
+
+	consensusState, err := neb.consensus.NewState(chain.GenesisBlock().ConsensusRoot(), neb.storage)
+	assert.Nil(t, err)
+	for i, v := range []string{"10000000000000000000000", "2000", "0", "1"} {
+		addr, err := core.AddressParse(DefaultOpenDynasty[i])
+		assert.Nil(t, err)
+		stake, err := consensusState.Stake(addr.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, v, stake.String())
+	}
+
+	dumpConf, err := core.DumpGenesis(chain)
+	assert.Nil(t, err)
+	assert.Equal(t, neb.genesis.Consensus.Dpos.Stakes, dumpConf.Consensus.Dpos.Stakes)
+	assert.Nil(t, core.CheckGenesisConfByDB(dumpConf, neb.genesis))
+
+	conf := MockGenesisConf()
+	err = core.CheckGenesisConfByDB(dumpConf, conf)
+	assert.Equal(t, core.ErrGenesisNotEqualStakeLenInDB, err)
+	conf.Consensus.Dpos.Stakes = []*corepb.GenesisConsensusDposStake{
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[0], Value: "10000000000000000000000"},
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[1], Value: "2001"},
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[3], Value: "1"},
+	}
+	err = core.CheckGenesisConfByDB(dumpConf, conf)
+	assert.Equal(t, core.ErrGenesisNotEqualStakeInDB, err)
+}
+
+func TestGenesisStakeExceedDistribution(t *testing.T) {
+	neb := mockNeb(t)
+	neb.genesis.Consensus.Dpos.Stakes = []*corepb.GenesisConsensusDposStake{
+		&corepb.GenesisConsensusDposStake{Address: DefaultOpenDynasty[0], Value: "10000000000000000000001"},
+	}
+	neb.storage, _ = storage.NewMemoryStorage()
+	chain, err := core.NewBlockChain(neb)
+	assert.Nil(t, err)
+	assert.Equal(t, core.ErrGenesisStakeExceedDistribution, chain.Setup(neb))
+}
+
 func TestCheckGenesisAndDBConsense(t *testing.T) {
 	conf := MockGenesisConf()
 	chain := mockNeb(t).chain
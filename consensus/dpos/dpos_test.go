@@ -19,6 +19,7 @@
 package dpos
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -143,15 +144,42 @@ func (nvm *mockNvm) CreateEngine(block *core.Block, tx *core.Transaction, owner,
 func (nvm *mockNvm) SetEngineExecutionLimits(limitsOfExecutionInstructions uint64) error {
 	return nil
 }
+func (nvm *mockNvm) SetEngineExecutionContext(ctx context.Context) error {
+	return nil
+}
 func (nvm *mockNvm) DeployAndInitEngine(source, sourceType, args string) (string, error) {
 	return "", nil
 }
 func (nvm *mockNvm) CallEngine(source, sourceType, function, args string) (string, error) {
 	return "", nil
 }
+func (nvm *mockNvm) ReceiveEngine(source, sourceType string) (string, error) {
+	return "", nil
+}
+func (nvm *mockNvm) LoadEngine(source, sourceType string) (string, error) {
+	return "", nil
+}
 func (nvm *mockNvm) ExecutionInstructions() (uint64, error) {
 	return uint64(100), nil
 }
+func (nvm *mockNvm) StorageBytesWritten() (uint64, error) {
+	return uint64(0), nil
+}
+func (nvm *mockNvm) Savepoint(name string) error {
+	return nil
+}
+func (nvm *mockNvm) RollbackToSavepoint(name string) error {
+	return nil
+}
+func (nvm *mockNvm) SetEngineReadOnly(readOnly bool) error {
+	return nil
+}
+func (nvm *mockNvm) SetEngineStorageReadTrace(enabled bool) error {
+	return nil
+}
+func (nvm *mockNvm) StorageKeysRead() ([]string, error) {
+	return nil, nil
+}
 func (nvm *mockNvm) DisposeEngine() {
 
 }
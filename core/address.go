@@ -20,8 +20,11 @@ package core
 
 import (
 	"strings"
+	"sync"
 
+	"github.com/nebulasio/go-nebulas/crypto"
 	"github.com/nebulasio/go-nebulas/crypto/hash"
+	"github.com/nebulasio/go-nebulas/crypto/keystore"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
 )
 
@@ -71,6 +74,44 @@ type Address struct {
 	address byteutils.Hash
 }
 
+// the human-readable address prefix of each chain id, mixed into the address checksum so that
+// an address of one network fails to parse on another. Chains not set use the legacy address with no prefix.
+var (
+	networkAddressPrefixes     = make(map[uint32]string)
+	networkAddressPrefixesLock sync.RWMutex
+)
+
+// SetNetworkAddressPrefix set the address prefix of the chain, "" resets it to the legacy address.
+// ErrAmbiguousNetworkPrefix is returned if the prefix can be taken for a hex address or overlaps
+// the prefix of another chain.
+func SetNetworkAddressPrefix(chainID uint32, prefix string) error {
+	networkAddressPrefixesLock.Lock()
+	defer networkAddressPrefixesLock.Unlock()
+
+	if prefix == "" {
+		delete(networkAddressPrefixes, chainID)
+		return nil
+	}
+	if strings.HasPrefix(prefix, "0x") || strings.Trim(prefix, "0123456789abcdefABCDEF") == "" {
+		return ErrAmbiguousNetworkPrefix
+	}
+	for id, v := range networkAddressPrefixes {
+		if id != chainID && (strings.HasPrefix(v, prefix) || strings.HasPrefix(prefix, v)) {
+			return ErrAmbiguousNetworkPrefix
+		}
+	}
+	networkAddressPrefixes[chainID] = prefix
+	return nil
+}
+
+// NetworkAddressPrefix return the address prefix of the chain, "" if not set.
+func NetworkAddressPrefix(chainID uint32) string {
+	networkAddressPrefixesLock.RLock()
+	defer networkAddressPrefixesLock.RUnlock()
+
+	return networkAddressPrefixes[chainID]
+}
+
 // Bytes returns address bytes
 func (a *Address) Bytes() []byte {
 	return a.address
@@ -81,6 +122,11 @@ func (a *Address) String() string {
 	return a.address.String()
 }
 
+// Encode returns address string under the network prefix.
+func (a *Address) Encode(prefix string) string {
+	return prefix + a.address.String()
+}
+
 // Equals compare two Address. True is equal, otherwise false.
 func (a *Address) Equals(b *Address) bool {
 	return a.address.Equals(b.address)
@@ -88,18 +134,50 @@ func (a *Address) Equals(b *Address) bool {
 
 // NewAddress create new #Address according to data bytes.
 func NewAddress(s []byte) (*Address, error) {
+	return NewAddressWithPrefix(s, "")
+}
+
+// NewAddressWithPrefix create new #Address of the network prefix according to data bytes.
+func NewAddressWithPrefix(s []byte, prefix string) (*Address, error) {
 	if len(s) != AddressDataLength {
 		return nil, ErrInvalidAddressDataLength
 	}
 
-	cs := checkSum(s)
+	cs := checkSum(prefix, s)
 	return &Address{address: append(s, cs...)}, nil
 }
 
 // NewAddressFromPublicKey return new address from publickey bytes
 func NewAddressFromPublicKey(s []byte) (*Address, error) {
+	return NewAddressFromPublicKeyWithPrefix(s, "")
+}
+
+// NewAddressFromPublicKeyWithPrefix return new address of the network prefix from publickey bytes
+func NewAddressFromPublicKeyWithPrefix(s []byte, prefix string) (*Address, error) {
 	hash := hash.Sha3256(s)
-	return NewAddress(hash[len(hash)-AddressDataLength:])
+	return NewAddressWithPrefix(hash[len(hash)-AddressDataLength:], prefix)
+}
+
+// AddressFromSignature return the address signed the hash, recovered from the raw signature without a tx.
+func AddressFromSignature(alg keystore.Algorithm, hash, sign byteutils.Hash) (*Address, error) {
+	return addressFromSignatureWithPrefix(alg, hash, sign, "")
+}
+
+// addressFromSignatureWithPrefix return the address of the network prefix signed the hash.
+func addressFromSignatureWithPrefix(alg keystore.Algorithm, hash, sign byteutils.Hash, prefix string) (*Address, error) {
+	signature, err := crypto.NewSignature(alg)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := signature.RecoverPublic(hash, sign)
+	if err != nil {
+		return nil, err
+	}
+	pubdata, err := pub.Encoded()
+	if err != nil {
+		return nil, err
+	}
+	return NewAddressFromPublicKeyWithPrefix(pubdata, prefix)
 }
 
 // NewContractAddressFromHash return new contract address from bytes.
@@ -111,36 +189,161 @@ func NewContractAddressFromHash(s []byte) (*Address, error) {
 	return NewAddress(s[len(s)-AddressDataLength:])
 }
 
-// AddressParse parse address string.
+// AddressParse parse address string of any network, the network prefix is optional.
 func AddressParse(s string) (*Address, error) {
-	if strings.HasPrefix(s, "0x") {
-		s = s[2:]
-	}
-	r, err := byteutils.FromHex(s)
+	r, err := addressHexParse(trimNetworkPrefix(s))
 	if err != nil {
-		return nil, ErrInvalidAddress
+		return nil, err
 	}
 
 	return AddressParseFromBytes(r)
 }
 
-// AddressParseFromBytes parse address from bytes.
+// AddressParseWithPrefix parse address string of the network prefix,
+// ErrWrongNetworkAddress is returned if the address belongs to another network.
+func AddressParseWithPrefix(s string, prefix string) (*Address, error) {
+	if prefix != "" && strings.HasPrefix(s, prefix) {
+		s = s[len(prefix):]
+	} else if trimNetworkPrefix(s) != s {
+		return nil, ErrWrongNetworkAddress
+	}
+	r, err := addressHexParse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return AddressParseFromBytesWithPrefix(r, prefix)
+}
+
+// AddressParseFromBytes parse address from bytes of any network.
 func AddressParseFromBytes(s []byte) (*Address, error) {
 	if len(s) != AddressLength {
 		return nil, ErrInvalidAddress
 	}
 
-	data := s[:AddressDataLength]
-	cs := s[AddressDataLength:AddressLength]
-	dcs := checkSum(data)
+	if !verifyCheckSum("", s) {
+		networkAddressPrefixesLock.RLock()
+		defer networkAddressPrefixesLock.RUnlock()
 
-	if !byteutils.Equal(cs, dcs) {
+		for _, prefix := range networkAddressPrefixes {
+			if verifyCheckSum(prefix, s) {
+				return &Address{address: s}, nil
+			}
+		}
 		return nil, ErrInvalidAddress
 	}
 
 	return &Address{address: s}, nil
 }
 
-func checkSum(data []byte) []byte {
-	return hash.Sha3256(data)[:AddressChecksumLength]
+// AddressParseFromBytesWithPrefix parse address from bytes of the network prefix,
+// ErrWrongNetworkAddress is returned if the address belongs to another network.
+func AddressParseFromBytesWithPrefix(s []byte, prefix string) (*Address, error) {
+	if len(s) != AddressLength {
+		return nil, ErrInvalidAddress
+	}
+
+	if !verifyCheckSum(prefix, s) {
+		if _, err := AddressParseFromBytes(s); err == nil {
+			return nil, ErrWrongNetworkAddress
+		}
+		return nil, ErrInvalidAddress
+	}
+
+	return &Address{address: s}, nil
+}
+
+func addressHexParse(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") {
+		s = s[2:]
+	}
+	r, err := byteutils.FromHex(s)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+	return r, nil
+}
+
+// trimNetworkPrefix trim the set network prefix from the address string if any,
+// the prefixes never overlap so at most one of them matches.
+func trimNetworkPrefix(s string) string {
+	networkAddressPrefixesLock.RLock()
+	defer networkAddressPrefixesLock.RUnlock()
+
+	for _, prefix := range networkAddressPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
+
+// chainReservedAddresses the system addresses which txs of a chain cannot transfer value to from the height on
+type chainReservedAddresses struct {
+	addrs  map[byteutils.HexHash]bool
+	height uint64
+}
+
+var (
+	reservedAddresses     = make(map[uint32]*chainReservedAddresses)
+	reservedAddressesLock sync.RWMutex
+)
+
+// SetReservedAddresses set the system addresses which txs of the chain cannot transfer value to, from
+// the block of height on, 0 means never. GenesisCoinbase is always reserved with addrs.
+// Only the value of txs is checked, gas fee and block reward are credited to accounts directly.
+func SetReservedAddresses(chainID uint32, addrs []*Address, height uint64) {
+	reservedAddressesLock.Lock()
+	defer reservedAddressesLock.Unlock()
+
+	if height == 0 {
+		delete(reservedAddresses, chainID)
+		return
+	}
+	reserved := &chainReservedAddresses{
+		addrs:  map[byteutils.HexHash]bool{GenesisCoinbase.address.Hex(): true},
+		height: height,
+	}
+	for _, addr := range addrs {
+		reserved.addrs[addr.address.Hex()] = true
+	}
+	reservedAddresses[chainID] = reserved
+}
+
+// IsReservedAddress return if the address is reserved on the chain in the block of height
+func IsReservedAddress(chainID uint32, height uint64, addr *Address) bool {
+	reservedAddressesLock.RLock()
+	defer reservedAddressesLock.RUnlock()
+
+	reserved, ok := reservedAddresses[chainID]
+	if !ok || height < reserved.height {
+		return false
+	}
+	return reserved.addrs[addr.address.Hex()]
+}
+
+// StaticAddressBlacklist the AddressBlacklist of a fixed address set, such as loaded from a file.
+type StaticAddressBlacklist map[byteutils.HexHash]bool
+
+// NewStaticAddressBlacklist with addrs
+func NewStaticAddressBlacklist(addrs ...*Address) StaticAddressBlacklist {
+	blacklist := make(StaticAddressBlacklist)
+	for _, addr := range addrs {
+		blacklist[addr.address.Hex()] = true
+	}
+	return blacklist
+}
+
+// IsBlacklisted return if the address is in the blacklist
+func (blacklist StaticAddressBlacklist) IsBlacklisted(block *Block, addr *Address) bool {
+	return blacklist[addr.address.Hex()]
+}
+
+// checkSum mix the network prefix in, the legacy checksum is kept for no prefix.
+func checkSum(prefix string, data []byte) []byte {
+	return hash.Sha3256([]byte(prefix), data)[:AddressChecksumLength]
+}
+
+func verifyCheckSum(prefix string, s []byte) bool {
+	return byteutils.Equal(s[AddressDataLength:AddressLength], checkSum(prefix, s[:AddressDataLength]))
 }
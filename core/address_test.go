@@ -23,8 +23,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nebulasio/go-nebulas/core/pb"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
 	"github.com/nebulasio/go-nebulas/crypto/keystore/secp256k1"
+	"github.com/nebulasio/go-nebulas/util"
+	"github.com/nebulasio/go-nebulas/util/byteutils"
+	"github.com/stretchr/testify/assert"
 )
 
 func mockAddress() *Address {
@@ -189,3 +193,100 @@ func TestNewContractAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressNetworkPrefix(t *testing.T) {
+	mainnet, testnet := uint32(1), uint32(1001)
+	assert.Nil(t, SetNetworkAddressPrefix(mainnet, "n1"))
+	assert.Nil(t, SetNetworkAddressPrefix(testnet, "t1"))
+	defer func() {
+		assert.Nil(t, SetNetworkAddressPrefix(mainnet, ""))
+		assert.Nil(t, SetNetworkAddressPrefix(testnet, ""))
+	}()
+	assert.Equal(t, "n1", NetworkAddressPrefix(mainnet))
+
+	// prefix overlapping another network or a hex address is ambiguous.
+	for _, prefix := range []string{"n", "n12", "0x", "ab12"} {
+		assert.Equal(t, ErrAmbiguousNetworkPrefix, SetNetworkAddressPrefix(2, prefix), prefix)
+	}
+	assert.Equal(t, "", NetworkAddressPrefix(2))
+	assert.Nil(t, SetNetworkAddressPrefix(mainnet, "n1"))
+
+	// the same bytes encode to distinct addresses under two prefixes.
+	data := []byte{223, 77, 34, 97, 20, 18, 19, 45, 62, 155, 211, 34, 248, 46, 41, 64, 103, 78, 193, 188}
+	mainAddr, err := NewAddressWithPrefix(append([]byte{}, data...), "n1")
+	assert.Nil(t, err)
+	testAddr, err := NewAddressWithPrefix(append([]byte{}, data...), "t1")
+	assert.Nil(t, err)
+	assert.False(t, mainAddr.Equals(testAddr))
+	assert.Equal(t, "n1", mainAddr.Encode("n1")[:2])
+	assert.Equal(t, "t1", testAddr.Encode("t1")[:2])
+
+	got, err := AddressParseWithPrefix(mainAddr.Encode("n1"), "n1")
+	assert.Nil(t, err)
+	assert.True(t, mainAddr.Equals(got))
+	got, err = AddressParseFromBytesWithPrefix(testAddr.Bytes(), "t1")
+	assert.Nil(t, err)
+	assert.True(t, testAddr.Equals(got))
+
+	// the network agnostic parse accepts both.
+	_, err = AddressParse(mainAddr.Encode("n1"))
+	assert.Nil(t, err)
+	_, err = AddressParseFromBytes(testAddr.Bytes())
+	assert.Nil(t, err)
+
+	// cross-network parse is rejected.
+	_, err = AddressParseWithPrefix(testAddr.Encode("t1"), "n1")
+	assert.Equal(t, ErrWrongNetworkAddress, err)
+	_, err = AddressParseWithPrefix(testAddr.Encode(""), "n1")
+	assert.Equal(t, ErrWrongNetworkAddress, err)
+	_, err = AddressParseFromBytesWithPrefix(mainAddr.Bytes(), "t1")
+	assert.Equal(t, ErrWrongNetworkAddress, err)
+	legacy, err := NewAddress(append([]byte{}, data...))
+	assert.Nil(t, err)
+	_, err = AddressParseFromBytesWithPrefix(legacy.Bytes(), "n1")
+	assert.Equal(t, ErrWrongNetworkAddress, err)
+	_, err = AddressParseFromBytesWithPrefix(append(append([]byte{}, data...), 0, 0, 0, 0), "n1")
+	assert.Equal(t, ErrInvalidAddress, err)
+
+	// tx from and to must be addresses of the tx chain network.
+	tx, err := NewTransaction(testnet, testAddr, testAddr, util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	assert.Nil(t, new(Transaction).FromProto(msg))
+	msg.(*corepb.Transaction).ChainId = mainnet
+	assert.Equal(t, ErrWrongNetworkAddress, new(Transaction).FromProto(msg))
+}
+
+func TestAddressFromSignature(t *testing.T) {
+	// a secp256k1 triple of the key sha3_256("nebulas") signing sha3_256("hello nebulas").
+	privdata, _ := byteutils.FromHex("77bf6aaa1445aafcb4d6ffe3803d868e3b92cb7663a57d0d48fc3c2bbde35f23")
+	hash, _ := byteutils.FromHex("5e57289d768bbc109d265668e4a04af634d85d4789fd3cb35454addba9b6f5a5")
+	sign, _ := byteutils.FromHex("6cdf673895712d3e41bdeedf996b085a3f35afb607edab2203708151b5b390c07f8034a1e82810276f0158eff07f85bd7a38ca447d72ba915116b1598a89f21b01")
+
+	addr, err := AddressFromSignature(keystore.SECP256K1, hash, sign)
+	assert.Nil(t, err)
+	assert.Equal(t, "1f917b08b5506266a7325889c119a544bef15d027b5df902", addr.String())
+
+	// same as the address of the key's public key.
+	priv := new(secp256k1.PrivateKey)
+	assert.Nil(t, priv.Decode(privdata))
+	pubdata, err := priv.PublicKey().Encoded()
+	assert.Nil(t, err)
+	want, err := NewAddressFromPublicKey(pubdata)
+	assert.Nil(t, err)
+	assert.True(t, want.Equals(addr))
+
+	// another hash recovers another address.
+	tampered := append([]byte{}, hash...)
+	tampered[0] ^= 0xff
+	other, err := AddressFromSignature(keystore.SECP256K1, tampered, sign)
+	if err == nil {
+		assert.False(t, want.Equals(other))
+	}
+
+	_, err = AddressFromSignature(keystore.Algorithm(0), hash, sign)
+	assert.NotNil(t, err)
+	_, err = AddressFromSignature(keystore.SECP256K1, hash, sign[:64])
+	assert.NotNil(t, err)
+}
@@ -19,17 +19,21 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/nebulasio/go-nebulas/crypto"
+	"github.com/nebulasio/go-nebulas/crypto/hash"
 
 	"github.com/nebulasio/go-nebulas/consensus/pb"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
 
 	"github.com/gogo/protobuf/proto"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/nebulasio/go-nebulas/common/trie"
 	"github.com/nebulasio/go-nebulas/core/pb"
 	"github.com/nebulasio/go-nebulas/core/state"
@@ -50,6 +54,9 @@ var (
 	// rule: 3% per year, 3,000,000. 1 block per 5 seconds
 	// value: 10^8 * 3% / (365*24*3600/5) * 10^18 ≈ 16 * 3% * 10*18 = 48 * 10^16
 	BlockReward, _ = util.NewUint128FromString("480000000000000000")
+
+	// BlockGasLimit max gas of all txs in a block: 5 * 10 ** 11
+	BlockGasLimit, _ = util.NewUint128FromString("500000000000")
 )
 
 // BlockHeader of a block
@@ -61,11 +68,13 @@ type BlockHeader struct {
 	stateRoot     byteutils.Hash
 	txsRoot       byteutils.Hash
 	eventsRoot    byteutils.Hash
+	receiptsRoot  byteutils.Hash
 	consensusRoot *consensuspb.ConsensusRoot
 
 	coinbase  *Address
 	timestamp int64
 	chainID   uint32
+	baseFee   *util.Uint128 // gas price burned per gas of txs, nil means 0
 
 	// sign
 	alg  keystore.Algorithm
@@ -74,16 +83,26 @@ type BlockHeader struct {
 
 // ToProto converts domain BlockHeader to proto BlockHeader
 func (b *BlockHeader) ToProto() (proto.Message, error) {
+	var baseFee []byte
+	if b.baseFee != nil {
+		fee, err := b.baseFee.ToFixedSizeByteSlice()
+		if err != nil {
+			return nil, err
+		}
+		baseFee = fee
+	}
 	return &corepb.BlockHeader{
 		Hash:          b.hash,
 		ParentHash:    b.parentHash,
 		StateRoot:     b.stateRoot,
 		TxsRoot:       b.txsRoot,
 		EventsRoot:    b.eventsRoot,
+		ReceiptsRoot:  b.receiptsRoot,
 		ConsensusRoot: b.consensusRoot,
 		Coinbase:      b.coinbase.address,
 		Timestamp:     b.timestamp,
 		ChainId:       b.chainID,
+		BaseFee:       baseFee,
 		Alg:           uint32(b.alg),
 		Sign:          b.sign,
 	}, nil
@@ -97,6 +116,7 @@ func (b *BlockHeader) FromProto(msg proto.Message) error {
 		b.stateRoot = msg.StateRoot
 		b.txsRoot = msg.TxsRoot
 		b.eventsRoot = msg.EventsRoot
+		b.receiptsRoot = msg.ReceiptsRoot
 		if msg.ConsensusRoot == nil {
 			return ErrInvalidProtoToBlockHeader
 		}
@@ -108,6 +128,14 @@ func (b *BlockHeader) FromProto(msg proto.Message) error {
 		b.coinbase = coinbase
 		b.timestamp = msg.Timestamp
 		b.chainID = msg.ChainId
+		b.baseFee = nil
+		if len(msg.BaseFee) > 0 {
+			baseFee, err := util.NewUint128FromFixedSizeByteSlice(msg.BaseFee)
+			if err != nil {
+				return ErrInvalidProtoToBlockHeader
+			}
+			b.baseFee = baseFee
+		}
 		b.alg = keystore.Algorithm(msg.Alg)
 		b.sign = msg.Sign
 		return nil
@@ -126,8 +154,16 @@ type Block struct {
 	accState       state.AccountState
 	txsState       *trie.BatchTrie
 	eventsState    *trie.BatchTrie
+	receiptsState  *trie.BatchTrie // execution result of txs keyed by tx hash, separate from contract events
 	consensusState state.ConsensusState
 	txPool         *TransactionPool
+	gasUsed        *util.Uint128
+	eventIndex     int64 // index of the last event recorded in block, monotonic in execution order
+	// contract events & their bytes recorded in block, bounded by the event buffer limits of the chain
+	eventCount int
+	eventBytes int
+	// contract frames tracked in local simulation only, nil in consensus execution
+	callTracer *callTracer
 
 	storage      storage.Storage
 	eventEmitter *EventEmitter
@@ -179,6 +215,7 @@ func (block *Block) FromProto(msg proto.Message) error {
 			block.transactions[idx] = tx
 		}
 		block.height = msg.Height
+		block.gasUsed = util.NewUint128()
 		return nil
 	}
 	return ErrInvalidProtoToBlock
@@ -198,6 +235,10 @@ func NewBlock(chainID uint32, coinbase *Address, parent *Block) (*Block, error)
 	if err != nil {
 		return nil, err
 	}
+	receiptsState, err := parent.receiptsState.Clone()
+	if err != nil {
+		return nil, err
+	}
 	consensusState, err := parent.consensusState.Clone()
 	if err != nil {
 		return nil, err
@@ -208,6 +249,7 @@ func NewBlock(chainID uint32, coinbase *Address, parent *Block) (*Block, error)
 			coinbase:      coinbase,
 			timestamp:     time.Now().Unix(),
 			chainID:       chainID,
+			baseFee:       parent.header.baseFee,
 			consensusRoot: &consensuspb.ConsensusRoot{},
 		},
 		transactions:   make(Transactions, 0),
@@ -215,8 +257,10 @@ func NewBlock(chainID uint32, coinbase *Address, parent *Block) (*Block, error)
 		accState:       accState,
 		txsState:       txsState,
 		eventsState:    eventsState,
+		receiptsState:  receiptsState,
 		consensusState: consensusState,
 		txPool:         parent.txPool,
+		gasUsed:        util.NewUint128(),
 		height:         parent.height + 1,
 		sealed:         false,
 		storage:        parent.storage,
@@ -277,6 +321,31 @@ func (block *Block) SetTimestamp(timestamp int64) {
 	block.header.timestamp = timestamp
 }
 
+// RandomSeed return the deterministic random seed of the tx in block for contracts, derived from
+// the parent block hash, height and tx hash. The hash of block itself is unknown until its txs are
+// executed, so the seed is identical for the producer and all validators executing the same block.
+func (block *Block) RandomSeed(txHash byteutils.Hash) byteutils.Hash {
+	return hash.Sha3256(block.ParentHash(), byteutils.FromUint64(block.height), txHash)
+}
+
+// BaseFee return the gas price burned per gas of txs in block.
+func (block *Block) BaseFee() *util.Uint128 {
+	if block.header.baseFee == nil {
+		return util.NewUint128()
+	}
+	return block.header.baseFee
+}
+
+// SetBaseFee set the base fee, it's inherited from the parent block by default.
+func (block *Block) SetBaseFee(baseFee *util.Uint128) {
+	if block.sealed {
+		logging.VLog().WithFields(logrus.Fields{
+			"block": block,
+		}).Fatal("Sealed block can't be changed.")
+	}
+	block.header.baseFee = baseFee
+}
+
 // Hash return block hash.
 func (block *Block) Hash() byteutils.Hash {
 	return block.header.hash
@@ -302,6 +371,11 @@ func (block *Block) EventsRoot() byteutils.Hash {
 	return block.header.eventsRoot
 }
 
+// ReceiptsRoot return receipts root hash.
+func (block *Block) ReceiptsRoot() byteutils.Hash {
+	return block.header.receiptsRoot
+}
+
 // ConsensusRoot return consensus root
 func (block *Block) ConsensusRoot() *consensuspb.ConsensusRoot {
 	return block.header.consensusRoot
@@ -343,6 +417,9 @@ func (block *Block) LinkParentBlock(chain *BlockChain, parentBlock *Block) error
 	if block.eventsState, err = parentBlock.eventsState.Clone(); err != nil {
 		return ErrCloneEventsState
 	}
+	if block.receiptsState, err = parentBlock.receiptsState.Clone(); err != nil {
+		return ErrCloneReceiptsState
+	}
 
 	elapsedSecond := block.Timestamp() - parentBlock.Timestamp()
 	consensusState, err := parentBlock.consensusState.NextState(elapsedSecond)
@@ -365,6 +442,7 @@ func (block *Block) begin() {
 	block.accState.Begin()
 	block.txsState.Begin()
 	block.eventsState.Begin()
+	block.receiptsState.Begin()
 	block.consensusState.Begin()
 }
 
@@ -372,6 +450,7 @@ func (block *Block) commit() {
 	block.accState.Commit()
 	block.txsState.Commit()
 	block.eventsState.Commit()
+	block.receiptsState.Commit()
 	block.consensusState.Commit()
 }
 
@@ -379,6 +458,7 @@ func (block *Block) rollback() {
 	block.accState.Rollback()
 	block.txsState.Rollback()
 	block.eventsState.Rollback()
+	block.receiptsState.Rollback()
 	block.consensusState.Rollback()
 }
 
@@ -556,6 +636,7 @@ func (block *Block) Seal() error {
 	}
 	block.header.txsRoot = block.txsState.RootHash()
 	block.header.eventsRoot = block.eventsState.RootHash()
+	block.header.receiptsRoot = block.receiptsState.RootHash()
 	if block.header.consensusRoot, err = block.consensusState.RootHash(); err != nil {
 		return err
 	}
@@ -640,8 +721,12 @@ func (block *Block) VerifyIntegrity(chainID uint32, consensus Consensus) error {
 	}
 
 	// verify transactions integrity.
+	var signers *lru.Cache
+	if block.txPool != nil && block.txPool.bc != nil {
+		signers = block.txPool.bc.signatureCache
+	}
 	for _, tx := range block.transactions {
-		if err := tx.VerifyIntegrity(block.header.chainID); err != nil {
+		if err := tx.verifyIntegrity(block.header.chainID, signers); err != nil {
 			logging.VLog().WithFields(logrus.Fields{
 				"tx":  tx,
 				"err": err,
@@ -649,6 +734,14 @@ func (block *Block) VerifyIntegrity(chainID uint32, consensus Consensus) error {
 			metricsInvalidBlock.Inc(1)
 			return err
 		}
+		if err := tx.verifyLowS(block.height); err != nil {
+			logging.VLog().WithFields(logrus.Fields{
+				"tx":  tx,
+				"err": err,
+			}).Debug("Failed to verify tx's signature malleability.")
+			metricsInvalidBlock.Inc(1)
+			return err
+		}
 	}
 
 	// verify the block is acceptable by consensus.
@@ -664,6 +757,54 @@ func (block *Block) VerifyIntegrity(chainID uint32, consensus Consensus) error {
 	return nil
 }
 
+// VerifyTransactionsAndRoot verify the integrity of all the txs in block and the txs root recomputed
+// from them on the parent txs root together, a bad signature or a tampered txs root fails in one call.
+func (block *Block) VerifyTransactionsAndRoot(chainID uint32) error {
+	if block.parentBlock == nil {
+		return ErrNilArgument
+	}
+
+	errs, err := Transactions(block.transactions).BatchVerifyIntegrity(chainID, "", nil)
+	if err != nil {
+		return err
+	}
+	for i, err := range errs {
+		if err != nil {
+			logging.VLog().WithFields(logrus.Fields{
+				"tx":  block.transactions[i],
+				"err": err,
+			}).Debug("Failed to verify tx's integrity.")
+			return err
+		}
+	}
+
+	txsState, err := block.parentBlock.txsState.Clone()
+	if err != nil {
+		return ErrCloneTxsState
+	}
+	for _, tx := range block.transactions {
+		pbTx, err := tx.ToProto()
+		if err != nil {
+			return err
+		}
+		txBytes, err := proto.Marshal(pbTx)
+		if err != nil {
+			return err
+		}
+		if _, err := txsState.Put(tx.hash, txBytes); err != nil {
+			return err
+		}
+	}
+	if !byteutils.Equal(txsState.RootHash(), block.TxsRoot()) {
+		logging.VLog().WithFields(logrus.Fields{
+			"expect": block.TxsRoot(),
+			"actual": byteutils.Hex(txsState.RootHash()),
+		}).Debug("Failed to verify txs root.")
+		return ErrTxsRootMismatch
+	}
+	return nil
+}
+
 // verifyState return state verify result.
 func (block *Block) verifyState() error {
 	// verify state root.
@@ -697,6 +838,15 @@ func (block *Block) verifyState() error {
 		return ErrInvalidBlockEventsRoot
 	}
 
+	// verify receipts root.
+	if !byteutils.Equal(block.receiptsState.RootHash(), block.ReceiptsRoot()) {
+		logging.VLog().WithFields(logrus.Fields{
+			"expect": block.ReceiptsRoot(),
+			"actual": byteutils.Hex(block.receiptsState.RootHash()),
+		}).Debug("Failed to verify receipts.")
+		return ErrInvalidBlockReceiptsRoot
+	}
+
 	// verify transaction root.
 	consensusRoot, err := block.consensusState.RootHash()
 	if err != nil {
@@ -715,13 +865,66 @@ func (block *Block) verifyState() error {
 
 // Execute block and return result.
 func (block *Block) execute() error {
+	return block.executeTransactions(context.Background(), nil)
+}
+
+// TxExecResult the execution result of a tx in block
+type TxExecResult struct {
+	Hash    byteutils.Hash
+	GasUsed *util.Uint128
+	Err     error
+}
+
+// ExecuteTransactionsStream execute the block as VerifyExecution without verifying the state,
+// the result of each tx is sent to the returned channel in tx order as it executes.
+// The execution stops early once ctx is done, then the block state is rolled back.
+// The channel is closed when the execution ends.
+func (block *Block) ExecuteTransactionsStream(ctx context.Context) (<-chan *TxExecResult, error) {
+	if ctx == nil {
+		return nil, ErrNilArgument
+	}
+
+	results := make(chan *TxExecResult)
+	go func() {
+		defer close(results)
+
+		block.begin()
+		err := block.executeTransactions(ctx, func(result *TxExecResult) {
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			block.rollback()
+			return
+		}
+		block.commit()
+	}()
+	return results, nil
+}
+
+// executeTransactions execute the txs of block in order, the result of each tx is passed to emit if not nil.
+func (block *Block) executeTransactions(ctx context.Context, emit func(*TxExecResult)) error {
 	startAt := time.Now().UnixNano()
+	block.gasUsed = util.NewUint128()
+	block.eventIndex = 0
+	block.eventCount, block.eventBytes = 0, 0
 	block.rewardCoinbase()
 
 	start := time.Now().UnixNano()
+	senders := make(map[byteutils.HexHash]int64)
 	for _, tx := range block.transactions {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
 		metricsTxExecute.Mark(1)
+		senders[tx.from.address.Hex()]++
 
+		gasBefore := block.gasUsed
 		giveback, err := block.executeTransaction(tx)
 		if giveback {
 			err := block.txPool.Push(tx)
@@ -729,6 +932,13 @@ func (block *Block) execute() error {
 				return err
 			}
 		}
+		if emit != nil {
+			gasUsed, subErr := block.gasUsed.Sub(gasBefore)
+			if subErr != nil {
+				return subErr
+			}
+			emit(&TxExecResult{Hash: tx.hash, GasUsed: gasUsed, Err: err})
+		}
 		if err != nil {
 			return err
 		}
@@ -745,10 +955,74 @@ func (block *Block) execute() error {
 	endAt := time.Now().UnixNano()
 	metricsBlockVerifiedTime.Update(endAt - startAt)
 	metricsTxsInBlock.Update(txs)
+	for sender, n := range senders {
+		metricsTxPerSenderCounter.Inc(sender, n)
+		metricsTxsPerSenderInBlock.Update(n)
+	}
 
 	return nil
 }
 
+// BlockExecSummary the result of a block dry run
+type BlockExecSummary struct {
+	GasUsed   *util.Uint128
+	FailedTxs int // txs rejected by execution or executed with failed status
+	StateRoot byteutils.Hash
+}
+
+// DryRun execute the txs of block on a clone of its state and return the summary, nothing is committed.
+// Unlike VerifyExecution, a rejected tx is counted as failed and skipped, the run goes on with the next tx.
+func (block *Block) DryRun() (*BlockExecSummary, error) {
+	sim, err := block.Clone()
+	if err != nil {
+		return nil, err
+	}
+	sim.begin()
+	defer sim.rollback()
+
+	sim.gasUsed = util.NewUint128()
+	sim.eventIndex = 0
+	sim.eventCount, sim.eventBytes = 0, 0
+	if err := sim.rewardCoinbase(); err != nil {
+		return nil, err
+	}
+
+	summary := &BlockExecSummary{}
+	for _, tx := range sim.transactions {
+		// each tx runs on a clone, the state changed by a rejected tx is dropped with it.
+		txBlock, err := sim.Clone()
+		if err != nil {
+			return nil, err
+		}
+		txBlock.begin()
+		if _, err := txBlock.executeTransaction(tx); err != nil {
+			logging.VLog().WithFields(logrus.Fields{
+				"tx":  tx,
+				"err": err,
+			}).Debug("Dry run rejected tx.")
+			txBlock.rollback()
+			summary.FailedTxs++
+			continue
+		}
+		txBlock.commit()
+		sim.Merge(txBlock)
+
+		txEvent, err := sim.fetchExecutionResult(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		if txEvent.Status == TxExecutionFailed {
+			summary.FailedTxs++
+		}
+	}
+
+	summary.GasUsed = sim.gasUsed
+	if summary.StateRoot, err = sim.accState.RootHash(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
 // GetBalance returns balance for the given address on this block.
 func (block *Block) GetBalance(address byteutils.Hash) (*util.Uint128, error) {
 	cblock, err := block.Clone()
@@ -775,34 +1049,45 @@ func (block *Block) GetNonce(address byteutils.Hash) (uint64, error) {
 	return account.Nonce(), nil
 }
 
+// AccountNonce returns the nonce of addr on this block, 0 means addr never sent a transaction.
+func (block *Block) AccountNonce(addr *Address) (uint64, error) {
+	if addr == nil {
+		return 0, ErrNilArgument
+	}
+	return block.GetNonce(addr.address)
+}
+
 // RecordEvent record event's topic and data with txHash
 func (block *Block) RecordEvent(txHash byteutils.Hash, topic, data string) error {
 	event := &Event{Topic: topic, Data: data}
 	return block.recordEvent(txHash, event)
 }
 
-func (block *Block) recordEvent(txHash byteutils.Hash, event *Event) error {
-	iter, err := block.eventsState.Iterator(txHash)
-	if err != nil && err != storage.ErrKeyNotFound {
-		return err
+// RecordContractEvent record event's topic and data emitted by the contract with txHash
+func (block *Block) RecordContractEvent(txHash byteutils.Hash, contract *Address, topic, data string) error {
+	if contract == nil {
+		return ErrNilArgument
 	}
-	cnt := int64(0)
-	if err != storage.ErrKeyNotFound {
-		exist, err := iter.Next()
-		if err != nil {
-			return err
-		}
-		for exist {
-			cnt++
-			exist, err = iter.Next()
-			if err != nil {
-				return err
-			}
+	event := &Event{Topic: topic, Data: data, Contract: contract.String()}
+	return block.recordEvent(txHash, event)
+}
+
+func (block *Block) recordEvent(txHash byteutils.Hash, event *Event) error {
+	// contract events are buffered in block up to the limits, the chain events are bounded by txs.
+	if strings.HasPrefix(event.Topic, TopicContractEventNameSpace) {
+		maxEvents, maxBytes := EventBufferLimitsOf(block.header.chainID)
+		count, size := block.eventCount+1, block.eventBytes+len(event.Topic)+len(event.Data)
+		if count > maxEvents || size > maxBytes {
+			return ErrEventBufferFull
 		}
+		block.eventCount, block.eventBytes = count, size
 	}
-	cnt++
-	key := append(txHash, byteutils.FromInt64(cnt)...)
-	bytes, err := json.Marshal(event)
+
+	// all events are recorded in events trie whatever the topic filter of node, the events root is the same on all nodes.
+	// the block event index in key keeps the events of a tx in record order.
+	index := block.eventIndex + 1
+	key := append(append([]byte{}, txHash...), byteutils.FromInt64(index)...)
+	bytes, err := json.Marshal(&indexedEvent{Event: event, Index: index})
 	if err != nil {
 		return err
 	}
@@ -810,12 +1095,94 @@ func (block *Block) recordEvent(txHash byteutils.Hash, event *Event) error {
 	if err != nil {
 		return err
 	}
+	block.eventIndex = index
 	return nil
 }
 
+// recordReceipt record the execution result of tx in receipts trie
+func (block *Block) recordReceipt(txHash byteutils.Hash, receipt []byte) error {
+	_, err := block.receiptsState.Put(txHash, receipt)
+	return err
+}
+
+// MerkleProof proves the receipt of a tx is in the receipts root of a block
+type MerkleProof struct {
+	TxHash  byteutils.Hash
+	Receipt []byte           // json of TransactionEvent
+	Path    trie.MerkleProof // nodes from the receipts root to the receipt
+}
+
+// ProveReceipt return the merkle proof of the tx receipt in block receipts root.
+func (block *Block) ProveReceipt(txHash byteutils.Hash) (*MerkleProof, error) {
+	path, err := block.receiptsState.Prove(txHash)
+	if err == trie.ErrNotFound {
+		return nil, ErrTransactionReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MerkleProof{TxHash: txHash, Receipt: path.Value(), Path: path}, nil
+}
+
+// VerifyReceiptProof check the proof against the receipts root and return the proved receipt.
+func VerifyReceiptProof(receiptsRoot byteutils.Hash, proof *MerkleProof) (*TransactionEvent, error) {
+	if proof == nil {
+		return nil, ErrNilArgument
+	}
+	mem, err := storage.NewMemoryStorage()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := trie.NewTrie(nil, mem)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifier.Verify(receiptsRoot, proof.TxHash, proof.Path); err != nil {
+		return nil, ErrInvalidReceiptProof
+	}
+	receipt := proof.Path.Value()
+	if receipt == nil || !byteutils.Equal(receipt, proof.Receipt) {
+		return nil, ErrInvalidReceiptProof
+	}
+
+	txEvent := new(TransactionEvent)
+	if err := json.Unmarshal(receipt, txEvent); err != nil {
+		return nil, ErrInvalidReceiptProof
+	}
+	if txEvent.Hash != proof.TxHash.String() {
+		return nil, ErrInvalidReceiptProof
+	}
+	return txEvent, nil
+}
+
+func (block *Block) eventTopicFilter() *EventTopicFilter {
+	if block.txPool == nil || block.txPool.bc == nil {
+		return nil
+	}
+	return block.txPool.bc.eventTopicFilter
+}
+
+// indexedEvent the event stored in block with its index
+type indexedEvent struct {
+	*Event
+	Index int64
+}
+
 // FetchEvents fetch events by txHash.
 func (block *Block) FetchEvents(txHash byteutils.Hash) ([]*Event, error) {
+	indexed, err := block.fetchIndexedEvents(txHash)
+	if err != nil {
+		return nil, err
+	}
 	events := []*Event{}
+	for _, event := range indexed {
+		events = append(events, event.Event)
+	}
+	return events, nil
+}
+
+func (block *Block) fetchIndexedEvents(txHash byteutils.Hash) ([]*indexedEvent, error) {
+	events := []*indexedEvent{}
 	iter, err := block.eventsState.Iterator(txHash)
 	if err != nil && err != storage.ErrKeyNotFound {
 		return nil, err
@@ -825,13 +1192,17 @@ func (block *Block) FetchEvents(txHash byteutils.Hash) ([]*Event, error) {
 		if err != nil {
 			return nil, err
 		}
+		filter := block.eventTopicFilter()
 		for exist {
-			event := new(Event)
+			event := &indexedEvent{Event: new(Event)}
 			err = json.Unmarshal(iter.Value(), event)
 			if err != nil {
 				return nil, err
 			}
-			events = append(events, event)
+			// filtered contract events stay in events trie, they are only not served by the node.
+			if filter.Serve(event.Topic) {
+				events = append(events, event)
+			}
 			exist, err = iter.Next()
 			if err != nil {
 				return nil, err
@@ -841,6 +1212,66 @@ func (block *Block) FetchEvents(txHash byteutils.Hash) ([]*Event, error) {
 	return events, nil
 }
 
+// OrderedEvents return all the events of the txs in block, sorted by the order they were recorded.
+func (block *Block) OrderedEvents() ([]*Event, error) {
+	indexed := []*indexedEvent{}
+	for _, tx := range block.transactions {
+		events, err := block.fetchIndexedEvents(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		indexed = append(indexed, events...)
+	}
+	sort.SliceStable(indexed, func(i, j int) bool {
+		return indexed[i].Index < indexed[j].Index
+	})
+
+	events := []*Event{}
+	for _, event := range indexed {
+		events = append(events, event.Event)
+	}
+	return events, nil
+}
+
+// EventsByContract return the events emitted by the contract in the txs of block, sorted by the order they were recorded.
+func (block *Block) EventsByContract(addr *Address) ([]*Event, error) {
+	if addr == nil {
+		return nil, ErrNilArgument
+	}
+
+	contract := addr.String()
+	indexed := []*indexedEvent{}
+	for _, tx := range block.transactions {
+		events, err := block.fetchIndexedEvents(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if event.Contract == contract {
+				indexed = append(indexed, event)
+			}
+		}
+	}
+	sort.SliceStable(indexed, func(i, j int) bool {
+		return indexed[i].Index < indexed[j].Index
+	})
+
+	events := []*Event{}
+	for _, event := range indexed {
+		events = append(events, event.Event)
+	}
+	return events, nil
+}
+
+// EventsByTxHash return the events recorded under the txHash, in the order they were recorded.
+func (block *Block) EventsByTxHash(txHash byteutils.Hash) ([]*Event, error) {
+	if txHash == nil {
+		return nil, ErrNilArgument
+	}
+
+	return block.FetchEvents(txHash)
+}
+
 func (block *Block) rewardCoinbase() error {
 	coinbaseAddr := block.header.coinbase.address
 	coinbaseAcc, err := block.accState.GetOrCreateUserAccount(coinbaseAddr)
@@ -884,7 +1315,10 @@ func (block *Block) acceptTransaction(tx *Transaction) error {
 	if _, err := block.txsState.Put(tx.hash, txBytes); err != nil {
 		return err
 	}
-	// incre nonce
+	// incre nonce, the wallet contract manages the nonce of nonceless tx.
+	if tx.nonceless {
+		return nil
+	}
 	fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
 	if err != nil {
 		return err
@@ -894,6 +1328,11 @@ func (block *Block) acceptTransaction(tx *Transaction) error {
 }
 
 func (block *Block) checkTransaction(tx *Transaction) (bool, error) {
+	// nonce of nonceless tx is checked by the wallet contract in execution.
+	if tx.nonceless {
+		return false, nil
+	}
+
 	// check nonce
 	fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
 	if err != nil {
@@ -917,17 +1356,169 @@ func (block *Block) executeTransaction(tx *Transaction) (bool, error) {
 		return giveback, err
 	}
 
-	if _, err := tx.VerifyExecution(block); err != nil {
+	// check block gas limit before execution, giveback tx to be packed in next block.
+	if tx.gasLimit.Cmp(block.RemainingGas()) > 0 {
+		return true, ErrBlockOutOfGas
+	}
+
+	gasUsed, err := tx.VerifyExecution(block)
+	if err == ErrTransactionNotYetValid {
+		// giveback time-locked tx to be packed in later block.
+		return true, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// gasUsed never exceeds tx.gasLimit, the block stays in its gas limit.
+	blockGasUsed, err := block.gasUsed.Add(gasUsed)
+	if err != nil {
 		return false, err
 	}
 
 	if err := block.acceptTransaction(tx); err != nil {
 		return false, err
 	}
+	block.gasUsed = blockGasUsed
 
 	return false, nil
 }
 
+// GasUsed return the total gas used by txs in the block.
+func (block *Block) GasUsed() *util.Uint128 {
+	return block.gasUsed
+}
+
+// TotalFees return the tip paid to the coinbase by the txs executed in block, the gas count of each tx
+// is read from its result event. The base fee is burned and nothing is charged in gasless mode.
+func (block *Block) TotalFees() (*util.Uint128, error) {
+	fees := util.NewUint128()
+	if IsGaslessMode(block.header.chainID) {
+		return fees, nil
+	}
+	for _, tx := range block.transactions {
+		txEvent, err := block.fetchExecutionResult(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+
+		gasUsed, err := util.NewUint128FromString(txEvent.GasCount)
+		if err != nil {
+			return nil, err
+		}
+		tip, err := tx.tipPerGas(block.BaseFee())
+		if err != nil {
+			return nil, err
+		}
+		fee, err := gasUsed.Mul(tip)
+		if err != nil {
+			return nil, err
+		}
+		if fees, err = fees.Add(fee); err != nil {
+			return nil, err
+		}
+	}
+	return fees, nil
+}
+
+// TransactionReceipt is a tx in block joined with its execution result
+type TransactionReceipt struct {
+	Transaction *Transaction
+	Event       *TransactionEvent
+}
+
+// TransactionReceipts return the txs in block with their execution results
+func (block *Block) TransactionReceipts() ([]*TransactionReceipt, error) {
+	receipts := make([]*TransactionReceipt, 0, len(block.transactions))
+	for _, tx := range block.transactions {
+		txEvent, err := block.fetchExecutionResult(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, &TransactionReceipt{Transaction: tx, Event: txEvent})
+	}
+	return receipts, nil
+}
+
+// fetchExecutionResult return the decoded execution result event of the tx
+func (block *Block) fetchExecutionResult(txHash byteutils.Hash) (*TransactionEvent, error) {
+	events, err := block.FetchEvents(txHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range events {
+		if v.Topic == TopicTransactionExecutionResult {
+			txEvent := new(TransactionEvent)
+			if err := json.Unmarshal([]byte(v.Data), txEvent); err != nil {
+				return nil, err
+			}
+			return txEvent, nil
+		}
+	}
+	return nil, ErrTransactionResultEventNotFound
+}
+
+// AccumulatedGas return the sum of gas used recorded in the execution result of each tx in block,
+// ErrGasAccumulationOverflow is returned if the sum overflows.
+func (block *Block) AccumulatedGas() (*util.Uint128, error) {
+	total := util.NewUint128()
+	for _, tx := range block.transactions {
+		txEvent, err := block.fetchExecutionResult(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		gasUsed, err := util.NewUint128FromString(txEvent.GasCount)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := total.Add(gasUsed)
+		if err != nil {
+			logging.VLog().WithFields(logrus.Fields{
+				"block": block,
+				"tx":    tx.hash,
+				"total": total,
+				"gas":   gasUsed,
+			}).Debug("Failed to accumulate gas used.")
+			return nil, ErrGasAccumulationOverflow
+		}
+		total = sum
+	}
+	return total, nil
+}
+
+// DeployedContracts return the addresses of contracts deployed by the txs of block in tx order,
+// failed deploys are skipped.
+func (block *Block) DeployedContracts() ([]*Address, error) {
+	var contracts []*Address
+	for _, tx := range block.transactions {
+		if tx.Type() != TxPayloadDeployType {
+			continue
+		}
+		txEvent, err := block.fetchExecutionResult(tx.hash)
+		if err != nil {
+			return nil, err
+		}
+		if txEvent.Status != TxExecutionSuccess {
+			continue
+		}
+		contract, err := tx.GenerateContractAddress()
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, contract)
+	}
+	return contracts, nil
+}
+
+// RemainingGas return the gas left for txs before reaching BlockGasLimit.
+func (block *Block) RemainingGas() *util.Uint128 {
+	remaining, err := BlockGasLimit.Sub(block.gasUsed)
+	if err != nil {
+		return util.NewUint128()
+	}
+	return remaining
+}
+
 // Dynasty return the validators in current dynasty
 func (block *Block) Dynasty() ([]byteutils.Hash, error) {
 	return block.consensusState.Dynasty()
@@ -996,10 +1587,19 @@ func HashBlock(block *Block) (byteutils.Hash, error) {
 	hasher.Write(block.StateRoot())
 	hasher.Write(block.TxsRoot())
 	hasher.Write(block.EventsRoot())
+	hasher.Write(block.ReceiptsRoot())
 	hasher.Write(consensusRoot)
 	hasher.Write(block.header.coinbase.address)
 	hasher.Write(byteutils.FromInt64(block.header.timestamp))
 	hasher.Write(byteutils.FromUint32(block.header.chainID))
+	// only blocks with base fee mix it in, keep the hash of other blocks unchanged.
+	if block.BaseFee().Cmp(util.NewUint128()) > 0 {
+		baseFee, err := block.BaseFee().ToFixedSizeByteSlice()
+		if err != nil {
+			return nil, err
+		}
+		hasher.Write(baseFee)
+	}
 
 	for _, tx := range block.transactions {
 		hasher.Write(tx.Hash())
@@ -1021,23 +1621,7 @@ func HashPbBlock(pbBlock *corepb.Block) byteutils.Hash { //ToAdd nil check
 
 // RecoverMiner return miner from block
 func RecoverMiner(block *Block) (*Address, error) {
-	signature, err := crypto.NewSignature(keystore.Algorithm(block.Alg()))
-	if err != nil {
-		return nil, err
-	}
-	pub, err := signature.RecoverPublic(block.Hash(), block.Signature())
-	if err != nil {
-		return nil, err
-	}
-	pubdata, err := pub.Encoded()
-	if err != nil {
-		return nil, err
-	}
-	addr, err := NewAddressFromPublicKey(pubdata)
-	if err != nil {
-		return nil, err
-	}
-	return addr, nil
+	return AddressFromSignature(block.Alg(), block.Hash(), block.Signature())
 }
 
 // LoadBlockFromStorage return a block from storage
@@ -1070,6 +1654,10 @@ func LoadBlockFromStorage(hash byteutils.Hash, chain *BlockChain) (*Block, error
 	if err != nil {
 		return nil, err
 	}
+	block.receiptsState, err = trie.NewBatchTrie(block.ReceiptsRoot(), chain.storage)
+	if err != nil {
+		return nil, err
+	}
 	consensusState, err := chain.consensusHandler.NewState(block.ConsensusRoot(), chain.storage)
 	if err != nil {
 		return nil, err
@@ -1083,6 +1671,62 @@ func LoadBlockFromStorage(hash byteutils.Hash, chain *BlockChain) (*Block, error
 	return block, nil
 }
 
+// forkPreState return an unsealed copy of block on the state of its parent, the state is backed by
+// an overlay of the chain storage, so that executing on it never mutates the chain storage.
+func (block *Block) forkPreState(chain *BlockChain) (*Block, error) {
+	parent := chain.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, ErrBlockNotFound
+	}
+	overlay, err := storage.NewOverlayStorage(chain.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	accState, err := state.NewAccountState(parent.StateRoot(), overlay)
+	if err != nil {
+		return nil, err
+	}
+	txsState, err := trie.NewBatchTrie(parent.TxsRoot(), overlay)
+	if err != nil {
+		return nil, err
+	}
+	eventsState, err := trie.NewBatchTrie(parent.EventsRoot(), overlay)
+	if err != nil {
+		return nil, err
+	}
+	receiptsState, err := trie.NewBatchTrie(parent.ReceiptsRoot(), overlay)
+	if err != nil {
+		return nil, err
+	}
+	parentConsensusState, err := chain.consensusHandler.NewState(parent.ConsensusRoot(), overlay)
+	if err != nil {
+		return nil, err
+	}
+	consensusState, err := parentConsensusState.NextState(block.Timestamp() - parent.Timestamp())
+	if err != nil {
+		return nil, err
+	}
+
+	header := *block.header
+	return &Block{
+		header:         &header,
+		transactions:   append(Transactions{}, block.transactions...),
+		parentBlock:    parent,
+		accState:       accState,
+		txsState:       txsState,
+		eventsState:    eventsState,
+		receiptsState:  receiptsState,
+		consensusState: consensusState,
+		txPool:         chain.txPool,
+		gasUsed:        util.NewUint128(),
+		height:         block.height,
+		sealed:         false,
+		storage:        overlay,
+		nvm:            chain.nvm.Clone(),
+	}, nil
+}
+
 // Clone return new Block, with cloned state.
 func (block *Block) Clone() (*Block, error) {
 	accState, err := block.accState.Clone()
@@ -1100,6 +1744,11 @@ func (block *Block) Clone() (*Block, error) {
 		return nil, ErrCloneEventsState
 	}
 
+	receiptsState, err := block.receiptsState.Clone()
+	if err != nil {
+		return nil, ErrCloneReceiptsState
+	}
+
 	consensusState, err := block.consensusState.Clone()
 	if err != nil {
 		return nil, err
@@ -1118,6 +1767,11 @@ func (block *Block) Clone() (*Block, error) {
 		height:         block.height,
 		parentBlock:    block.parentBlock,
 		txPool:         block.txPool,
+		gasUsed:        block.gasUsed,
+		eventIndex:     block.eventIndex,
+		eventCount:     block.eventCount,
+		eventBytes:     block.eventBytes,
+		callTracer:     block.callTracer,
 		storage:        block.storage,
 		eventEmitter:   block.eventEmitter,
 		nvm:            nvm,
@@ -1125,17 +1779,56 @@ func (block *Block) Clone() (*Block, error) {
 		accState:       accState,
 		txsState:       txsState,
 		eventsState:    eventsState,
+		receiptsState:  receiptsState,
 		consensusState: consensusState,
 	}, nil
 }
 
+// enterContract push the contract frame to the call tracer in local simulation,
+// the returned func pops it. It does nothing in consensus execution.
+// The engine of the frame must be created before, and disposed after the returned func,
+// as the storage keys read in the frame are taken from it in storage trace.
+func (block *Block) enterContract(contract *Address, function string) func() {
+	if block.callTracer == nil {
+		return func() {}
+	}
+	block.callTracer.enter(contract, function)
+	if !block.callTracer.traceStorage {
+		return block.callTracer.exit
+	}
+
+	// the trace is diagnostic only, its failure never fails the execution.
+	if err := block.nvm.SetEngineStorageReadTrace(true); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"contract": contract,
+			"err":      err,
+		}).Debug("Failed to trace storage reads.")
+	}
+	return func() {
+		keys, err := block.nvm.StorageKeysRead()
+		if err != nil {
+			logging.VLog().WithFields(logrus.Fields{
+				"contract": contract,
+				"err":      err,
+			}).Debug("Failed to fetch storage reads.")
+		}
+		block.callTracer.read(contract, keys)
+		block.callTracer.exit()
+	}
+}
+
 // Merge merge the state from source block.
 func (block *Block) Merge(source *Block) {
 	block.accState = source.accState
 	block.txsState = source.txsState
 	block.eventsState = source.eventsState
+	block.receiptsState = source.receiptsState
 	block.consensusState = source.consensusState
 	block.transactions = source.transactions
+	block.gasUsed = source.gasUsed
+	block.eventIndex = source.eventIndex
+	block.eventCount = source.eventCount
+	block.eventBytes = source.eventBytes
 }
 
 // Dispose dispose block.
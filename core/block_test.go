@@ -19,7 +19,12 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +36,7 @@ import (
 	pb "github.com/gogo/protobuf/proto"
 	"github.com/nebulasio/go-nebulas/core/pb"
 	"github.com/nebulasio/go-nebulas/crypto"
+	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
 	"github.com/nebulasio/go-nebulas/crypto/keystore/secp256k1"
 	"github.com/nebulasio/go-nebulas/neblet/pb"
@@ -116,6 +122,9 @@ func (cs *mockConsensusState) NextState(elapsed int64) (state.ConsensusState, er
 
 func (cs *mockConsensusState) Dynasty() ([]byteutils.Hash, error) { return nil, nil }
 func (cs *mockConsensusState) DynastyRoot() byteutils.Hash        { return nil }
+func (cs *mockConsensusState) Stake(byteutils.Hash) (*util.Uint128, error) {
+	return util.NewUint128(), nil
+}
 
 type mockConsensus struct {
 	chain *BlockChain
@@ -296,29 +305,99 @@ func (n *mockNeb) SetGenesis(genesis *corepb.Genesis) {
 }
 
 type mockNvm struct {
+	storageBytesWritten uint64
+	result              string
+	callErr             error
+	initErr             error
+	calls               *int
+	readOnly            *bool
+	instructions        uint64 // instructions the call needs, 0 means 100 and never out of gas
+	limit               uint64
+	onCall              func(block *Block) error // called in each call with the block of the engine, e.g. to simulate a nested contract call, its error fails the call
+	block               *Block
+	storageKeys         []string // storage keys the call reads
+	traceStorage        bool
+	executionCtx        context.Context
+	busy                bool // call computes until the execution context is done
 }
 
 func (nvm *mockNvm) CreateEngine(block *Block, tx *Transaction, owner, contract state.Account, state state.AccountState) error {
+	nvm.block = block
+	nvm.traceStorage = false
 	return nil
 }
 func (nvm *mockNvm) SetEngineExecutionLimits(limitsOfExecutionInstructions uint64) error {
+	nvm.limit = limitsOfExecutionInstructions
+	return nil
+}
+func (nvm *mockNvm) SetEngineExecutionContext(ctx context.Context) error {
+	nvm.executionCtx = ctx
 	return nil
 }
 func (nvm *mockNvm) DeployAndInitEngine(source, sourceType, args string) (string, error) {
-	return "", nil
+	return "", nvm.initErr
 }
 func (nvm *mockNvm) CallEngine(source, sourceType, function, args string) (string, error) {
-	return "", nil
+	if nvm.calls != nil {
+		*nvm.calls++
+	}
+	if nvm.instructions > 0 && nvm.limit < nvm.instructions {
+		return "", ErrInsufficientGas
+	}
+	if nvm.busy && nvm.executionCtx != nil && nvm.executionCtx.Done() != nil {
+		<-nvm.executionCtx.Done()
+		return "", ErrExecutionDeadlineExceeded
+	}
+	if nvm.onCall != nil {
+		if err := nvm.onCall(nvm.block); err != nil {
+			return "", err
+		}
+	}
+	return nvm.result, nvm.callErr
+}
+func (nvm *mockNvm) ReceiveEngine(source, sourceType string) (string, error) {
+	return nvm.CallEngine(source, sourceType, ContractReceiveFunction, "")
+}
+func (nvm *mockNvm) LoadEngine(source, sourceType string) (string, error) {
+	return nvm.CallEngine(source, sourceType, "", "")
 }
 func (nvm *mockNvm) ExecutionInstructions() (uint64, error) {
+	if nvm.instructions > 0 {
+		return nvm.instructions, nil
+	}
 	return uint64(100), nil
 }
+func (nvm *mockNvm) StorageBytesWritten() (uint64, error) {
+	return nvm.storageBytesWritten, nil
+}
+func (nvm *mockNvm) Savepoint(name string) error {
+	return nil
+}
+func (nvm *mockNvm) RollbackToSavepoint(name string) error {
+	return nil
+}
+func (nvm *mockNvm) SetEngineReadOnly(readOnly bool) error {
+	if nvm.readOnly != nil {
+		*nvm.readOnly = readOnly
+	}
+	return nil
+}
+func (nvm *mockNvm) SetEngineStorageReadTrace(enabled bool) error {
+	nvm.traceStorage = enabled
+	return nil
+}
+func (nvm *mockNvm) StorageKeysRead() ([]string, error) {
+	if !nvm.traceStorage {
+		return nil, nil
+	}
+	return nvm.storageKeys, nil
+}
 func (nvm *mockNvm) DisposeEngine() {
 
 }
 
 func (nvm *mockNvm) Clone() Engine {
-	return &mockNvm{}
+	return &mockNvm{storageBytesWritten: nvm.storageBytesWritten, result: nvm.result, callErr: nvm.callErr, initErr: nvm.initErr, calls: nvm.calls, readOnly: nvm.readOnly, instructions: nvm.instructions, onCall: nvm.onCall, storageKeys: nvm.storageKeys}
 }
 
 func testNeb(t *testing.T) *mockNeb {
@@ -394,6 +473,10 @@ func TestBlock(t *testing.T) {
 						1,
 						util.NewUint128(),
 						util.NewUint128(),
+						0,
+						nil,
+						false,
+						nil,
 						keystore.SECP256K1,
 						nil,
 					},
@@ -408,6 +491,10 @@ func TestBlock(t *testing.T) {
 						2,
 						util.NewUint128(),
 						util.NewUint128(),
+						0,
+						nil,
+						false,
+						nil,
 						keystore.SECP256K1,
 						nil,
 					},
@@ -572,7 +659,291 @@ func TestBlock_CollectTransactions(t *testing.T) {
 	// mock net message
 	block, _ = deepCopyBlock(block)
 	assert.Equal(t, block.LinkParentBlock(bc, bc.tailBlock), nil)
+	executed := metricsTxPerSenderCounter.Count(from.address.Hex())
 	assert.Nil(t, block.VerifyExecution())
+	// the 5 txs of the sender are counted.
+	assert.Equal(t, executed+5, metricsTxPerSenderCounter.Count(from.address.Hex()))
+	assert.Equal(t, int64(0), metricsTxPerSenderCounter.Count(to.address.Hex()))
+}
+
+func TestBlock_GasLimit(t *testing.T) {
+	bc := testNeb(t).chain
+
+	from := mockAddress()
+
+	block, err := bc.NewBlock(from)
+	assert.Nil(t, err)
+	assert.Equal(t, BlockGasLimit, block.RemainingGas())
+
+	// allow only two min gas txs in the block.
+	limit := BlockGasLimit
+	defer func() { BlockGasLimit = limit }()
+	BlockGasLimit, _ = MinGasCountPerTransaction.Mul(util.NewUint128FromUint(2))
+
+	// tx of gasLimit above the remaining gas is given back before execution, even if it would use less.
+	gasLimit, _ := util.NewUint128FromInt(30000)
+	tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, gasLimit)
+	signTransaction(t, tx)
+	block.begin()
+	_, err = block.executeTransaction(tx)
+	assert.Nil(t, err)
+	block.commit()
+	tx, _ = NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), 2, TxPayloadBinaryType, nil, TransactionGasPrice, gasLimit)
+	signTransaction(t, tx)
+	block.begin()
+	giveback, err := block.executeTransaction(tx)
+	assert.Equal(t, ErrBlockOutOfGas, err)
+	assert.True(t, giveback)
+	_, err = block.fetchExecutionResult(tx.hash)
+	assert.Equal(t, ErrTransactionResultEventNotFound, err)
+	block.rollback()
+	assert.Equal(t, MinGasCountPerTransaction, block.GasUsed())
+
+	for nonce := uint64(2); nonce <= 3; nonce++ {
+		tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), nonce, TxPayloadBinaryType, nil, TransactionGasPrice, MinGasCountPerTransaction)
+		signTransaction(t, tx)
+
+		block.begin()
+		giveback, err := block.executeTransaction(tx)
+		if nonce <= 2 {
+			assert.Nil(t, err)
+			block.commit()
+			continue
+		}
+		assert.Equal(t, ErrBlockOutOfGas, err)
+		assert.True(t, giveback)
+		block.rollback()
+	}
+	assert.Equal(t, BlockGasLimit, block.GasUsed())
+	assert.Equal(t, "0", block.RemainingGas().String())
+}
+
+func TestBlock_TotalFees(t *testing.T) {
+	bc := testNeb(t).chain
+
+	from := mockAddress()
+
+	block, err := bc.NewBlock(from)
+	assert.Nil(t, err)
+
+	fees, err := block.TotalFees()
+	assert.Nil(t, err)
+	assert.Equal(t, "0", fees.String())
+
+	// the base fee is burned, only the tip is paid to the coinbase.
+	baseFee, _ := util.NewUint128FromInt(1000)
+	block.header.baseFee = baseFee
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	block.begin()
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	block.commit()
+
+	gasLimit, _ := util.NewUint128FromInt(200000)
+	wanted := util.NewUint128()
+	for nonce := uint64(1); nonce <= 3; nonce++ {
+		// different data length and gas price make different fees.
+		gasPrice, _ := TransactionGasPrice.Mul(util.NewUint128FromUint(nonce))
+		data := bytes.Repeat([]byte("x"), int(nonce*10))
+		tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), nonce, TxPayloadBinaryType, data, gasPrice, gasLimit)
+		signTransaction(t, tx)
+
+		block.begin()
+		_, err := block.executeTransaction(tx)
+		assert.Nil(t, err)
+		block.commit()
+		block.transactions = append(block.transactions, tx)
+
+		gasUsed, err := tx.GasCountOfTxBase()
+		assert.Nil(t, err)
+		tip, err := gasPrice.Sub(baseFee)
+		assert.Nil(t, err)
+		fee, err := gasUsed.Mul(tip)
+		assert.Nil(t, err)
+		wanted, err = wanted.Add(fee)
+		assert.Nil(t, err)
+	}
+
+	fees, err = block.TotalFees()
+	assert.Nil(t, err)
+	assert.Equal(t, wanted.String(), fees.String())
+
+	// the legacy gas_used of result event still carries gasPrice * gas.
+	txEvent, err := block.fetchExecutionResult(block.transactions[0].hash)
+	assert.Nil(t, err)
+	gasUsed, err := block.transactions[0].GasCountOfTxBase()
+	assert.Nil(t, err)
+	assert.Equal(t, gasUsed.String(), txEvent.GasCount)
+	legacy, err := TransactionGasPrice.Mul(gasUsed)
+	assert.Nil(t, err)
+	assert.Equal(t, legacy.String(), txEvent.GasUsed)
+
+	// nothing is charged in gasless mode.
+	SetGaslessMode(bc.ChainID(), true)
+	defer SetGaslessMode(bc.ChainID(), false)
+	fees, err = block.TotalFees()
+	assert.Nil(t, err)
+	assert.Equal(t, "0", fees.String())
+}
+
+func TestBlock_BaseFee(t *testing.T) {
+	bc := testNeb(t).chain
+
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+	assert.Equal(t, "0", block.BaseFee().String())
+	hash, err := HashBlock(block)
+	assert.Nil(t, err)
+
+	baseFee, _ := util.NewUint128FromInt(100)
+	block.SetBaseFee(baseFee)
+	assert.Equal(t, baseFee, block.BaseFee())
+	feeHash, err := HashBlock(block)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, feeHash)
+
+	// base fee survives proto round trip.
+	msg, err := block.header.ToProto()
+	assert.Nil(t, err)
+	header := new(BlockHeader)
+	assert.Nil(t, header.FromProto(msg))
+	assert.Equal(t, "100", header.baseFee.String())
+
+	// child block inherits the base fee.
+	child, err := NewBlock(bc.ChainID(), mockAddress(), block)
+	assert.Nil(t, err)
+	assert.Equal(t, baseFee, child.BaseFee())
+}
+
+func TestBlock_RandomSeed(t *testing.T) {
+	bc := testNeb(t).chain
+
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+	txHash := byteutils.Hash(hash.Sha3256([]byte("tx1")))
+	seed := block.RandomSeed(txHash)
+	assert.Equal(t, 32, len(seed))
+
+	// identical on the validator executing the block decoded from proto.
+	assert.Nil(t, block.Seal())
+	msg, err := block.ToProto()
+	assert.Nil(t, err)
+	received := new(Block)
+	assert.Nil(t, received.FromProto(msg))
+	assert.Equal(t, seed, received.RandomSeed(txHash))
+
+	// differs between txs.
+	assert.NotEqual(t, seed, block.RandomSeed(hash.Sha3256([]byte("tx2"))))
+}
+
+func TestBlock_TransactionReceipts(t *testing.T) {
+	bc := testNeb(t).chain
+
+	from := mockAddress()
+
+	block, err := bc.NewBlock(from)
+	assert.Nil(t, err)
+
+	gasLimit, _ := util.NewUint128FromInt(200000)
+	payloadTypes := []string{TxPayloadBinaryType, "unknown", TxPayloadBinaryType}
+	for i, payloadType := range payloadTypes {
+		tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), uint64(i+1), payloadType, nil, TransactionGasPrice, gasLimit)
+		signTransaction(t, tx)
+
+		block.begin()
+		_, err := block.executeTransaction(tx)
+		assert.Nil(t, err)
+		block.commit()
+		block.transactions = append(block.transactions, tx)
+	}
+
+	receipts, err := block.TransactionReceipts()
+	assert.Nil(t, err)
+	assert.Equal(t, len(payloadTypes), len(receipts))
+	wantedStatus := []int8{TxExecutionSuccess, TxExecutionFailed, TxExecutionSuccess}
+	for i, receipt := range receipts {
+		assert.Equal(t, block.transactions[i], receipt.Transaction)
+		assert.Equal(t, receipt.Transaction.hash.String(), receipt.Event.Hash)
+		assert.Equal(t, wantedStatus[i], receipt.Event.Status)
+		assert.Equal(t, MinGasCountPerTransaction.String(), receipt.Event.GasCount)
+	}
+	assert.Equal(t, ErrInvalidTxPayloadType.Error(), receipts[1].Event.Error)
+}
+
+func TestBlock_DryRun(t *testing.T) {
+	bc := testNeb(t).chain
+	from, coinbase := mockAddress(), mockAddress()
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	value, _ := util.NewUint128FromInt(1)
+
+	var txs Transactions
+	payloadTypes := []string{TxPayloadBinaryType, "unknown", TxPayloadBinaryType}
+	for i, payloadType := range payloadTypes {
+		tx, err := NewTransaction(bc.ChainID(), from, mockAddress(), value, uint64(i+1), payloadType, nil, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		signTransaction(t, tx)
+		txs = append(txs, tx)
+	}
+	newBlock := func(txs Transactions) *Block {
+		block, err := NewBlock(bc.ChainID(), coinbase, bc.tailBlock)
+		assert.Nil(t, err)
+		block.begin()
+		acc, err := block.accState.GetOrCreateUserAccount(from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, acc.AddBalance(balance))
+		block.commit()
+		block.transactions = append(Transactions{}, txs...)
+		return block
+	}
+
+	block := newBlock(txs)
+	rootBefore, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	summary, err := block.DryRun()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.FailedTxs)
+	gasUsed, err := MinGasCountPerTransaction.Mul(util.NewUint128FromUint(uint64(len(txs))))
+	assert.Nil(t, err)
+	assert.Equal(t, gasUsed, summary.GasUsed)
+
+	// nothing is committed to the dry-run block.
+	root, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, rootBefore, root)
+	nonce, err := block.GetNonce(from.address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+
+	// the state root matches the committed execution.
+	expected := newBlock(txs)
+	expected.begin()
+	assert.Nil(t, expected.execute())
+	expected.commit()
+	expectedRoot, err := expected.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedRoot, summary.StateRoot)
+	assert.Equal(t, expected.GasUsed(), summary.GasUsed)
+
+	// the last tx is rejected as out of block gas before execution, its gasLimit is above the remaining gas.
+	limit := BlockGasLimit
+	defer func() { BlockGasLimit = limit }()
+	BlockGasLimit, err = TransactionMaxGas.Add(MinGasCountPerTransaction)
+	assert.Nil(t, err)
+	summary, err = newBlock(txs).DryRun()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, summary.FailedTxs)
+	gasUsed, err = MinGasCountPerTransaction.Mul(util.NewUint128FromUint(2))
+	assert.Nil(t, err)
+	assert.Equal(t, gasUsed, summary.GasUsed)
+
+	expected = newBlock(txs[:2])
+	expected.begin()
+	assert.Nil(t, expected.execute())
+	expected.commit()
+	expectedRoot, err = expected.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedRoot, summary.StateRoot)
 }
 
 func TestBlock_fetchEvents(t *testing.T) {
@@ -595,6 +966,404 @@ func TestBlock_fetchEvents(t *testing.T) {
 	}
 }
 
+func TestBlock_ExecuteTransactionsStream(t *testing.T) {
+	bc := testNeb(t).chain
+	from, coinbase := mockAddress(), mockAddress()
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	value, _ := util.NewUint128FromInt(1)
+
+	var txs Transactions
+	for nonce := uint64(1); nonce <= 5; nonce++ {
+		tx, err := NewTransaction(bc.ChainID(), from, mockAddress(), value, nonce, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		signTransaction(t, tx)
+		txs = append(txs, tx)
+	}
+	newBlock := func() *Block {
+		block, err := NewBlock(bc.ChainID(), coinbase, bc.tailBlock)
+		assert.Nil(t, err)
+		block.begin()
+		acc, err := block.accState.GetOrCreateUserAccount(from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, acc.AddBalance(balance))
+		block.commit()
+		block.transactions = append(Transactions{}, txs...)
+		return block
+	}
+
+	// results arrive in tx order, the state matches the non-streaming execution.
+	block := newBlock()
+	results, err := block.ExecuteTransactionsStream(context.Background())
+	assert.Nil(t, err)
+	var hashes []byteutils.Hash
+	for result := range results {
+		assert.Nil(t, result.Err)
+		assert.Equal(t, MinGasCountPerTransaction, result.GasUsed)
+		hashes = append(hashes, result.Hash)
+	}
+	assert.Equal(t, len(txs), len(hashes))
+	for i, tx := range txs {
+		assert.Equal(t, tx.hash, hashes[i])
+	}
+	nonce, err := block.GetNonce(from.address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(txs)), nonce)
+
+	expected := newBlock()
+	expected.begin()
+	assert.Nil(t, expected.execute())
+	expected.commit()
+	expectedRoot, err := expected.accState.RootHash()
+	assert.Nil(t, err)
+	root, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedRoot, root)
+
+	// cancellation halts execution, at most the executing tx is reported after cancel.
+	block = newBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err = block.ExecuteTransactionsStream(ctx)
+	assert.Nil(t, err)
+	<-results
+	cancel()
+	count := 1
+	for range results {
+		count++
+	}
+	assert.True(t, count <= 2)
+	nonce, err = block.GetNonce(from.address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+
+	_, err = block.ExecuteTransactionsStream(nil)
+	assert.Equal(t, ErrNilArgument, err)
+}
+
+func TestBlock_AccountNonce(t *testing.T) {
+	bc := testNeb(t).chain
+	from, fresh := mockAddress(), mockAddress()
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	value, _ := util.NewUint128FromInt(1)
+
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	block.begin()
+	acc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, acc.AddBalance(balance))
+	for nonce := uint64(1); nonce <= 3; nonce++ {
+		tx, err := NewTransaction(bc.ChainID(), from, mockAddress(), value, nonce, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		signTransaction(t, tx)
+		block.transactions = append(block.transactions, tx)
+	}
+	assert.Nil(t, block.execute())
+	block.commit()
+
+	nonce, err := block.AccountNonce(fresh)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), nonce)
+	nonce, err = block.AccountNonce(from)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	_, err = block.AccountNonce(nil)
+	assert.Equal(t, ErrNilArgument, err)
+}
+
+func TestBlock_AccumulatedGas(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	block.begin()
+	defer block.rollback()
+
+	total, err := block.AccumulatedGas()
+	assert.Nil(t, err)
+	assert.Equal(t, util.NewUint128(), total)
+
+	addTx := func(gasUsed *util.Uint128) {
+		tx, err := NewTransaction(bc.ChainID(), mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		tx.hash, err = HashTransaction(tx)
+		assert.Nil(t, err)
+		assert.Nil(t, tx.recordResultEvent(block, gasUsed, "", nil))
+		block.transactions = append(block.transactions, tx)
+	}
+	max, _ := util.NewUint128FromString("340282366920938463463374607431768211455")
+	almostMax, _ := util.NewUint128FromString("340282366920938463463374607431768211454")
+	one, _ := util.NewUint128FromInt(1)
+
+	// exactly reaching the max is fine.
+	addTx(almostMax)
+	addTx(one)
+	total, err = block.AccumulatedGas()
+	assert.Nil(t, err)
+	assert.Equal(t, max, total)
+
+	// one more gas overflows.
+	addTx(one)
+	_, err = block.AccumulatedGas()
+	assert.Equal(t, ErrGasAccumulationOverflow, err)
+
+	// tx without execution result.
+	tx, err := NewTransaction(bc.ChainID(), mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	tx.hash, err = HashTransaction(tx)
+	assert.Nil(t, err)
+	block.transactions = append(block.transactions[:1], tx)
+	_, err = block.AccumulatedGas()
+	assert.Equal(t, ErrTransactionResultEventNotFound, err)
+}
+
+func TestBlock_DeployedContracts(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+	nvm := &mockNvm{}
+	block.nvm = nvm
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	contracts, err := block.DeployedContracts()
+	assert.Nil(t, err)
+	assert.Empty(t, contracts)
+
+	execute := func(tx *Transaction) {
+		signTransaction(t, tx)
+
+		block.begin()
+		fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, fromAcc.AddBalance(balance))
+		_, err = block.executeTransaction(tx)
+		assert.Nil(t, err)
+		block.commit()
+		block.transactions = append(block.transactions, tx)
+	}
+
+	deployed := mockDeployTransaction(bc.ChainID(), 1)
+	execute(deployed)
+	execute(mockNormalTransaction(bc.ChainID(), 1))
+	nvm.initErr = errors.New("init failed")
+	execute(mockDeployTransaction(bc.ChainID(), 1))
+
+	wanted, err := deployed.GenerateContractAddress()
+	assert.Nil(t, err)
+	contracts, err = block.DeployedContracts()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Address{wanted}, contracts)
+}
+
+func TestBlock_VerifyTransactionsAndRoot(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+
+	signedTx := func() *Transaction {
+		tx := mockNormalTransaction(bc.ChainID(), 1)
+		signTransaction(t, tx)
+		return tx
+	}
+
+	block.begin()
+	for i := 0; i < 3; i++ {
+		tx := signedTx()
+		assert.Nil(t, block.acceptTransaction(tx))
+		block.transactions = append(block.transactions, tx)
+	}
+	block.commit()
+	assert.Nil(t, block.Seal())
+	assert.Nil(t, block.VerifyTransactionsAndRoot(bc.ChainID()))
+
+	// a swapped tx is well signed but diverges from the txs root.
+	original := block.transactions[1]
+	block.transactions[1] = signedTx()
+	assert.Equal(t, ErrTxsRootMismatch, block.VerifyTransactionsAndRoot(bc.ChainID()))
+
+	// a bad signature fails before the root is recomputed.
+	block.transactions[1] = original
+	original.value = util.NewUint128FromUint(1)
+	assert.Equal(t, ErrInvalidTransactionHash, block.VerifyTransactionsAndRoot(bc.ChainID()))
+}
+
+func TestBlock_ProveReceipt(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+
+	tx1 := &Transaction{hash: hash.Sha3256([]byte("tx1"))}
+	tx2 := &Transaction{hash: hash.Sha3256([]byte("tx2"))}
+	tx3 := &Transaction{hash: hash.Sha3256([]byte("tx3"))}
+	gasUsed, _ := util.NewUint128FromInt(20000)
+	block.begin()
+	assert.Nil(t, tx1.recordResultEvent(block, gasUsed, "", nil))
+	assert.Nil(t, tx2.recordResultEvent(block, gasUsed, "", ErrInsufficientBalance))
+	assert.Nil(t, tx3.recordResultEvent(block, gasUsed, "ok", nil))
+	block.commit()
+	assert.Nil(t, block.Seal())
+	assert.NotEqual(t, bc.tailBlock.ReceiptsRoot(), block.ReceiptsRoot())
+
+	proof, err := block.ProveReceipt(tx2.hash)
+	assert.Nil(t, err)
+	receipt, err := VerifyReceiptProof(block.ReceiptsRoot(), proof)
+	assert.Nil(t, err)
+	assert.Equal(t, tx2.hash.String(), receipt.Hash)
+	assert.Equal(t, int8(TxExecutionFailed), receipt.Status)
+	assert.Equal(t, gasUsed.String(), receipt.GasUsed)
+	assert.Equal(t, ErrInsufficientBalance.Error(), receipt.Error)
+
+	// receipts of contract events are not mixed in.
+	_, err = block.ProveReceipt(hash.Sha3256([]byte("tx4")))
+	assert.Equal(t, ErrTransactionReceiptNotFound, err)
+
+	// proof of another block root.
+	_, err = VerifyReceiptProof(bc.tailBlock.ReceiptsRoot(), proof)
+	assert.Equal(t, ErrInvalidReceiptProof, err)
+
+	// tampered receipt.
+	tampered := *proof
+	tampered.Receipt = []byte(strings.Replace(string(proof.Receipt), `"status":0`, `"status":1`, 1))
+	_, err = VerifyReceiptProof(block.ReceiptsRoot(), &tampered)
+	assert.Equal(t, ErrInvalidReceiptProof, err)
+
+	// proof of tx1 claimed as tx2.
+	proof1, err := block.ProveReceipt(tx1.hash)
+	assert.Nil(t, err)
+	proof1.TxHash = tx2.hash
+	_, err = VerifyReceiptProof(block.ReceiptsRoot(), proof1)
+	assert.Equal(t, ErrInvalidReceiptProof, err)
+
+	// the receipts root survives storage.
+	assert.Nil(t, bc.StoreBlockToStorage(block))
+	stored, err := LoadBlockFromStorage(block.Hash(), bc)
+	assert.Nil(t, err)
+	assert.Equal(t, block.ReceiptsRoot(), stored.ReceiptsRoot())
+	proof, err = stored.ProveReceipt(tx3.hash)
+	assert.Nil(t, err)
+	_, err = VerifyReceiptProof(block.ReceiptsRoot(), proof)
+	assert.Nil(t, err)
+}
+
+func TestBlock_EventTopicFilter(t *testing.T) {
+	bc := testNeb(t).chain
+	keep := TopicContractEventNameSpace + ".keep"
+	drop := TopicContractEventNameSpace + ".drop"
+	tx := &Transaction{hash: hash.Sha3256([]byte("tx"))}
+	records := []*Event{
+		{Topic: drop, Data: "1"},
+		{Topic: keep, Data: "2"},
+		{Topic: drop, Data: "3"},
+		{Topic: keep, Data: "4"},
+		{Topic: TopicTransactionExecutionResult, Data: "5"},
+	}
+
+	record := func() *Block {
+		block, err := bc.NewBlock(mockAddress())
+		assert.Nil(t, err)
+		block.begin()
+		for _, event := range records {
+			assert.Nil(t, block.RecordEvent(tx.hash, event.Topic, event.Data))
+		}
+		block.commit()
+		block.transactions = Transactions{tx}
+		return block
+	}
+
+	bc.SetEventTopicFilter(&EventTopicFilter{Allow: []string{keep}})
+	block := record()
+
+	// filtered events are not served.
+	served := []*Event{records[1], records[3], records[4]}
+	events, err := block.FetchEvents(tx.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, served, events)
+	events, err = block.OrderedEvents()
+	assert.Nil(t, err)
+	assert.Equal(t, served, events)
+
+	// the events root is the same with and without the filter.
+	bc.SetEventTopicFilter(nil)
+	events, err = block.FetchEvents(tx.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, records, events)
+	expected := record()
+	assert.Equal(t, expected.eventsState.RootHash(), block.eventsState.RootHash())
+
+	deny := &EventTopicFilter{Deny: []string{drop}}
+	assert.True(t, deny.Serve(keep))
+	assert.False(t, deny.Serve(drop))
+	assert.True(t, (*EventTopicFilter)(nil).Serve(drop))
+}
+
+func TestBlock_OrderedEvents(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+
+	tx1 := &Transaction{hash: []byte("tx1")}
+	tx2 := &Transaction{hash: []byte("tx2")}
+	tx3 := &Transaction{hash: []byte("tx3")}
+	records := []struct {
+		tx    *Transaction
+		event *Event
+	}{
+		{tx1, &Event{Topic: "chain.tx", Data: "1"}},
+		{tx2, &Event{Topic: "chain.tx", Data: "2"}},
+		{tx1, &Event{Topic: "chain.tx", Data: "3"}},
+		{tx3, &Event{Topic: "chain.tx", Data: "4"}},
+		{tx2, &Event{Topic: "chain.tx", Data: "5"}},
+	}
+	block.begin()
+	for _, r := range records {
+		assert.Nil(t, block.recordEvent(r.tx.hash, r.event))
+	}
+	block.commit()
+	// the order of txs in block doesn't affect the event order.
+	block.transactions = Transactions{tx3, tx1, tx2}
+
+	for i := 0; i < 10; i++ {
+		events, err := block.OrderedEvents()
+		assert.Nil(t, err)
+		assert.Equal(t, len(records), len(events))
+		for idx, event := range events {
+			assert.Equal(t, records[idx].event, event)
+		}
+	}
+
+	events, err := block.FetchEvents(tx1.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Event{records[0].event, records[2].event}, events)
+}
+
+func TestBlock_EventsByTxHash(t *testing.T) {
+	bc := testNeb(t).chain
+	from := mockAddress()
+
+	block, err := bc.NewBlock(from)
+	assert.Nil(t, err)
+
+	events, err := block.EventsByTxHash([]byte("none"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+
+	gasLimit, _ := util.NewUint128FromInt(200000)
+	tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), 1, "unknown", nil, TransactionGasPrice, gasLimit)
+	signTransaction(t, tx)
+	block.begin()
+	_, err = tx.VerifyExecution(block)
+	assert.Nil(t, err)
+	block.commit()
+
+	events, err = block.EventsByTxHash(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, TopicTransactionExecutionResult, events[0].Topic)
+	txEvent := TransactionEvent{}
+	assert.Nil(t, json.Unmarshal([]byte(events[0].Data), &txEvent))
+	assert.Equal(t, TxExecutionFailed, int(txEvent.Status))
+	assert.Equal(t, ErrInvalidTxPayloadType.Error(), txEvent.Error)
+}
+
 func TestBlockSign(t *testing.T) {
 	bc := testNeb(t).chain
 	block := bc.tailBlock
@@ -641,6 +1410,149 @@ func TestRecordEvent(t *testing.T) {
 	assert.Equal(t, events[0].Data, "world")
 }
 
+func TestBlock_EventsByContract(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	token, exchange := mockAddress(), mockAddress()
+	tx1, tx2 := mockNormalTransaction(bc.chainID, 1), mockNormalTransaction(bc.chainID, 2)
+	for _, tx := range []*Transaction{tx1, tx2} {
+		hash, err := HashTransaction(tx)
+		assert.Nil(t, err)
+		tx.hash = hash
+	}
+	block.transactions = append(block.transactions, tx1, tx2)
+
+	topic := TopicContractEventNameSpace + ".transfer"
+	assert.Nil(t, block.RecordContractEvent(tx1.hash, token, topic, "t1"))
+	assert.Nil(t, block.RecordContractEvent(tx1.hash, exchange, topic, "e1"))
+	assert.Nil(t, block.RecordEvent(tx1.hash, TopicTransactionExecutionResult, "result"))
+	assert.Nil(t, block.RecordContractEvent(tx2.hash, exchange, topic, "e2"))
+	assert.Nil(t, block.RecordContractEvent(tx2.hash, token, topic, "t2"))
+
+	events, err := block.EventsByContract(token)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Event{
+		{Topic: topic, Data: "t1", Contract: token.String()},
+		{Topic: topic, Data: "t2", Contract: token.String()},
+	}, events)
+
+	events, err = block.EventsByContract(exchange)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"e1", "e2"}, []string{events[0].Data, events[1].Data})
+
+	events, err = block.EventsByContract(mockAddress())
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	// events not emitted by contracts carry no contract.
+	events, err = block.FetchEvents(tx1.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, "", events[2].Contract)
+
+	_, err = block.EventsByContract(nil)
+	assert.Equal(t, ErrNilArgument, err)
+	assert.Equal(t, ErrNilArgument, block.RecordContractEvent(tx1.hash, nil, topic, ""))
+}
+
+func TestBlock_EventBuffer(t *testing.T) {
+	bc := testNeb(t).chain
+	defer SetEventBufferLimits(bc.chainID, 0, 0)
+	topic := TopicContractEventNameSpace + ".transfer"
+	txHash := hash.Sha3256([]byte("tx"))
+	contract := mockAddress()
+
+	// count limit, chain events are not limited.
+	SetEventBufferLimits(bc.chainID, 2, 0)
+	maxEvents, maxBytes := EventBufferLimitsOf(bc.chainID + 1)
+	assert.Equal(t, DefaultMaxEventsPerBlock, maxEvents)
+	assert.Equal(t, DefaultMaxEventBytesPerBlock, maxBytes)
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	block.begin()
+	assert.Nil(t, block.RecordContractEvent(txHash, contract, topic, "1"))
+	assert.Nil(t, block.RecordContractEvent(txHash, contract, topic, "2"))
+	assert.Equal(t, ErrEventBufferFull, block.RecordContractEvent(txHash, contract, topic, "3"))
+	assert.Nil(t, block.RecordEvent(txHash, TopicTransactionExecutionResult, "result"))
+	events, err := block.FetchEvents(txHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(events))
+	block.rollback()
+
+	// bytes limit.
+	SetEventBufferLimits(bc.chainID, 0, 2*len(topic)+10)
+	block, err = NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	block.begin()
+	assert.Nil(t, block.RecordContractEvent(txHash, contract, topic, "12345"))
+	assert.Equal(t, ErrEventBufferFull, block.RecordContractEvent(txHash, contract, topic, "123456"))
+	assert.Nil(t, block.RecordContractEvent(txHash, contract, topic, "12345"))
+	block.rollback()
+
+	// the call emitting beyond the buffer is reverted with its events.
+	SetEventBufferLimits(bc.chainID, 3, 0)
+	block = bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err = deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	block.eventCount, block.eventBytes = 0, 0
+
+	emit, _ := NewCallPayload("emit", "").ToBytes()
+	var (
+		current  *Transaction
+		emitErrs []error
+	)
+	nvm.onCall = func(block *Block) error {
+		for i := 0; i < 2; i++ {
+			if err := block.RecordContractEvent(current.hash, contract, topic, "spam"); err != nil {
+				emitErrs = append(emitErrs, err)
+				return err
+			}
+		}
+		return nil
+	}
+	tests := []struct {
+		status int8
+		events int
+		revert *RevertReason
+	}{
+		{TxExecutionSuccess, 3, nil},
+		{TxExecutionFailed, 1, &RevertReason{Code: RevertCodeEventBufferFull, Message: ErrEventBufferFull.Error()}},
+	}
+	for i, tt := range tests {
+		tx, err := NewTransaction(bc.chainID, deployTx.from, contract, util.NewUint128(), uint64(i+2), TxPayloadCallType, emit, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		signTransaction(t, tx)
+		current = tx
+		_, err = tx.VerifyExecution(block)
+		assert.Nil(t, err)
+
+		events, err := block.FetchEvents(tx.hash)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.events, len(events))
+		txEvent := TransactionEvent{}
+		assert.Nil(t, json.Unmarshal([]byte(events[len(events)-1].Data), &txEvent))
+		assert.Equal(t, tt.status, txEvent.Status)
+		assert.Equal(t, tt.revert, txEvent.Revert)
+	}
+	assert.Equal(t, []error{ErrEventBufferFull}, emitErrs)
+	assert.Equal(t, 2, block.eventCount)
+}
+
 func TestBlockVerifyIntegrity(t *testing.T) {
 	bc := testNeb(t).chain
 	assert.Equal(t, bc.tailBlock.VerifyIntegrity(0, bc.ConsensusHandler()), ErrInvalidChainID)
@@ -19,6 +19,7 @@
 package core
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -57,6 +58,15 @@ type BlockChain struct {
 	cachedBlocks       *lru.Cache
 	detachedTailBlocks *lru.Cache
 
+	// cached local execution results by (tx hash, state root), nil means disabled
+	localExecutionCache *lru.Cache
+
+	// signers of verified txs keyed by tx hash, nil means disabled
+	signatureCache *lru.Cache
+
+	// contract events served by the node, nil means all
+	eventTopicFilter *EventTopicFilter
+
 	// latest irreversible block
 	lib *Block
 
@@ -112,6 +122,56 @@ func NewBlockChain(neb Neblet) (*BlockChain, error) {
 		}
 	}
 
+	txHasher, err := ParseTxHasher(neb.Config().Chain.TxHasher)
+	if err != nil {
+		return nil, err
+	}
+	// sign and verify of txs on the chain share the same hasher.
+	SetTxHasher(neb.Config().Chain.ChainId, txHasher)
+
+	SetGaslessMode(neb.Config().Chain.ChainId, neb.Config().Chain.GaslessMode)
+	if neb.Config().Chain.GaslessMode {
+		logging.CLog().WithFields(logrus.Fields{
+			"chainID": neb.Config().Chain.ChainId,
+		}).Warn("Gasless mode is on, txs are executed without charging any gas fee.")
+	}
+	SetRejectNoOpTransfers(neb.Config().Chain.ChainId, neb.Config().Chain.RejectNoopTransfers)
+
+	if len(neb.Config().Chain.Blacklist) > 0 {
+		var addrs []*Address
+		for _, v := range neb.Config().Chain.Blacklist {
+			addr, err := AddressParse(v)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, addr)
+		}
+		SetTransactionBlacklist(neb.Config().Chain.ChainId, NewStaticAddressBlacklist(addrs...), neb.Config().Chain.BlacklistReceivers)
+	} else {
+		SetTransactionBlacklist(neb.Config().Chain.ChainId, nil, false)
+	}
+	if err := SetNetworkAddressPrefix(neb.Config().Chain.ChainId, neb.Config().Chain.AddressPrefix); err != nil {
+		return nil, err
+	}
+	SetLowSSignatureHeight(neb.Config().Chain.ChainId, neb.Config().Chain.LowSSignatureHeight)
+	var reserved []*Address
+	for _, v := range neb.Config().Chain.ReservedAddresses {
+		addr, err := AddressParse(v)
+		if err != nil {
+			return nil, err
+		}
+		reserved = append(reserved, addr)
+	}
+	SetReservedAddresses(neb.Config().Chain.ChainId, reserved, neb.Config().Chain.ReservedAddressesHeight)
+	SetMaxTimestampDrift(neb.Config().Chain.ChainId, int64(neb.Config().Chain.MaxTxTimestampDrift))
+	SetMempoolPriorityWeights(neb.Config().Chain.ChainId, neb.Config().Chain.MempoolPriorityGasPriceWeight, neb.Config().Chain.MempoolPriorityAgeWeight)
+	SetEventBufferLimits(neb.Config().Chain.ChainId, int(neb.Config().Chain.MaxEventsPerBlock), int(neb.Config().Chain.MaxEventBytesPerBlock))
+	gasCaps, err := ParseContractGasCaps(neb.Config().Chain.ContractGasCaps)
+	if err != nil {
+		return nil, err
+	}
+	SetContractGasCaps(neb.Config().Chain.ChainId, gasCaps)
+
 	blockPool, err := NewBlockPool(1024)
 	if err != nil {
 		return nil, err
@@ -635,6 +695,77 @@ func (bc *BlockChain) GasPrice() *util.Uint128 {
 	return gasPrice
 }
 
+// GasPricePercentiles returns the gas price percentiles of txs in the last blocks on the canonical chain,
+// keyed by the requested percentile in [0, 100]. The default gas price is used if there is no tx.
+func (bc *BlockChain) GasPricePercentiles(blocks int, percentiles []int) (map[int]*util.Uint128, error) {
+	if blocks <= 0 {
+		return nil, ErrInvalidArgument
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, ErrInvalidArgument
+		}
+	}
+
+	var prices []*util.Uint128
+	block := bc.tailBlock
+	for i := 0; i < blocks && block != nil && !CheckGenesisBlock(block); i++ {
+		for _, tx := range block.transactions {
+			prices = append(prices, tx.gasPrice)
+		}
+		block = bc.GetBlock(block.ParentHash())
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+
+	result := make(map[int]*util.Uint128, len(percentiles))
+	for _, p := range percentiles {
+		if len(prices) == 0 {
+			result[p] = TransactionGasPrice
+			continue
+		}
+		// nearest-rank percentile.
+		rank := (p*len(prices) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		result[p] = prices[rank-1]
+	}
+	return result, nil
+}
+
+// GasParams is a snapshot of the active gas parameters of the chain.
+type GasParams struct {
+	MaxGasPrice          string `json:"max_gas_price"`
+	MaxGas               string `json:"max_gas"`
+	DefaultGasPrice      string `json:"default_gas_price"`
+	MinGasPerTx          string `json:"min_gas_per_tx"`
+	GasPerByte           string `json:"gas_per_byte"`
+	MaxDataPayloadLength int    `json:"max_data_payload_length"`
+}
+
+// CurrentGasParams returns the active gas parameters, the gas config of the tx pool overrides the defaults.
+func CurrentGasParams(chain *BlockChain) GasParams {
+	gasPrice, gasLimit := TransactionGasPrice, TransactionMaxGas
+	if chain != nil && chain.txPool != nil {
+		if chain.txPool.minGasPrice != nil {
+			gasPrice = chain.txPool.minGasPrice
+		}
+		if chain.txPool.maxGasLimit != nil {
+			gasLimit = chain.txPool.maxGasLimit
+		}
+	}
+	return GasParams{
+		MaxGasPrice:          TransactionMaxGasPrice.String(),
+		MaxGas:               gasLimit.String(),
+		DefaultGasPrice:      gasPrice.String(),
+		MinGasPerTx:          MinGasCountPerTransaction.String(),
+		GasPerByte:           GasCountPerByte.String(),
+		MaxDataPayloadLength: MaxDataPayLoadLength,
+	}
+}
+
 // EstimateGas returns the transaction gas cost
 func (bc *BlockChain) EstimateGas(tx *Transaction) (*util.Uint128, error) {
 	if tx == nil {
@@ -666,6 +797,62 @@ func (bc *BlockChain) Call(tx *Transaction) (string, error) {
 	return result, err
 }
 
+// CallView returns the result of the view function of contract on the tail block, the function is run
+// read-only by the nvm without a signed tx and charging no gas, its execution is bounded by TransactionMaxGas.
+func (bc *BlockChain) CallView(contract *Address, function string, args string) (string, error) {
+	if contract == nil {
+		return "", ErrNilArgument
+	}
+	payload, err := NewCallPayload(function, args).ToBytes()
+	if err != nil {
+		return "", err
+	}
+	// the contract itself is the caller, it's never signed nor sent.
+	tx, err := NewTransaction(bc.chainID, contract, contract, util.NewUint128(), 0, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	if err != nil {
+		return "", err
+	}
+	if tx.hash, err = HashTransaction(tx); err != nil {
+		return "", err
+	}
+	return tx.StaticCall(bc.tailBlock)
+}
+
+// EnableLocalExecutionCache cache the results of EstimateGas and Call against unchanged state, size <= 0 disables it.
+func (bc *BlockChain) EnableLocalExecutionCache(size int) error {
+	if size <= 0 {
+		bc.localExecutionCache = nil
+		return nil
+	}
+	cache, err := lru.New(size)
+	if err != nil {
+		return err
+	}
+	bc.localExecutionCache = cache
+	return nil
+}
+
+// EnableSignatureCache cache the signers of verified txs to skip the signature recovery when a tx
+// is verified again, such as in pool and then in block, size <= 0 disables it.
+func (bc *BlockChain) EnableSignatureCache(size int) error {
+	if size <= 0 {
+		bc.signatureCache = nil
+		return nil
+	}
+	cache, err := lru.New(size)
+	if err != nil {
+		return err
+	}
+	bc.signatureCache = cache
+	return nil
+}
+
+// SetEventTopicFilter set the filter of contract events served by the node, nil serves all events.
+// The filter is local to the node, it never changes the events recorded in blocks.
+func (bc *BlockChain) SetEventTopicFilter(filter *EventTopicFilter) {
+	bc.eventTopicFilter = filter
+}
+
 // Dump dump full chain.
 func (bc *BlockChain) Dump(count int) string {
 	rl := []string{}
@@ -740,17 +927,21 @@ func (bc *BlockChain) LoadGenesisFromStorage() (*Block, error) { // ToRefine, re
 		if err != nil {
 			return nil, err
 		}
-		if err := bc.StoreBlockToStorage(genesis); err != nil {
-			return nil, err
-		}
-		heightKey := byteutils.FromUint64(genesis.height)
-		if err := bc.storage.Put(heightKey, genesis.Hash()); err != nil {
+		if err := bc.storeGenesisBlock(genesis); err != nil {
 			return nil, err
 		}
 	}
 	return genesis, nil
 }
 
+func (bc *BlockChain) storeGenesisBlock(genesis *Block) error {
+	if err := bc.StoreBlockToStorage(genesis); err != nil {
+		return err
+	}
+	heightKey := byteutils.FromUint64(genesis.height)
+	return bc.storage.Put(heightKey, genesis.Hash())
+}
+
 // LoadLIBFromStorage load LIB
 func (bc *BlockChain) LoadLIBFromStorage() (*Block, error) {
 	hash, err := bc.storage.Get([]byte(LIB))
@@ -216,6 +216,43 @@ func TestBlockChain_EstimateGas(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestBlockChain_CallView(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	readOnly := false
+	nvm := &mockNvm{readOnly: &readOnly, result: "100"}
+	block.nvm = nvm
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	stateRoot, err := block.accState.RootHash()
+	assert.Nil(t, err)
+
+	result, err := bc.CallView(contract, "balanceOf", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "100", result)
+	assert.True(t, readOnly)
+	root, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, stateRoot, root)
+
+	_, err = bc.CallView(mockAddress(), "balanceOf", "")
+	assert.Equal(t, ErrContractNotFound, err)
+	_, err = bc.CallView(nil, "balanceOf", "")
+	assert.Equal(t, ErrNilArgument, err)
+}
+
 func TestTailBlock(t *testing.T) {
 	bc := testNeb(t).chain
 	block, err := bc.LoadTailFromStorage()
@@ -258,3 +295,86 @@ func TestGetPrice(t *testing.T) {
 	bc.StoreBlockToStorage(block)
 	assert.Equal(t, bc.GasPrice(), lowerGasPrice)
 }
+
+func TestGasPricePercentiles(t *testing.T) {
+	bc := testNeb(t).chain
+	percentiles, err := bc.GasPricePercentiles(10, []int{50, 90})
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]*util.Uint128{50: TransactionGasPrice, 90: TransactionGasPrice}, percentiles)
+
+	ks := keystore.DefaultKS
+	from := mockAddress()
+	key, err := ks.GetUnlocked(from.String())
+	assert.Nil(t, err)
+	signature, err := crypto.NewSignature(keystore.SECP256K1)
+	assert.Nil(t, err)
+	signature.InitSign(key.(keystore.PrivateKey))
+	gasLimit, _ := util.NewUint128FromInt(200000)
+	price := func(i int) *util.Uint128 {
+		p, _ := util.NewUint128FromInt(int64(i) * 1000000)
+		return p
+	}
+
+	// block with prices 6..10 on top of block with prices 1..5, then an empty tail block.
+	nonce := uint64(0)
+	for _, prices := range [][]int{{3, 1, 5, 2, 4}, {10, 6, 9, 7, 8}, nil} {
+		block, err := bc.NewBlock(from)
+		assert.Nil(t, err)
+		for _, i := range prices {
+			nonce++
+			tx, _ := NewTransaction(bc.ChainID(), from, from, util.NewUint128(), nonce, TxPayloadBinaryType, []byte("nas"), price(i), gasLimit)
+			tx.Sign(signature)
+			block.transactions = append(block.transactions, tx)
+		}
+		block.Seal()
+		block.Sign(signature)
+		bc.SetTailBlock(block)
+		bc.StoreBlockToStorage(block)
+	}
+
+	percentiles, err = bc.GasPricePercentiles(3, []int{0, 50, 90, 100})
+	assert.Nil(t, err)
+	assert.Equal(t, price(1), percentiles[0])
+	assert.Equal(t, price(5), percentiles[50])
+	assert.Equal(t, price(9), percentiles[90])
+	assert.Equal(t, price(10), percentiles[100])
+
+	// only the last two blocks are scanned.
+	percentiles, err = bc.GasPricePercentiles(2, []int{50, 90})
+	assert.Nil(t, err)
+	assert.Equal(t, price(8), percentiles[50])
+	assert.Equal(t, price(10), percentiles[90])
+
+	// the empty tail block alone falls back to the default gas price.
+	percentiles, err = bc.GasPricePercentiles(1, []int{50})
+	assert.Nil(t, err)
+	assert.Equal(t, TransactionGasPrice, percentiles[50])
+
+	_, err = bc.GasPricePercentiles(0, []int{50})
+	assert.Equal(t, ErrInvalidArgument, err)
+	_, err = bc.GasPricePercentiles(3, []int{101})
+	assert.Equal(t, ErrInvalidArgument, err)
+}
+
+func TestCurrentGasParams(t *testing.T) {
+	bc := testNeb(t).chain
+	params := CurrentGasParams(bc)
+	assert.Equal(t, GasParams{
+		MaxGasPrice:          TransactionMaxGasPrice.String(),
+		MaxGas:               TransactionMaxGas.String(),
+		DefaultGasPrice:      TransactionGasPrice.String(),
+		MinGasPerTx:          MinGasCountPerTransaction.String(),
+		GasPerByte:           GasCountPerByte.String(),
+		MaxDataPayloadLength: MaxDataPayLoadLength,
+	}, params)
+
+	gasPrice, _ := util.NewUint128FromInt(2000000)
+	gasLimit, _ := util.NewUint128FromInt(3000000)
+	bc.txPool.SetGasConfig(gasPrice, gasLimit)
+	params = CurrentGasParams(bc)
+	assert.Equal(t, "2000000", params.DefaultGasPrice)
+	assert.Equal(t, "3000000", params.MaxGas)
+	assert.Equal(t, TransactionMaxGasPrice.String(), params.MaxGasPrice)
+
+	assert.Equal(t, TransactionGasPrice.String(), CurrentGasParams(nil).DefaultGasPrice)
+}
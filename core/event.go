@@ -19,6 +19,7 @@
 package core
 
 import (
+	"strings"
 	"sync"
 
 	"time"
@@ -61,12 +62,45 @@ const (
 
 	// TopicRevertBlock the topic of revert block
 	TopicRevertBlock = "chain.revertBlock"
+
+	// TopicContractEventNameSpace the name space of topics of events triggered by contracts
+	TopicContractEventNameSpace = "chain.contract"
 )
 
 // Event event structure.
 type Event struct {
 	Topic string
 	Data  string
+	// Contract the address of the contract emitted the event, empty if not emitted by a contract.
+	Contract string `json:",omitempty"`
+}
+
+// EventTopicFilter decide which contract events are served by the node. All events are recorded in the events trie
+// whatever the filter, so the events root never depends on the filter of node. Events not triggered by contracts are always served.
+type EventTopicFilter struct {
+	Allow []string // only these contract topics are served if not empty
+	Deny  []string // these contract topics are never served
+}
+
+// Serve return if the event of topic should be served by the node
+func (f *EventTopicFilter) Serve(topic string) bool {
+	if f == nil || !strings.HasPrefix(topic, TopicContractEventNameSpace+".") {
+		return true
+	}
+	for _, v := range f.Deny {
+		if v == topic {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, v := range f.Allow {
+		if v == topic {
+			return true
+		}
+	}
+	return false
 }
 
 // EventSubscriber subscriber object
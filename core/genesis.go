@@ -19,7 +19,11 @@
 package core
 
 import (
+	"encoding/csv"
+	"io"
 	"io/ioutil"
+	"reflect"
+	"strings"
 
 	"github.com/nebulasio/go-nebulas/consensus/pb"
 
@@ -27,7 +31,10 @@ import (
 	"github.com/nebulasio/go-nebulas/common/trie"
 	"github.com/nebulasio/go-nebulas/core/pb"
 	"github.com/nebulasio/go-nebulas/core/state"
+	"github.com/nebulasio/go-nebulas/crypto/hash"
+	"github.com/nebulasio/go-nebulas/storage"
 	"github.com/nebulasio/go-nebulas/util"
+	"github.com/nebulasio/go-nebulas/util/byteutils"
 	"github.com/nebulasio/go-nebulas/util/logging"
 	"github.com/sirupsen/logrus"
 )
@@ -58,6 +65,130 @@ func LoadGenesisConf(filePath string) (*corepb.Genesis, error) {
 	return genesis, nil
 }
 
+// LoadTokenDistributionCSV load genesis token distribution from `address,value` lines,
+// the values of duplicate addresses are summed up.
+func LoadTokenDistributionCSV(r io.Reader) ([]*corepb.GenesisTokenDistribution, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var distribution []*corepb.GenesisTokenDistribution
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(record) != 2 {
+			logging.CLog().WithFields(logrus.Fields{
+				"line":   line,
+				"record": record,
+				"err":    err,
+			}).Error("Found malformed line in genesis token distribution csv.")
+			return nil, ErrGenesisMalformedTokenCSV
+		}
+		address, value := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		// skip the header of spreadsheet export.
+		if line == 1 && strings.EqualFold(address, "address") && strings.EqualFold(value, "value") {
+			continue
+		}
+		distribution = append(distribution, &corepb.GenesisTokenDistribution{Address: address, Value: value})
+	}
+
+	merged := new(corepb.Genesis)
+	if err := MergeTokenDistribution(merged, distribution); err != nil {
+		return nil, err
+	}
+	return merged.TokenDistribution, nil
+}
+
+// MergeTokenDistribution merge the token distribution into genesis conf,
+// the value of an address already in conf is added to its balance.
+func MergeTokenDistribution(conf *corepb.Genesis, distribution []*corepb.GenesisTokenDistribution) error {
+	if conf == nil {
+		return ErrNilArgument
+	}
+
+	balances := make(map[string]*corepb.GenesisTokenDistribution)
+	for _, v := range conf.TokenDistribution {
+		if addr, err := AddressParse(v.Address); err == nil {
+			balances[addr.String()] = v
+		}
+	}
+	for _, v := range distribution {
+		addr, err := AddressParse(v.Address)
+		if err != nil {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"err":     err,
+			}).Error("Found invalid address in genesis token distribution.")
+			return ErrGenesisInvalidTokenAddress
+		}
+		value, err := util.NewUint128FromString(v.Value)
+		if err != nil {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"value":   v.Value,
+				"err":     err,
+			}).Error("Found invalid value in genesis token distribution.")
+			return ErrGenesisInvalidTokenValue
+		}
+
+		exist, ok := balances[addr.String()]
+		if !ok {
+			entry := &corepb.GenesisTokenDistribution{Address: addr.String(), Value: value.String()}
+			balances[addr.String()] = entry
+			conf.TokenDistribution = append(conf.TokenDistribution, entry)
+			continue
+		}
+		balance, err := util.NewUint128FromString(exist.Value)
+		if err != nil {
+			return ErrGenesisInvalidTokenValue
+		}
+		if value, err = balance.Add(value); err != nil {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"balance": exist.Value,
+				"value":   v.Value,
+			}).Error("Found genesis token distribution value overflow.")
+			return ErrGenesisTokenValueOverflow
+		}
+		exist.Value = value.String()
+	}
+	return nil
+}
+
+// ValidateGenesisConf check the chainID, dynasty and token distribution of genesis conf
+func ValidateGenesisConf(conf *corepb.Genesis) error {
+	if conf == nil {
+		return ErrNilArgument
+	}
+	if conf.GetMeta().GetChainId() == 0 {
+		return ErrGenesisInvalidChainID
+	}
+	if len(conf.GetConsensus().GetDpos().GetDynasty()) == 0 {
+		return ErrGenesisEmptyDynasty
+	}
+	for _, v := range conf.TokenDistribution {
+		if _, err := AddressParse(v.Address); err != nil {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"err":     err,
+			}).Error("Found invalid address in genesis token distribution.")
+			return ErrGenesisInvalidTokenAddress
+		}
+		if _, err := util.NewUint128FromString(v.Value); err != nil {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"value":   v.Value,
+				"err":     err,
+			}).Error("Found invalid value in genesis token distribution.")
+			return ErrGenesisInvalidTokenValue
+		}
+	}
+	return nil
+}
+
 // NewGenesisBlock create genesis @Block from file.
 func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 	if conf == nil || chain == nil {
@@ -76,6 +207,13 @@ func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	receiptsState, err := trie.NewBatchTrie(nil, chain.storage)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGenesisStakes(conf); err != nil {
+		return nil, err
+	}
 	consensusState, err := chain.consensusHandler.GenesisState(chain, conf)
 	if err != nil {
 		return nil, err
@@ -92,8 +230,10 @@ func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 		accState:       accState,
 		txsState:       txsState,
 		eventsState:    eventsState,
+		receiptsState:  receiptsState,
 		consensusState: consensusState,
 		txPool:         chain.txPool,
+		gasUsed:        util.NewUint128(),
 		storage:        chain.storage,
 		eventEmitter:   chain.eventEmitter,
 		nvm:            chain.nvm,
@@ -113,6 +253,14 @@ func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 			genesisBlock.rollback()
 			return nil, err
 		}
+		// the coinbase is skipped by DumpGenesis, its distribution would be silently dropped.
+		if addr.Equals(GenesisCoinbase) {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+			}).Error("Found genesis coinbase in genesis token distribution.")
+			genesisBlock.rollback()
+			return nil, ErrCoinbaseInDistribution
+		}
 		acc, err := genesisBlock.accState.GetOrCreateUserAccount(addr.address)
 		if err != nil {
 			genesisBlock.rollback()
@@ -136,6 +284,7 @@ func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 	}
 	genesisBlock.header.txsRoot = genesisBlock.txsState.RootHash()
 	genesisBlock.header.eventsRoot = genesisBlock.eventsState.RootHash()
+	genesisBlock.header.receiptsRoot = genesisBlock.receiptsState.RootHash()
 	if genesisBlock.header.consensusRoot, err = genesisBlock.consensusState.RootHash(); err != nil {
 		return nil, err
 	}
@@ -146,6 +295,96 @@ func NewGenesisBlock(conf *corepb.Genesis, chain *BlockChain) (*Block, error) {
 	return genesisBlock, nil
 }
 
+// ComputeGenesisRoots compute the roots of the genesis block built from conf without a full chain,
+// so that light clients can verify a genesis header independently.
+// The consensus root is the sha3 of the marshaled consensus root, see HashConsensusRoot.
+func ComputeGenesisRoots(conf *corepb.Genesis, storage storage.Storage, consensus Consensus) (stateRoot, txsRoot, eventsRoot, consensusRoot byteutils.Hash, err error) {
+	if conf == nil || storage == nil || consensus == nil {
+		return nil, nil, nil, nil, ErrNilArgument
+	}
+
+	// the genesis only needs the storage and the consensus of chain.
+	chain := &BlockChain{
+		storage:          storage,
+		consensusHandler: consensus,
+	}
+	genesis, err := NewGenesisBlock(conf, chain)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if consensusRoot, err = HashConsensusRoot(genesis.ConsensusRoot()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return genesis.StateRoot(), genesis.TxsRoot(), genesis.EventsRoot(), consensusRoot, nil
+}
+
+// HashConsensusRoot return the sha3 of the marshaled consensus root.
+func HashConsensusRoot(root *consensuspb.ConsensusRoot) (byteutils.Hash, error) {
+	if root == nil {
+		return nil, ErrNilArgument
+	}
+	bytes, err := proto.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Sha3256(bytes), nil
+}
+
+// checkGenesisStakes check the staked amount of each validator not exceeds its token distribution
+func checkGenesisStakes(conf *corepb.Genesis) error {
+	stakes := conf.GetConsensus().GetDpos().GetStakes()
+	if len(stakes) == 0 {
+		return nil
+	}
+
+	distribution := make(map[string]*util.Uint128)
+	for _, v := range conf.TokenDistribution {
+		addr, err := AddressParse(v.Address)
+		if err != nil {
+			return err
+		}
+		value, err := util.NewUint128FromString(v.Value)
+		if err != nil {
+			return err
+		}
+		if balance, ok := distribution[addr.String()]; ok {
+			if value, err = value.Add(balance); err != nil {
+				return err
+			}
+		}
+		distribution[addr.String()] = value
+	}
+
+	staked := make(map[string]*util.Uint128)
+	for _, v := range stakes {
+		addr, err := AddressParse(v.Address)
+		if err != nil {
+			return err
+		}
+		value, err := util.NewUint128FromString(v.Value)
+		if err != nil {
+			return err
+		}
+		if amount, ok := staked[addr.String()]; ok {
+			if value, err = value.Add(amount); err != nil {
+				return err
+			}
+		}
+		staked[addr.String()] = value
+
+		balance, ok := distribution[addr.String()]
+		if !ok || balance.Cmp(value) < 0 {
+			logging.CLog().WithFields(logrus.Fields{
+				"address": v.Address,
+				"stake":   value,
+				"balance": balance,
+			}).Error("Found genesis stake exceeds token distribution.")
+			return ErrGenesisStakeExceedDistribution
+		}
+	}
+	return nil
+}
+
 // CheckGenesisBlock if a block is a genesis block
 func CheckGenesisBlock(block *Block) bool {
 	if block == nil {
@@ -168,8 +407,19 @@ func DumpGenesis(chain *BlockChain) (*corepb.Genesis, error) {
 		return nil, err
 	}
 	bootstrap := []string{}
+	stakes := []*corepb.GenesisConsensusDposStake{}
 	for _, v := range dynasty {
 		bootstrap = append(bootstrap, v.String())
+		stake, err := genesis.consensusState.Stake(v)
+		if err != nil {
+			return nil, err
+		}
+		if stake.Cmp(util.NewUint128()) > 0 {
+			stakes = append(stakes, &corepb.GenesisConsensusDposStake{
+				Address: v.String(),
+				Value:   stake.String(),
+			})
+		}
 	}
 	distribution := []*corepb.GenesisTokenDistribution{}
 	accounts, err := genesis.accState.Accounts() // ToConfirm: Accounts interface is risky
@@ -186,12 +436,84 @@ func DumpGenesis(chain *BlockChain) (*corepb.Genesis, error) {
 	return &corepb.Genesis{
 		Meta: &corepb.GenesisMeta{ChainId: genesis.ChainID()},
 		Consensus: &corepb.GenesisConsensus{
-			Dpos: &corepb.GenesisConsensusDpos{Dynasty: bootstrap},
+			Dpos: &corepb.GenesisConsensusDpos{Dynasty: bootstrap, Stakes: stakes},
 		},
 		TokenDistribution: distribution,
 	}, nil
 }
 
+// VerifyStoredGenesis check the genesis block in the storage is the one built from the expected conf
+func VerifyStoredGenesis(chain *BlockChain, expected *corepb.Genesis) error {
+	if chain == nil || expected == nil {
+		return ErrNilArgument
+	}
+	stored, err := LoadBlockFromStorage(GenesisHash, chain)
+	if err != nil {
+		return err
+	}
+	genesis, err := NewGenesisBlock(expected, chain)
+	if err != nil {
+		return err
+	}
+
+	if stored.ChainID() != genesis.ChainID() {
+		return ErrGenesisNotEqualChainIDInDB
+	}
+	if !stored.StateRoot().Equals(genesis.StateRoot()) {
+		logging.VLog().WithFields(logrus.Fields{
+			"stored":   stored.StateRoot(),
+			"expected": genesis.StateRoot(),
+		}).Error("Found stored genesis state root mismatch.")
+		return ErrGenesisStateRootMismatch
+	}
+	if !stored.TxsRoot().Equals(genesis.TxsRoot()) {
+		logging.VLog().WithFields(logrus.Fields{
+			"stored":   stored.TxsRoot(),
+			"expected": genesis.TxsRoot(),
+		}).Error("Found stored genesis txs root mismatch.")
+		return ErrGenesisTxsRootMismatch
+	}
+	if !reflect.DeepEqual(stored.ConsensusRoot(), genesis.ConsensusRoot()) {
+		logging.VLog().WithFields(logrus.Fields{
+			"stored":   stored.ConsensusRoot(),
+			"expected": genesis.ConsensusRoot(),
+		}).Error("Found stored genesis consensus root mismatch.")
+		return ErrGenesisConsensusRootMismatch
+	}
+	return nil
+}
+
+// EnsureGenesis return the genesis block in the storage if it matches conf, otherwise build and store
+// the genesis block from conf when the chain is not initialized yet. The bool is true if it is newly created.
+func EnsureGenesis(conf *corepb.Genesis, chain *BlockChain) (*Block, bool, error) {
+	if conf == nil || chain == nil {
+		return nil, false, ErrNilArgument
+	}
+	genesis, err := LoadBlockFromStorage(GenesisHash, chain)
+	if err == nil {
+		stored, err := DumpGenesis(chain)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := CheckGenesisConfByDB(stored, conf); err != nil {
+			return nil, false, err
+		}
+		return genesis, false, nil
+	}
+	if err != storage.ErrKeyNotFound {
+		return nil, false, err
+	}
+
+	genesis, err = NewGenesisBlock(conf, chain)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := chain.storeGenesisBlock(genesis); err != nil {
+		return nil, false, err
+	}
+	return genesis, true, nil
+}
+
 //CheckGenesisConfByDB check mem and genesis.conf if equal return nil
 func CheckGenesisConfByDB(pGenesisDB *corepb.Genesis, pGenesis *corepb.Genesis) error {
 	//private function [Empty parameters are checked by the caller]
@@ -208,6 +530,10 @@ func CheckGenesisConfByDB(pGenesisDB *corepb.Genesis, pGenesis *corepb.Genesis)
 			return ErrGenesisNotEqualTokenLenInDB
 		}
 
+		if len(pGenesis.Consensus.Dpos.Stakes) != len(pGenesisDB.Consensus.Dpos.Stakes) {
+			return ErrGenesisNotEqualStakeLenInDB
+		}
+
 		// check dpos equal
 		for _, confDposAddr := range pGenesis.Consensus.Dpos.Dynasty {
 			contains := false
@@ -223,6 +549,21 @@ func CheckGenesisConfByDB(pGenesisDB *corepb.Genesis, pGenesis *corepb.Genesis)
 
 		}
 
+		// check stake equal
+		for _, confStake := range pGenesis.Consensus.Dpos.Stakes {
+			contains := false
+			for _, stake := range pGenesisDB.Consensus.Dpos.Stakes {
+				if stake.Address == confStake.Address &&
+					stake.Value == confStake.Value {
+					contains = true
+					break
+				}
+			}
+			if !contains {
+				return ErrGenesisNotEqualStakeInDB
+			}
+		}
+
 		// check distribution equal
 		for _, confDistribution := range pGenesis.TokenDistribution {
 			contains := false
@@ -19,8 +19,11 @@
 package core
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/nebulasio/go-nebulas/core/pb"
+	"github.com/nebulasio/go-nebulas/storage"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +34,180 @@ func TestInvalidAddressInTokenDistribution(t *testing.T) {
 	_, err := NewGenesisBlock(mockConf, chain)
 	assert.Equal(t, err, ErrInvalidAddress)
 }
+
+func TestCoinbaseInTokenDistribution(t *testing.T) {
+	mockConf := MockGenesisConf()
+	mockConf.TokenDistribution[0].Address = GenesisCoinbase.String()
+	chain := testNeb(t).chain
+	_, err := NewGenesisBlock(mockConf, chain)
+	assert.Equal(t, ErrCoinbaseInDistribution, err)
+}
+
+func TestValidateGenesisConf(t *testing.T) {
+	assert.Nil(t, ValidateGenesisConf(MockGenesisConf()))
+	assert.Equal(t, ErrNilArgument, ValidateGenesisConf(nil))
+
+	tests := []struct {
+		name   string
+		modify func(conf *corepb.Genesis)
+		wanted error
+	}{
+		{"zero chainID", func(conf *corepb.Genesis) { conf.Meta.ChainId = 0 }, ErrGenesisInvalidChainID},
+		{"nil meta", func(conf *corepb.Genesis) { conf.Meta = nil }, ErrGenesisInvalidChainID},
+		{"empty dynasty", func(conf *corepb.Genesis) { conf.Consensus.Dpos.Dynasty = nil }, ErrGenesisEmptyDynasty},
+		{"nil consensus", func(conf *corepb.Genesis) { conf.Consensus = nil }, ErrGenesisEmptyDynasty},
+		{"invalid address", func(conf *corepb.Genesis) {
+			conf.TokenDistribution[0].Address = "1a263547d167c74cf4b8f9166cfa244de0481c514a45aa2"
+		}, ErrGenesisInvalidTokenAddress},
+		{"invalid value", func(conf *corepb.Genesis) { conf.TokenDistribution[0].Value = "-1" }, ErrGenesisInvalidTokenValue},
+		{"non-numeric value", func(conf *corepb.Genesis) { conf.TokenDistribution[0].Value = "ten" }, ErrGenesisInvalidTokenValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := MockGenesisConf()
+			tt.modify(conf)
+			assert.Equal(t, tt.wanted, ValidateGenesisConf(conf))
+		})
+	}
+}
+
+func TestVerifyStoredGenesis(t *testing.T) {
+	chain := testNeb(t).chain
+	assert.Nil(t, VerifyStoredGenesis(chain, MockGenesisConf()))
+	assert.Equal(t, ErrNilArgument, VerifyStoredGenesis(chain, nil))
+
+	tests := []struct {
+		name   string
+		modify func(conf *corepb.Genesis)
+		wanted error
+	}{
+		{"tampered value", func(conf *corepb.Genesis) { conf.TokenDistribution[0].Value = "1" }, ErrGenesisStateRootMismatch},
+		{"dropped account", func(conf *corepb.Genesis) { conf.TokenDistribution = conf.TokenDistribution[1:] }, ErrGenesisStateRootMismatch},
+		{"other chain", func(conf *corepb.Genesis) { conf.Meta.ChainId++ }, ErrGenesisNotEqualChainIDInDB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := MockGenesisConf()
+			tt.modify(conf)
+			assert.Equal(t, tt.wanted, VerifyStoredGenesis(chain, conf))
+		})
+	}
+}
+
+func TestComputeGenesisRoots(t *testing.T) {
+	chain := testNeb(t).chain
+	genesis := chain.genesisBlock
+
+	// roots are computed on a fresh storage, as a light client does.
+	stor, err := storage.NewMemoryStorage()
+	assert.Nil(t, err)
+	stateRoot, txsRoot, eventsRoot, consensusRoot, err := ComputeGenesisRoots(MockGenesisConf(), stor, chain.ConsensusHandler())
+	assert.Nil(t, err)
+	assert.Equal(t, genesis.StateRoot(), stateRoot)
+	assert.Equal(t, genesis.TxsRoot(), txsRoot)
+	assert.Equal(t, genesis.EventsRoot(), eventsRoot)
+	wanted, err := HashConsensusRoot(genesis.ConsensusRoot())
+	assert.Nil(t, err)
+	assert.Equal(t, wanted, consensusRoot)
+
+	conf := MockGenesisConf()
+	conf.TokenDistribution[0].Value = "1"
+	stateRoot, _, _, _, err = ComputeGenesisRoots(conf, stor, chain.ConsensusHandler())
+	assert.Nil(t, err)
+	assert.NotEqual(t, genesis.StateRoot(), stateRoot)
+
+	_, _, _, _, err = ComputeGenesisRoots(nil, stor, chain.ConsensusHandler())
+	assert.Equal(t, ErrNilArgument, err)
+}
+
+func TestEnsureGenesis(t *testing.T) {
+	chain := testNeb(t).chain
+	existing := chain.genesisBlock
+	// the mock consensus keeps no dynasty in the genesis.
+	mockConf := func() *corepb.Genesis {
+		conf := MockGenesisConf()
+		conf.Consensus.Dpos.Dynasty = nil
+		return conf
+	}
+
+	// re-init with the matching conf keeps the stored genesis.
+	genesis, created, err := EnsureGenesis(mockConf(), chain)
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existing.Hash(), genesis.Hash())
+	assert.Equal(t, existing.StateRoot(), genesis.StateRoot())
+
+	conf := mockConf()
+	conf.Meta.ChainId++
+	_, _, err = EnsureGenesis(conf, chain)
+	assert.Equal(t, ErrGenesisNotEqualChainIDInDB, err)
+
+	// first init on empty storage.
+	chain.storage, _ = storage.NewMemoryStorage()
+	genesis, created, err = EnsureGenesis(mockConf(), chain)
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, existing.StateRoot(), genesis.StateRoot())
+	assert.Nil(t, VerifyStoredGenesis(chain, MockGenesisConf()))
+
+	genesis, created, err = EnsureGenesis(mockConf(), chain)
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, existing.StateRoot(), genesis.StateRoot())
+
+	_, _, err = EnsureGenesis(nil, chain)
+	assert.Equal(t, ErrNilArgument, err)
+}
+
+func TestLoadTokenDistributionCSV(t *testing.T) {
+	a := "1a263547d167c74cf4b8f9166cfa244de0481c514a45aa2c"
+	b := "2fe3f9f51f9a05dd5f7c5329127f7c917917149b4e16b0b8"
+	max := "340282366920938463463374607431768211455"
+
+	tests := []struct {
+		name   string
+		csv    string
+		want   []*corepb.GenesisTokenDistribution
+		wanted error
+	}{
+		{"valid", "address,value\n" + a + ",100\n# comment\n" + b + ", 200\n", []*corepb.GenesisTokenDistribution{
+			{Address: a, Value: "100"},
+			{Address: b, Value: "200"},
+		}, nil},
+		{"duplicate address", a + ",100\n" + b + ",1\n" + a + ",50\n", []*corepb.GenesisTokenDistribution{
+			{Address: a, Value: "150"},
+			{Address: b, Value: "1"},
+		}, nil},
+		{"empty", "", nil, nil},
+		{"malformed line", a + ",100\n" + b + "\n", nil, ErrGenesisMalformedTokenCSV},
+		{"extra column", a + ",100,1\n", nil, ErrGenesisMalformedTokenCSV},
+		{"invalid address", "n1abc,100\n", nil, ErrGenesisInvalidTokenAddress},
+		{"invalid value", a + ",-1\n", nil, ErrGenesisInvalidTokenValue},
+		{"overflow", a + "," + max + "\n" + a + ",1\n", nil, ErrGenesisTokenValueOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distribution, err := LoadTokenDistributionCSV(strings.NewReader(tt.csv))
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.want, distribution)
+		})
+	}
+}
+
+func TestMergeTokenDistribution(t *testing.T) {
+	conf := MockGenesisConf()
+	count := len(conf.TokenDistribution)
+	a := conf.TokenDistribution[0].Address
+	c := "333cb3ed8c417971845382ede3cf67a0a96270c05fe2f700"
+
+	distribution, err := LoadTokenDistributionCSV(strings.NewReader(a + ",1\n" + c + ",5\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, MergeTokenDistribution(conf, distribution))
+	assert.Equal(t, count+1, len(conf.TokenDistribution))
+	assert.Equal(t, "10000000000000000000001", conf.TokenDistribution[0].Value)
+	assert.Equal(t, c, conf.TokenDistribution[count].Address)
+	assert.Equal(t, "5", conf.TokenDistribution[count].Value)
+	assert.Nil(t, ValidateGenesisConf(conf))
+
+	assert.Equal(t, ErrNilArgument, MergeTokenDistribution(nil, distribution))
+}
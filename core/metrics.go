@@ -19,7 +19,12 @@
 package core
 
 import (
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
 	metrics "github.com/nebulasio/go-nebulas/metrics"
+	"github.com/nebulasio/go-nebulas/util/byteutils"
 )
 
 // Metrics for core
@@ -59,6 +64,54 @@ var (
 	metricsTxExeSuccess = metrics.NewMeter("neb.transaction.execute.success")
 	metricsTxExeFailed  = metrics.NewMeter("neb.transaction.execute.failed")
 
+	// sender metrics, to surface the accounts flooding the chain
+	metricsTxPerSenderCounter  = newSenderCounter("neb.transaction.sender", 1024)
+	metricsTxsPerSenderInBlock = metrics.NewHistogramWithUniformSample("neb.block.txs_per_sender", 1024)
+
 	// event metrics
 	metricsCachedEvent = metrics.NewGauge("neb.event.cached")
 )
+
+// senderCounter counts the txs executed per sender, only the recent active senders are kept,
+// the counter of an evicted sender is removed from the metrics registry.
+type senderCounter struct {
+	mu     sync.Mutex
+	prefix string
+	counts *lru.Cache
+}
+
+func newSenderCounter(prefix string, size int) *senderCounter {
+	c := &senderCounter{prefix: prefix}
+	c.counts, _ = lru.NewWithEvict(size, func(key interface{}, value interface{}) {
+		metrics.Unregister(c.name(key.(byteutils.HexHash)))
+	})
+	return c
+}
+
+func (c *senderCounter) name(sender byteutils.HexHash) string {
+	return fmt.Sprintf("%s.%s", c.prefix, sender)
+}
+
+// Inc increase the tx count of the sender by n
+func (c *senderCounter) Inc(sender byteutils.HexHash, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := n
+	if v, ok := c.counts.Get(sender); ok {
+		count += v.(int64)
+	}
+	c.counts.Add(sender, count)
+	metrics.NewCounter(c.name(sender)).Inc(n)
+}
+
+// Count return the tx count of the sender
+func (c *senderCounter) Count(sender byteutils.HexHash) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.counts.Get(sender); ok {
+		return v.(int64)
+	}
+	return 0
+}
@@ -11,6 +11,7 @@ It has these top-level messages:
 	Account
 	Data
 	Transaction
+	TransactionCondition
 	BlockHeader
 	Block
 	NetBlocks
@@ -108,18 +109,26 @@ func (m *Data) GetPayload() []byte {
 }
 
 type Transaction struct {
-	Hash      []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
-	From      []byte `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
-	To        []byte `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
-	Value     []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
-	Nonce     uint64 `protobuf:"varint,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
-	Timestamp int64  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Data      *Data  `protobuf:"bytes,7,opt,name=data" json:"data,omitempty"`
-	ChainId   uint32 `protobuf:"varint,8,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
-	GasPrice  []byte `protobuf:"bytes,9,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
-	GasLimit  []byte `protobuf:"bytes,10,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
-	Alg       uint32 `protobuf:"varint,11,opt,name=alg,proto3" json:"alg,omitempty"`
-	Sign      []byte `protobuf:"bytes,12,opt,name=sign,proto3" json:"sign,omitempty"`
+	Hash                 []byte                `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	From                 []byte                `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   []byte                `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Value                []byte                `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Nonce                uint64                `protobuf:"varint,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp            int64                 `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Data                 *Data                 `protobuf:"bytes,7,opt,name=data" json:"data,omitempty"`
+	ChainId              uint32                `protobuf:"varint,8,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	GasPrice             []byte                `protobuf:"bytes,9,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	GasLimit             []byte                `protobuf:"bytes,10,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
+	Alg                  uint32                `protobuf:"varint,11,opt,name=alg,proto3" json:"alg,omitempty"`
+	Sign                 []byte                `protobuf:"bytes,12,opt,name=sign,proto3" json:"sign,omitempty"`
+	NotBefore            int64                 `protobuf:"varint,13,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	FeeToken             []byte                `protobuf:"bytes,14,opt,name=fee_token,json=feeToken,proto3" json:"fee_token,omitempty"`
+	Nonceless            bool                  `protobuf:"varint,15,opt,name=nonceless,proto3" json:"nonceless,omitempty"`
+	Condition            *TransactionCondition `protobuf:"bytes,16,opt,name=condition" json:"condition,omitempty"`
+	MaxFeePerGas         []byte                `protobuf:"bytes,17,opt,name=max_fee_per_gas,json=maxFeePerGas,proto3" json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas []byte                `protobuf:"bytes,18,opt,name=max_priority_fee_per_gas,json=maxPriorityFeePerGas,proto3" json:"max_priority_fee_per_gas,omitempty"`
+	ExpiryTimestamp      int64                 `protobuf:"varint,19,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
+	TypedSigning         bool                  `protobuf:"varint,20,opt,name=typed_signing,json=typedSigning,proto3" json:"typed_signing,omitempty"`
 }
 
 func (m *Transaction) Reset()                    { *m = Transaction{} }
@@ -211,6 +220,86 @@ func (m *Transaction) GetSign() []byte {
 	return nil
 }
 
+func (m *Transaction) GetNotBefore() int64 {
+	if m != nil {
+		return m.NotBefore
+	}
+	return 0
+}
+
+func (m *Transaction) GetFeeToken() []byte {
+	if m != nil {
+		return m.FeeToken
+	}
+	return nil
+}
+
+func (m *Transaction) GetNonceless() bool {
+	if m != nil {
+		return m.Nonceless
+	}
+	return false
+}
+
+func (m *Transaction) GetCondition() *TransactionCondition {
+	if m != nil {
+		return m.Condition
+	}
+	return nil
+}
+
+func (m *Transaction) GetMaxFeePerGas() []byte {
+	if m != nil {
+		return m.MaxFeePerGas
+	}
+	return nil
+}
+
+func (m *Transaction) GetMaxPriorityFeePerGas() []byte {
+	if m != nil {
+		return m.MaxPriorityFeePerGas
+	}
+	return nil
+}
+
+func (m *Transaction) GetExpiryTimestamp() int64 {
+	if m != nil {
+		return m.ExpiryTimestamp
+	}
+	return 0
+}
+
+func (m *Transaction) GetTypedSigning() bool {
+	if m != nil {
+		return m.TypedSigning
+	}
+	return false
+}
+
+type TransactionCondition struct {
+	Contract []byte `protobuf:"bytes,1,opt,name=contract,proto3" json:"contract,omitempty"`
+	Function string `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+}
+
+func (m *TransactionCondition) Reset()                    { *m = TransactionCondition{} }
+func (m *TransactionCondition) String() string            { return proto.CompactTextString(m) }
+func (*TransactionCondition) ProtoMessage()               {}
+func (*TransactionCondition) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{3} }
+
+func (m *TransactionCondition) GetContract() []byte {
+	if m != nil {
+		return m.Contract
+	}
+	return nil
+}
+
+func (m *TransactionCondition) GetFunction() string {
+	if m != nil {
+		return m.Function
+	}
+	return ""
+}
+
 type BlockHeader struct {
 	Hash          []byte                     `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
 	ParentHash    []byte                     `protobuf:"bytes,2,opt,name=parent_hash,json=parentHash,proto3" json:"parent_hash,omitempty"`
@@ -223,12 +312,14 @@ type BlockHeader struct {
 	TxsRoot       []byte                     `protobuf:"bytes,10,opt,name=txs_root,json=txsRoot,proto3" json:"txs_root,omitempty"`
 	EventsRoot    []byte                     `protobuf:"bytes,11,opt,name=events_root,json=eventsRoot,proto3" json:"events_root,omitempty"`
 	ConsensusRoot *consensuspb.ConsensusRoot `protobuf:"bytes,12,opt,name=consensus_root,json=consensusRoot" json:"consensus_root,omitempty"`
+	ReceiptsRoot  []byte                     `protobuf:"bytes,13,opt,name=receipts_root,json=receiptsRoot,proto3" json:"receipts_root,omitempty"`
+	BaseFee       []byte                     `protobuf:"bytes,14,opt,name=base_fee,json=baseFee,proto3" json:"base_fee,omitempty"`
 }
 
 func (m *BlockHeader) Reset()                    { *m = BlockHeader{} }
 func (m *BlockHeader) String() string            { return proto.CompactTextString(m) }
 func (*BlockHeader) ProtoMessage()               {}
-func (*BlockHeader) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{3} }
+func (*BlockHeader) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{4} }
 
 func (m *BlockHeader) GetHash() []byte {
 	if m != nil {
@@ -307,6 +398,20 @@ func (m *BlockHeader) GetConsensusRoot() *consensuspb.ConsensusRoot {
 	return nil
 }
 
+func (m *BlockHeader) GetReceiptsRoot() []byte {
+	if m != nil {
+		return m.ReceiptsRoot
+	}
+	return nil
+}
+
+func (m *BlockHeader) GetBaseFee() []byte {
+	if m != nil {
+		return m.BaseFee
+	}
+	return nil
+}
+
 type Block struct {
 	Header       *BlockHeader   `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
 	Transactions []*Transaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
@@ -316,7 +421,7 @@ type Block struct {
 func (m *Block) Reset()                    { *m = Block{} }
 func (m *Block) String() string            { return proto.CompactTextString(m) }
 func (*Block) ProtoMessage()               {}
-func (*Block) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{4} }
+func (*Block) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{5} }
 
 func (m *Block) GetHeader() *BlockHeader {
 	if m != nil {
@@ -348,7 +453,7 @@ type NetBlocks struct {
 func (m *NetBlocks) Reset()                    { *m = NetBlocks{} }
 func (m *NetBlocks) String() string            { return proto.CompactTextString(m) }
 func (*NetBlocks) ProtoMessage()               {}
-func (*NetBlocks) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{5} }
+func (*NetBlocks) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{6} }
 
 func (m *NetBlocks) GetFrom() string {
 	if m != nil {
@@ -380,7 +485,7 @@ type NetBlock struct {
 func (m *NetBlock) Reset()                    { *m = NetBlock{} }
 func (m *NetBlock) String() string            { return proto.CompactTextString(m) }
 func (*NetBlock) ProtoMessage()               {}
-func (*NetBlock) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{6} }
+func (*NetBlock) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{7} }
 
 func (m *NetBlock) GetFrom() string {
 	if m != nil {
@@ -411,7 +516,7 @@ type DownloadBlock struct {
 func (m *DownloadBlock) Reset()                    { *m = DownloadBlock{} }
 func (m *DownloadBlock) String() string            { return proto.CompactTextString(m) }
 func (*DownloadBlock) ProtoMessage()               {}
-func (*DownloadBlock) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{7} }
+func (*DownloadBlock) Descriptor() ([]byte, []int) { return fileDescriptorBlock, []int{8} }
 
 func (m *DownloadBlock) GetHash() []byte {
 	if m != nil {
@@ -431,6 +536,7 @@ func init() {
 	proto.RegisterType((*Account)(nil), "corepb.Account")
 	proto.RegisterType((*Data)(nil), "corepb.Data")
 	proto.RegisterType((*Transaction)(nil), "corepb.Transaction")
+	proto.RegisterType((*TransactionCondition)(nil), "corepb.TransactionCondition")
 	proto.RegisterType((*BlockHeader)(nil), "corepb.BlockHeader")
 	proto.RegisterType((*Block)(nil), "corepb.Block")
 	proto.RegisterType((*NetBlocks)(nil), "corepb.NetBlocks")
@@ -441,47 +547,59 @@ func init() {
 func init() { proto.RegisterFile("block.proto", fileDescriptorBlock) }
 
 var fileDescriptorBlock = []byte{
-	// 660 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xef, 0x6a, 0xd4, 0x40,
-	0x10, 0x27, 0x77, 0xb9, 0xbb, 0xdc, 0xe4, 0xae, 0x94, 0xb5, 0xc8, 0x5a, 0x95, 0x86, 0x88, 0x70,
-	0x20, 0xde, 0x41, 0x15, 0xea, 0xd7, 0x6a, 0x3f, 0x54, 0x11, 0x29, 0xc1, 0x2f, 0x82, 0x70, 0x6c,
-	0x36, 0x6b, 0x12, 0xcc, 0xed, 0x86, 0xec, 0x5e, 0x6d, 0x1f, 0xc0, 0x07, 0xf0, 0x3d, 0x7c, 0x29,
-	0xdf, 0x44, 0x76, 0x36, 0xb9, 0x3f, 0xb6, 0x5f, 0xfc, 0x36, 0xbf, 0x99, 0x9d, 0xc9, 0x6f, 0x7e,
-	0x33, 0x13, 0x08, 0xd3, 0x4a, 0xf1, 0xef, 0xf3, 0xba, 0x51, 0x46, 0x91, 0x21, 0x57, 0x8d, 0xa8,
-	0xd3, 0xe3, 0x37, 0x79, 0x69, 0x8a, 0x75, 0x3a, 0xe7, 0x6a, 0xb5, 0x90, 0x22, 0x5d, 0x57, 0x4c,
-	0x97, 0x6a, 0x91, 0xab, 0x97, 0x2d, 0x58, 0x70, 0x25, 0xb5, 0x90, 0x7a, 0xad, 0x17, 0x75, 0xba,
-	0xd0, 0x86, 0x19, 0xe1, 0x2a, 0xc4, 0xbf, 0x3c, 0x18, 0x9d, 0x73, 0xae, 0xd6, 0xd2, 0x10, 0x0a,
-	0x23, 0x96, 0x65, 0x8d, 0xd0, 0x9a, 0x7a, 0x91, 0x37, 0x9b, 0x24, 0x1d, 0xb4, 0x91, 0x94, 0x55,
-	0x4c, 0x72, 0x41, 0x7b, 0x2e, 0xd2, 0x42, 0x72, 0x04, 0x03, 0xa9, 0xac, 0xbf, 0x1f, 0x79, 0x33,
-	0x3f, 0x71, 0x80, 0x3c, 0x86, 0xf1, 0x35, 0x6b, 0xf4, 0xb2, 0x60, 0xba, 0xa0, 0x3e, 0x66, 0x04,
-	0xd6, 0x71, 0xc9, 0x74, 0x41, 0x4e, 0x20, 0x4c, 0xcb, 0xc6, 0x14, 0xcb, 0xba, 0x62, 0x5c, 0xd0,
-	0x01, 0x86, 0x01, 0x5d, 0x57, 0xd6, 0x13, 0xbf, 0x06, 0xff, 0x82, 0x19, 0x46, 0x08, 0xf8, 0xe6,
-	0xb6, 0x16, 0x48, 0x66, 0x9c, 0xa0, 0x6d, 0x99, 0xd4, 0xec, 0xb6, 0x52, 0x2c, 0xeb, 0x98, 0xb4,
-	0x30, 0xfe, 0xdd, 0x83, 0xf0, 0x73, 0xc3, 0xa4, 0x66, 0xdc, 0x94, 0x4a, 0xda, 0x6c, 0xfc, 0xbc,
-	0x6b, 0x05, 0x6d, 0xeb, 0xfb, 0xd6, 0xa8, 0x55, 0x9b, 0x8a, 0x36, 0x39, 0x80, 0x9e, 0x51, 0x48,
-	0x7f, 0x92, 0xf4, 0x8c, 0xb2, 0x1d, 0x5d, 0xb3, 0x6a, 0x2d, 0x5a, 0xde, 0x0e, 0x6c, 0xfb, 0x1c,
-	0xec, 0xf6, 0xf9, 0x04, 0xc6, 0xa6, 0x5c, 0x09, 0x6d, 0xd8, 0xaa, 0xa6, 0xc3, 0xc8, 0x9b, 0xf5,
-	0x93, 0xad, 0x83, 0x44, 0xe0, 0x67, 0xcc, 0x30, 0x3a, 0x8a, 0xbc, 0x59, 0x78, 0x3a, 0x99, 0xbb,
-	0x61, 0xcd, 0x6d, 0x6f, 0x09, 0x46, 0xc8, 0x23, 0x08, 0x78, 0xc1, 0x4a, 0xb9, 0x2c, 0x33, 0x1a,
-	0x44, 0xde, 0x6c, 0x9a, 0x8c, 0x10, 0xbf, 0xcf, 0xac, 0x84, 0x39, 0xd3, 0xcb, 0xba, 0x29, 0xb9,
-	0xa0, 0x63, 0x27, 0x61, 0xce, 0xf4, 0x95, 0xc5, 0x5d, 0xb0, 0x2a, 0x57, 0xa5, 0xa1, 0xb0, 0x09,
-	0x7e, 0xb4, 0x98, 0x1c, 0x42, 0x9f, 0x55, 0x39, 0x0d, 0xb1, 0x9e, 0x35, 0x6d, 0xdb, 0xba, 0xcc,
-	0x25, 0x9d, 0xb8, 0xb6, 0xad, 0x1d, 0xff, 0xe9, 0x41, 0xf8, 0xd6, 0xae, 0xd2, 0xa5, 0x60, 0x99,
-	0x68, 0xee, 0x95, 0xeb, 0x04, 0xc2, 0x9a, 0x35, 0x42, 0x1a, 0x37, 0x48, 0xa7, 0x1a, 0x38, 0x17,
-	0x8e, 0xf2, 0x18, 0x02, 0xae, 0x4a, 0x99, 0x32, 0xdd, 0xc9, 0xb5, 0xc1, 0xfb, 0xda, 0x0c, 0xfe,
-	0xd5, 0x66, 0xb7, 0xf3, 0xe1, 0x7e, 0xe7, 0x2d, 0xff, 0xd1, 0x5d, 0xfe, 0xc1, 0x96, 0x3f, 0x79,
-	0x0a, 0x80, 0x7b, 0xbc, 0x6c, 0x94, 0x32, 0xad, 0x40, 0x63, 0xf4, 0x24, 0x4a, 0x19, 0x5b, 0xdf,
-	0xdc, 0x68, 0x17, 0x74, 0x02, 0x8d, 0xcc, 0x8d, 0xc6, 0xd0, 0x09, 0x84, 0xe2, 0x5a, 0x48, 0xd3,
-	0x46, 0x43, 0xd7, 0x95, 0x73, 0xe1, 0x83, 0x73, 0x38, 0xd8, 0xdc, 0x8b, 0x7b, 0x33, 0xc1, 0x09,
-	0x1e, 0xcf, 0x37, 0xee, 0x3a, 0x9d, 0xbf, 0xeb, 0x6c, 0x9b, 0x93, 0x4c, 0xf9, 0x2e, 0xfc, 0xe0,
-	0x07, 0xfd, 0x43, 0x3f, 0xfe, 0xe9, 0xc1, 0x00, 0x35, 0x26, 0x2f, 0x60, 0x58, 0xa0, 0xce, 0xa8,
-	0x6f, 0x78, 0xfa, 0xa0, 0x5b, 0x86, 0x9d, 0x11, 0x24, 0xed, 0x13, 0x72, 0x06, 0x13, 0xb3, 0x5d,
-	0x64, 0x4d, 0x7b, 0x51, 0x7f, 0x37, 0x65, 0x67, 0xc9, 0x93, 0xbd, 0x87, 0xe4, 0xa1, 0xfd, 0x4a,
-	0x99, 0x17, 0xa6, 0xbd, 0xc6, 0x16, 0xc5, 0x5f, 0x61, 0xfc, 0x49, 0x18, 0xfc, 0x94, 0xde, 0xdc,
-	0x40, 0x7b, 0x55, 0x78, 0x03, 0x47, 0x30, 0x48, 0x99, 0xe1, 0x6e, 0xc4, 0x7e, 0xe2, 0x00, 0x79,
-	0x0e, 0x43, 0xfc, 0xd9, 0x68, 0xda, 0x47, 0x06, 0xd3, 0x3d, 0xd2, 0x49, 0x1b, 0x8c, 0xbf, 0x40,
-	0xd0, 0x55, 0xff, 0x8f, 0xe2, 0xcf, 0x60, 0x80, 0xf9, 0x48, 0xf5, 0x4e, 0x6d, 0x17, 0x8b, 0xcf,
-	0x60, 0x7a, 0xa1, 0x7e, 0x48, 0x7b, 0xdf, 0x9b, 0xfa, 0xf7, 0x1d, 0x35, 0x6e, 0x47, 0x6f, 0xbb,
-	0x1d, 0xe9, 0x10, 0xff, 0x6e, 0xaf, 0xfe, 0x06, 0x00, 0x00, 0xff, 0xff, 0x5a, 0x0b, 0x40, 0x95,
-	0x2e, 0x05, 0x00, 0x00,
+	// 859 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x95, 0x55, 0xdb, 0x6e, 0xdc, 0x36,
+	0x10, 0x85, 0xbd, 0x77, 0xee, 0xae, 0xed, 0x30, 0x46, 0xa1, 0xba, 0x29, 0x12, 0x28, 0x28, 0xd0,
+	0xa2, 0xc8, 0x2e, 0x90, 0x04, 0x4d, 0xd0, 0x37, 0x3b, 0x41, 0x73, 0x41, 0x11, 0x18, 0x8a, 0x5f,
+	0x02, 0x04, 0x10, 0x28, 0x89, 0xde, 0x25, 0xa2, 0x25, 0x05, 0x92, 0xeb, 0xda, 0x1f, 0xd0, 0x0f,
+	0xc8, 0x27, 0xe4, 0x13, 0xf3, 0x07, 0x9d, 0x19, 0x4a, 0xda, 0xdd, 0xc4, 0x2f, 0x7d, 0xe3, 0x39,
+	0x33, 0xc3, 0x99, 0xe1, 0x1c, 0x8d, 0xd8, 0x38, 0x2b, 0x4d, 0xfe, 0x69, 0x56, 0x59, 0xe3, 0x0d,
+	0xef, 0xe7, 0xc6, 0xca, 0x2a, 0x3b, 0x79, 0xbe, 0x50, 0x7e, 0xb9, 0xce, 0x66, 0xb9, 0x59, 0xcd,
+	0xb5, 0xcc, 0xd6, 0xa5, 0x70, 0xca, 0xcc, 0x17, 0xe6, 0x51, 0x0d, 0xe6, 0xb9, 0xd1, 0x4e, 0x6a,
+	0xb7, 0x76, 0xf3, 0x2a, 0x9b, 0x3b, 0x2f, 0xbc, 0x0c, 0x37, 0xc4, 0x9f, 0xf7, 0xd8, 0xe0, 0x34,
+	0xcf, 0xcd, 0x5a, 0x7b, 0x1e, 0xb1, 0x81, 0x28, 0x0a, 0x2b, 0x9d, 0x8b, 0xf6, 0x1e, 0xec, 0xfd,
+	0x3a, 0x49, 0x1a, 0x88, 0x96, 0x4c, 0x94, 0x42, 0xe7, 0x32, 0xda, 0x0f, 0x96, 0x1a, 0xf2, 0x63,
+	0xd6, 0xd3, 0x06, 0xf9, 0x0e, 0xf0, 0xdd, 0x24, 0x00, 0xfe, 0x13, 0x1b, 0x5d, 0x09, 0xeb, 0xd2,
+	0xa5, 0x70, 0xcb, 0xa8, 0x4b, 0x11, 0x43, 0x24, 0x5e, 0x03, 0xe6, 0xf7, 0xd9, 0x38, 0x53, 0xd6,
+	0x2f, 0xd3, 0xaa, 0x14, 0x10, 0xd8, 0x23, 0x33, 0x23, 0xea, 0x1c, 0x99, 0xf8, 0x29, 0xeb, 0xbe,
+	0x14, 0x5e, 0x70, 0xce, 0xba, 0xfe, 0xa6, 0x92, 0x54, 0xcc, 0x28, 0xa1, 0x33, 0x56, 0x52, 0x89,
+	0x9b, 0xd2, 0x88, 0xa2, 0xa9, 0xa4, 0x86, 0xf1, 0xd7, 0x2e, 0x1b, 0x5f, 0x58, 0xa1, 0x9d, 0xc8,
+	0xbd, 0x32, 0x1a, 0xa3, 0x29, 0x7d, 0x68, 0x85, 0xce, 0xc8, 0x5d, 0x5a, 0xb3, 0xaa, 0x43, 0xe9,
+	0xcc, 0x0f, 0xd8, 0xbe, 0x37, 0x54, 0xfe, 0x24, 0x81, 0x13, 0x76, 0x74, 0x25, 0xca, 0xb5, 0xac,
+	0xeb, 0x0e, 0x60, 0xd3, 0x67, 0x6f, 0xbb, 0xcf, 0x7b, 0x6c, 0xe4, 0xd5, 0x4a, 0xc2, 0x83, 0xae,
+	0xaa, 0xa8, 0x0f, 0x96, 0x4e, 0xb2, 0x21, 0xf8, 0x03, 0xd6, 0x2d, 0xa0, 0x8f, 0x68, 0x00, 0x86,
+	0xf1, 0xe3, 0xc9, 0x2c, 0x0c, 0x6b, 0x86, 0xbd, 0x25, 0x64, 0xe1, 0x3f, 0xb2, 0x61, 0xbe, 0x14,
+	0x4a, 0xa7, 0xaa, 0x88, 0x86, 0xe0, 0x35, 0x4d, 0x06, 0x84, 0xdf, 0x14, 0xf8, 0x84, 0x0b, 0xe1,
+	0xd2, 0xca, 0x2a, 0x48, 0x3a, 0x0a, 0x4f, 0x08, 0xc4, 0x39, 0xe2, 0xc6, 0x58, 0xaa, 0x95, 0xf2,
+	0x11, 0x6b, 0x8d, 0x7f, 0x23, 0xe6, 0x47, 0xac, 0x23, 0xca, 0x45, 0x34, 0xa6, 0xfb, 0xf0, 0x88,
+	0x6d, 0x3b, 0xb5, 0xd0, 0xd1, 0x24, 0xb4, 0x8d, 0x67, 0xfe, 0x33, 0x63, 0xda, 0xf8, 0x34, 0x93,
+	0x97, 0x50, 0x55, 0x34, 0x0d, 0xb5, 0x03, 0x73, 0x46, 0x04, 0x66, 0xb8, 0x94, 0x32, 0xf5, 0xe6,
+	0x93, 0xd4, 0xd1, 0x41, 0xc8, 0x00, 0xc4, 0x05, 0x62, 0x6c, 0x9b, 0xfa, 0x2f, 0x51, 0x2a, 0x87,
+	0x60, 0x1c, 0x26, 0x1b, 0x82, 0xff, 0xc9, 0x46, 0x20, 0xb7, 0x42, 0xe1, 0x14, 0xa2, 0x23, 0xea,
+	0xfd, 0x5e, 0xd3, 0xfb, 0xd6, 0x80, 0x5e, 0x34, 0x3e, 0xc9, 0xc6, 0x9d, 0xff, 0xc2, 0x0e, 0x57,
+	0xe2, 0x3a, 0xc5, 0xd4, 0x95, 0xb4, 0x29, 0xf4, 0x14, 0xdd, 0xa1, 0xe4, 0x13, 0xa0, 0xff, 0x92,
+	0xf2, 0x5c, 0xda, 0x57, 0xc2, 0xf1, 0x3f, 0x58, 0x84, 0x6e, 0xf0, 0x38, 0xc6, 0x2a, 0x7f, 0xb3,
+	0xe3, 0xcf, 0xc9, 0xff, 0x18, 0xec, 0xe7, 0xb5, 0x79, 0x13, 0xf7, 0x1b, 0x3b, 0x92, 0xd7, 0x95,
+	0xb2, 0x37, 0xe9, 0x66, 0x6c, 0x77, 0xa9, 0xf5, 0xc3, 0xc0, 0x5f, 0xb4, 0xc3, 0x7b, 0xc8, 0xa6,
+	0x28, 0xb8, 0x22, 0xc5, 0xd7, 0x52, 0x7a, 0x11, 0x1d, 0x53, 0x9f, 0x13, 0x22, 0xdf, 0x07, 0x2e,
+	0x7e, 0xc7, 0x8e, 0x6f, 0xeb, 0x88, 0x9f, 0xc0, 0x5c, 0x8d, 0xf6, 0x16, 0xf8, 0x5a, 0x7f, 0x2d,
+	0x46, 0xdb, 0xe5, 0x5a, 0x53, 0x00, 0xe9, 0x70, 0x94, 0xb4, 0x38, 0xfe, 0xd2, 0x61, 0xe3, 0x33,
+	0xfc, 0xbe, 0x5f, 0x4b, 0x51, 0x48, 0x7b, 0xab, 0x86, 0xe1, 0xf3, 0xa9, 0x84, 0x95, 0xda, 0x87,
+	0xaf, 0x2b, 0x48, 0x99, 0x05, 0x8a, 0xbe, 0x2f, 0x4a, 0xae, 0x74, 0x26, 0x5c, 0xa3, 0xe1, 0x16,
+	0xef, 0x0a, 0xb6, 0xf7, 0xad, 0x60, 0xb7, 0xe5, 0xd8, 0xdf, 0x95, 0x63, 0x2d, 0xaa, 0xc1, 0xf7,
+	0xa2, 0x1a, 0xee, 0x8a, 0x8a, 0x96, 0x4b, 0x6a, 0x8d, 0xf1, 0xb5, 0x6a, 0x47, 0xc4, 0x24, 0x40,
+	0xe0, 0xfd, 0xfe, 0xda, 0x05, 0x63, 0x50, 0xed, 0x00, 0x30, 0x99, 0xa0, 0x2b, 0x79, 0x05, 0x1d,
+	0xd4, 0xd6, 0x71, 0xe8, 0x2a, 0x50, 0xe4, 0x70, 0xca, 0x0e, 0xda, 0x25, 0x16, 0x7c, 0x26, 0x24,
+	0xad, 0x93, 0x59, 0x4b, 0x83, 0xbe, 0x5e, 0x34, 0x67, 0x8c, 0x49, 0xa6, 0xf9, 0x36, 0xc4, 0x91,
+	0x5a, 0x99, 0x4b, 0x55, 0x35, 0x59, 0xa6, 0x41, 0x5a, 0x0d, 0xd9, 0xd4, 0x88, 0x2f, 0x85, 0x92,
+	0xaa, 0x75, 0x3f, 0x40, 0x0c, 0x1a, 0x7a, 0xdb, 0x1d, 0x76, 0x8e, 0xba, 0xf1, 0xbf, 0x7b, 0xac,
+	0x47, 0x33, 0xe2, 0xbf, 0xb3, 0xfe, 0x92, 0xe6, 0x44, 0xf3, 0x19, 0x3f, 0xbe, 0xdb, 0xa8, 0x7c,
+	0x6b, 0x84, 0x49, 0xed, 0xc2, 0x9f, 0xb1, 0x89, 0xdf, 0x48, 0xc5, 0xc1, 0xdc, 0x3a, 0xdb, 0x21,
+	0x5b, 0x32, 0x4a, 0x76, 0x1c, 0xf9, 0x0f, 0x98, 0x45, 0x2d, 0x96, 0xbe, 0x5e, 0xb1, 0x35, 0x8a,
+	0x3f, 0xb2, 0xd1, 0x3b, 0xe9, 0x29, 0x95, 0x6b, 0x17, 0x5b, 0xbd, 0x2a, 0x69, 0xb1, 0xc1, 0xca,
+	0xca, 0x84, 0xcf, 0x83, 0x44, 0x60, 0x65, 0x11, 0x80, 0x2f, 0xac, 0x4f, 0x7f, 0x10, 0x07, 0xd7,
+	0x61, 0x05, 0xd3, 0x9d, 0xa2, 0x93, 0xda, 0x18, 0x7f, 0x60, 0xc3, 0xe6, 0xf6, 0xff, 0x71, 0xf9,
+	0x43, 0x60, 0x31, 0x84, 0x4a, 0xfd, 0xee, 0xee, 0x60, 0x8b, 0x9f, 0xb1, 0xe9, 0x4b, 0xf3, 0x8f,
+	0xc6, 0xa5, 0xdd, 0xde, 0x7f, 0xdb, 0xa6, 0x26, 0x75, 0xed, 0x6f, 0xd4, 0x95, 0xf5, 0xe9, 0x97,
+	0xf5, 0xe4, 0x3f, 0xa1, 0x5b, 0x52, 0xb0, 0x03, 0x07, 0x00, 0x00,
 }
@@ -13,6 +13,7 @@ It has these top-level messages:
 	GenesisConsensus
 	GenesisConsensusDpos
 	GenesisTokenDistribution
+	GenesisConsensusDposStake
 */
 package corepb
 
@@ -104,6 +105,8 @@ func (m *GenesisConsensus) GetDpos() *GenesisConsensusDpos {
 type GenesisConsensusDpos struct {
 	// dpos genesis dynasty address
 	Dynasty []string `protobuf:"bytes,1,rep,name=dynasty" json:"dynasty,omitempty"`
+	// dpos genesis dynasty stake, optional
+	Stakes []*GenesisConsensusDposStake `protobuf:"bytes,2,rep,name=stakes" json:"stakes,omitempty"`
 }
 
 func (m *GenesisConsensusDpos) Reset()                    { *m = GenesisConsensusDpos{} }
@@ -118,6 +121,13 @@ func (m *GenesisConsensusDpos) GetDynasty() []string {
 	return nil
 }
 
+func (m *GenesisConsensusDpos) GetStakes() []*GenesisConsensusDposStake {
+	if m != nil {
+		return m.Stakes
+	}
+	return nil
+}
+
 type GenesisTokenDistribution struct {
 	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
 	Value   string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -142,33 +152,62 @@ func (m *GenesisTokenDistribution) GetValue() string {
 	return ""
 }
 
+type GenesisConsensusDposStake struct {
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Value   string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *GenesisConsensusDposStake) Reset()         { *m = GenesisConsensusDposStake{} }
+func (m *GenesisConsensusDposStake) String() string { return proto.CompactTextString(m) }
+func (*GenesisConsensusDposStake) ProtoMessage()    {}
+func (*GenesisConsensusDposStake) Descriptor() ([]byte, []int) {
+	return fileDescriptorGenesis, []int{5}
+}
+
+func (m *GenesisConsensusDposStake) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *GenesisConsensusDposStake) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Genesis)(nil), "corepb.Genesis")
 	proto.RegisterType((*GenesisMeta)(nil), "corepb.GenesisMeta")
 	proto.RegisterType((*GenesisConsensus)(nil), "corepb.GenesisConsensus")
 	proto.RegisterType((*GenesisConsensusDpos)(nil), "corepb.GenesisConsensusDpos")
 	proto.RegisterType((*GenesisTokenDistribution)(nil), "corepb.GenesisTokenDistribution")
+	proto.RegisterType((*GenesisConsensusDposStake)(nil), "corepb.GenesisConsensusDposStake")
 }
 
 func init() { proto.RegisterFile("genesis.proto", fileDescriptorGenesis) }
 
 var fileDescriptorGenesis = []byte{
-	// 264 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x51, 0x4b, 0xc3, 0x30,
-	0x14, 0x85, 0xa9, 0x9d, 0xab, 0xbd, 0x65, 0xa0, 0x71, 0x0f, 0x11, 0x7c, 0x28, 0x79, 0xb1, 0x4f,
-	0x65, 0x4c, 0xf0, 0x0f, 0x58, 0x10, 0x05, 0x11, 0x82, 0xef, 0x23, 0x6d, 0x2e, 0x1a, 0xd4, 0xa4,
-	0xf4, 0xa6, 0xc2, 0x7e, 0x9b, 0x7f, 0x4e, 0x96, 0xae, 0x38, 0x8a, 0x7b, 0x3c, 0x39, 0x5f, 0x2e,
-	0xe7, 0xdc, 0x0b, 0x8b, 0x37, 0xb4, 0x48, 0x86, 0xca, 0xb6, 0x73, 0xde, 0xb1, 0x79, 0xe3, 0x3a,
-	0x6c, 0x6b, 0xf1, 0x13, 0x41, 0xf2, 0x30, 0x38, 0xec, 0x06, 0x66, 0x5f, 0xe8, 0x15, 0x8f, 0xf2,
-	0xa8, 0xc8, 0xd6, 0x97, 0xe5, 0x80, 0x94, 0x7b, 0xfb, 0x19, 0xbd, 0x92, 0x01, 0x60, 0x77, 0x90,
-	0x36, 0xce, 0x12, 0x5a, 0xea, 0x89, 0x9f, 0x04, 0x9a, 0x4f, 0xe8, 0xfb, 0xd1, 0x97, 0x7f, 0x28,
-	0x7b, 0x01, 0xe6, 0xdd, 0x07, 0xda, 0x8d, 0x36, 0xe4, 0x3b, 0x53, 0xf7, 0xde, 0x38, 0xcb, 0xe3,
-	0x3c, 0x2e, 0xb2, 0x75, 0x3e, 0x19, 0xf0, 0xba, 0x03, 0xab, 0x03, 0x4e, 0x5e, 0xf8, 0xe9, 0x93,
-	0x28, 0x20, 0x3b, 0x48, 0xc7, 0xae, 0xe0, 0xac, 0x79, 0x57, 0xc6, 0x6e, 0x8c, 0x0e, 0x25, 0x16,
-	0x32, 0x09, 0xfa, 0x51, 0x8b, 0x0a, 0xce, 0xa7, 0xc9, 0xd8, 0x0a, 0x66, 0xba, 0x75, 0xb4, 0xef,
-	0x7b, 0x7d, 0xac, 0x41, 0xd5, 0x3a, 0x92, 0x81, 0x14, 0x2b, 0x58, 0xfe, 0xe7, 0x32, 0x0e, 0x89,
-	0xde, 0x5a, 0x45, 0x7e, 0xcb, 0xa3, 0x3c, 0x2e, 0x52, 0x39, 0x4a, 0xf1, 0x04, 0xfc, 0x58, 0xa1,
-	0xdd, 0x2f, 0xa5, 0x75, 0x87, 0x34, 0x44, 0x48, 0xe5, 0x28, 0xd9, 0x12, 0x4e, 0xbf, 0xd5, 0x67,
-	0x8f, 0x61, 0xb9, 0xa9, 0x1c, 0x44, 0x3d, 0x0f, 0xa7, 0xbb, 0xfd, 0x0d, 0x00, 0x00, 0xff, 0xff,
-	0xbe, 0xf4, 0x0e, 0x3f, 0xcb, 0x01, 0x00, 0x00,
+	// 292 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0x41, 0x4b, 0xfc, 0x30,
+	0x10, 0xc5, 0xe9, 0xbf, 0xfb, 0x6f, 0xed, 0x94, 0x05, 0x8d, 0x7b, 0xc8, 0x82, 0x87, 0x9a, 0x8b,
+	0x3d, 0x15, 0x59, 0x41, 0xf0, 0x6c, 0x41, 0x54, 0x44, 0x88, 0xde, 0x97, 0xb4, 0x09, 0x1a, 0xaa,
+	0x49, 0x69, 0x52, 0x61, 0x3f, 0x9b, 0x5f, 0x4e, 0x9a, 0xb6, 0xb8, 0x14, 0x2b, 0x78, 0x7c, 0x79,
+	0xbf, 0xcc, 0xcc, 0x9b, 0x04, 0x96, 0x2f, 0x42, 0x09, 0x23, 0x4d, 0x56, 0x37, 0xda, 0x6a, 0x14,
+	0x94, 0xba, 0x11, 0x75, 0x41, 0x3e, 0x3d, 0x08, 0x6f, 0x7a, 0x07, 0x9d, 0xc1, 0xe2, 0x5d, 0x58,
+	0x86, 0xbd, 0xc4, 0x4b, 0xe3, 0xcd, 0x71, 0xd6, 0x23, 0xd9, 0x60, 0x3f, 0x08, 0xcb, 0xa8, 0x03,
+	0xd0, 0x25, 0x44, 0xa5, 0x56, 0x46, 0x28, 0xd3, 0x1a, 0xfc, 0xcf, 0xd1, 0x78, 0x42, 0x5f, 0x8f,
+	0x3e, 0xfd, 0x46, 0xd1, 0x23, 0x20, 0xab, 0x2b, 0xa1, 0xb6, 0x5c, 0x1a, 0xdb, 0xc8, 0xa2, 0xb5,
+	0x52, 0x2b, 0xec, 0x27, 0x7e, 0x1a, 0x6f, 0x92, 0x49, 0x81, 0xe7, 0x0e, 0xcc, 0xf7, 0x38, 0x7a,
+	0x64, 0xa7, 0x47, 0x24, 0x85, 0x78, 0x6f, 0x3a, 0xb4, 0x86, 0x83, 0xf2, 0x95, 0x49, 0xb5, 0x95,
+	0xdc, 0x85, 0x58, 0xd2, 0xd0, 0xe9, 0x5b, 0x4e, 0x72, 0x38, 0x9c, 0x4e, 0x86, 0xce, 0x61, 0xc1,
+	0x6b, 0x6d, 0x86, 0xbc, 0x27, 0x73, 0x09, 0xf2, 0x5a, 0x1b, 0xea, 0x48, 0x52, 0xc1, 0xea, 0x27,
+	0x17, 0x61, 0x08, 0xf9, 0x4e, 0x31, 0x63, 0x77, 0xd8, 0x4b, 0xfc, 0x34, 0xa2, 0xa3, 0x44, 0x57,
+	0x10, 0x18, 0xcb, 0x2a, 0xd1, 0xed, 0xa9, 0x8b, 0x79, 0xfa, 0x5b, 0x97, 0xa7, 0x8e, 0xa4, 0xc3,
+	0x05, 0x72, 0x07, 0x78, 0x6e, 0x17, 0x5d, 0x43, 0xc6, 0x79, 0x23, 0x4c, 0x3f, 0x7d, 0x44, 0x47,
+	0x89, 0x56, 0xf0, 0xff, 0x83, 0xbd, 0xb5, 0xc2, 0xbd, 0x4b, 0x44, 0x7b, 0x41, 0xee, 0x61, 0x3d,
+	0xdb, 0xf0, 0xaf, 0xc5, 0x8a, 0xc0, 0x7d, 0xa1, 0x8b, 0xaf, 0x01, 0x00, 0x75, 0xee, 0x0b, 0x83,
+	0x53, 0x02, 0x00, 0x00,
 }
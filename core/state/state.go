@@ -155,6 +155,16 @@ func (acc *account) Clone() (Account, error) {
 	}, nil
 }
 
+// Savepoint return a savepoint of account's storage in the batch task
+func (acc *account) Savepoint() (int, error) {
+	return acc.variables.Savepoint()
+}
+
+// RollbackToSavepoint undo the changes of account's storage after the savepoint
+func (acc *account) RollbackToSavepoint(savepoint int) error {
+	return acc.variables.RollbackToSavepoint(savepoint)
+}
+
 // IncrNonce by 1
 func (acc *account) IncrNonce() {
 	acc.nonce++
@@ -325,6 +335,20 @@ func (as *accountState) CreateContractAccount(addr []byte, birthPlace []byte) (A
 	return as.newAccount(addr, birthPlace)
 }
 
+// UpgradeContractAccount set the birthPlace of the contract as upgrade tx hash, the contract storage is preserved
+func (as *accountState) UpgradeContractAccount(addr []byte, birthPlace []byte) (Account, error) {
+	acc, err := as.GetContractAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	contract, ok := acc.(*account)
+	if !ok {
+		return nil, ErrContractNotFound
+	}
+	contract.birthPlace = birthPlace
+	return contract, nil
+}
+
 func (as *accountState) Accounts() ([]Account, error) {
 	accounts := []Account{}
 	iter, err := as.stateTrie.Iterator(nil)
@@ -54,6 +54,9 @@ type Account interface {
 	Get(key []byte) ([]byte, error)
 	Del(key []byte) error
 	Iterator(prefix []byte) (Iterator, error)
+
+	Savepoint() (int, error)
+	RollbackToSavepoint(savepoint int) error
 }
 
 // AccountState Interface
@@ -70,6 +73,7 @@ type AccountState interface {
 	GetOrCreateUserAccount(addr []byte) (Account, error)
 	GetContractAccount(addr []byte) (Account, error)
 	CreateContractAccount(addr []byte, birthPlace []byte) (Account, error)
+	UpgradeContractAccount(addr []byte, birthPlace []byte) (Account, error)
 }
 
 // ConsensusState interface of consensus state
@@ -88,4 +92,5 @@ type ConsensusState interface {
 
 	Dynasty() ([]byteutils.Hash, error)
 	DynastyRoot() byteutils.Hash
+	Stake(byteutils.Hash) (*util.Uint128, error)
 }
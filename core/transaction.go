@@ -19,16 +19,26 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"encoding/json"
+	"math/big"
 
 	"github.com/gogo/protobuf/proto"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jbenet/go-base58"
 	"github.com/nebulasio/go-nebulas/core/pb"
-	"github.com/nebulasio/go-nebulas/crypto"
+	"github.com/nebulasio/go-nebulas/core/state"
 	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
+	"github.com/nebulasio/go-nebulas/crypto/keystore/secp256k1"
 	"github.com/nebulasio/go-nebulas/util"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
 	"github.com/nebulasio/go-nebulas/util/logging"
@@ -58,6 +68,28 @@ var (
 
 	// MaxDataPayLoadLength Max data length in transaction
 	MaxDataPayLoadLength = 1024 * 1024
+
+	// FeeTokenTransferGasLimit gas limit of the fee token transfer call when charging gas in fee token
+	FeeTokenTransferGasLimit, _ = util.NewUint128FromInt(100000)
+
+	// ConditionGasLimit gas limit of the predicate call of conditional txs
+	ConditionGasLimit, _ = util.NewUint128FromInt(100000)
+
+	// ContractNonceGasLimit gas limit of the nonce validation call of nonceless txs
+	ContractNonceGasLimit, _ = util.NewUint128FromInt(100000)
+)
+
+// Default limits of contract events recorded in a block, the events filtered out of events trie are
+// counted as well, the emitting call beyond them fails with ErrEventBufferFull.
+const (
+	DefaultMaxEventsPerBlock     = 100000
+	DefaultMaxEventBytesPerBlock = 32 * 1024 * 1024
+)
+
+// Default weights of MempoolPriority, by default waiting 100 seconds weighs as much as the default gasPrice.
+const (
+	DefaultMempoolPriorityGasPriceWeight = 1.0
+	DefaultMempoolPriorityAgeWeight      = 10000.0
 )
 
 // TransactionEvent transaction event
@@ -66,6 +98,66 @@ type TransactionEvent struct {
 	Status  int8   `json:"status"`
 	GasUsed string `json:"gas_used"`
 	Error   string `json:"error"`
+	// GasCount the gas used by the tx. GasUsed keeps its legacy value, gasPrice * gas of the executed payload.
+	GasCount string `json:"gas_count"`
+	// ResultHash sha3256 of the payload execution result, for cross-node result verification.
+	ResultHash string `json:"result_hash"`
+	// Revert the structured reason of the failed execution.
+	Revert *RevertReason `json:"revert,omitempty"`
+}
+
+// RevertReason the structured reason of a failed execution, the engine returns it
+// as the error when the contract throws with a message.
+type RevertReason struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewRevertReason with code and message
+func NewRevertReason(code int, message string) *RevertReason {
+	return &RevertReason{Code: code, Message: message}
+}
+
+// Error returns the revert message
+func (r *RevertReason) Error() string {
+	return r.Message
+}
+
+// RevertReasonOf return the structured reason of the execution error, nil for no error.
+func RevertReasonOf(err error) *RevertReason {
+	if err == nil {
+		return nil
+	}
+	if reason, ok := err.(*RevertReason); ok {
+		return reason
+	}
+	code := RevertCodeUnknown
+	switch err {
+	case ErrInsufficientGas, ErrOutOfGasLimit:
+		code = RevertCodeOutOfGas
+	case ErrExecutionDeadlineExceeded:
+		code = RevertCodeDeadlineExceeded
+	case ErrReadOnlyViolation:
+		code = RevertCodeReadOnlyViolation
+	case ErrContractRejectedValue:
+		code = RevertCodeRejectedValue
+	case ErrInsufficientBalance:
+		code = RevertCodeInsufficientBalance
+	case ErrEventBufferFull:
+		code = RevertCodeEventBufferFull
+	}
+	return NewRevertReason(code, err.Error())
+}
+
+// TxFieldError the tx field failed to convert from proto, Field is the error of the field,
+// such as ErrInvalidTxGasPrice, and Err is the cause.
+type TxFieldError struct {
+	Field error
+	Err   error
+}
+
+func (e *TxFieldError) Error() string {
+	return e.Field.Error() + ": " + e.Err.Error()
 }
 
 // Transaction type is used to handle all transaction data.
@@ -80,12 +172,39 @@ type Transaction struct {
 	chainID   uint32
 	gasPrice  *util.Uint128
 	gasLimit  *util.Uint128
+	notBefore int64      // tx is valid only in blocks not earlier than it, 0 means no lock
+	expiry    int64      // tx is valid only in blocks not later than it, 0 means no expiry
+	feeToken  *Address   // gas fee is paid in the token contract if set, otherwise in native coin
+	nonceless bool       // nonce is validated by the wallet contract at tx.to instead of the account nonce
+	condition *Condition // tx is executed only if the predicate contract returns true, nil means unconditional
+	typed     bool       // tx is signed over the typed data hash in the default domain instead of the legacy hash
+
+	// dynamic fee against the block base fee, nil means the tx pays gasPrice
+	maxFeePerGas         *util.Uint128
+	maxPriorityFeePerGas *util.Uint128
 
 	// Signature
 	alg  keystore.Algorithm
 	sign byteutils.Hash // Signature values
 }
 
+// Condition the predicate contract function gating the execution of a transaction
+type Condition struct {
+	Contract *Address
+	Function string
+}
+
+// NewCondition create a condition calling the function of the predicate contract.
+func NewCondition(contract *Address, function string) (*Condition, error) {
+	if contract == nil {
+		return nil, ErrNilArgument
+	}
+	if !callFunctionRegexp.MatchString(function) {
+		return nil, ErrInvalidCallFunction
+	}
+	return &Condition{Contract: contract, Function: function}, nil
+}
+
 // From return from address
 func (tx *Transaction) From() *Address {
 	return tx.from
@@ -116,6 +235,46 @@ func (tx *Transaction) Nonce() uint64 {
 	return tx.nonce
 }
 
+// NotBefore return the timestamp before which tx is not valid, 0 means no lock
+func (tx *Transaction) NotBefore() int64 {
+	return tx.notBefore
+}
+
+// TypedSigning return if tx is signed over the typed data hash instead of the legacy hash
+func (tx *Transaction) TypedSigning() bool {
+	return tx.typed
+}
+
+// ExpiryTimestamp return the timestamp after which tx is expired, 0 means no expiry
+func (tx *Transaction) ExpiryTimestamp() int64 {
+	return tx.expiry
+}
+
+// FeeToken return the token contract which the gas fee is paid in, nil means native coin
+func (tx *Transaction) FeeToken() *Address {
+	return tx.feeToken
+}
+
+// Nonceless return if the nonce of tx is validated by the wallet contract instead of the account nonce
+func (tx *Transaction) Nonceless() bool {
+	return tx.nonceless
+}
+
+// Condition return the predicate gating the execution of tx, nil means unconditional
+func (tx *Transaction) Condition() *Condition {
+	return tx.condition
+}
+
+// MaxFeePerGas return the max gas price of dynamic fee tx, nil means the tx pays gasPrice
+func (tx *Transaction) MaxFeePerGas() *util.Uint128 {
+	return tx.maxFeePerGas
+}
+
+// MaxPriorityFeePerGas return the max tip per gas paid to the coinbase of dynamic fee tx
+func (tx *Transaction) MaxPriorityFeePerGas() *util.Uint128 {
+	return tx.maxPriorityFeePerGas
+}
+
 // Type return tx type
 func (tx *Transaction) Type() string {
 	return tx.data.Type
@@ -140,19 +299,47 @@ func (tx *Transaction) ToProto() (proto.Message, error) {
 	if err != nil {
 		return nil, err
 	}
+	var feeToken []byte
+	if tx.feeToken != nil {
+		feeToken = tx.feeToken.address
+	}
+	var condition *corepb.TransactionCondition
+	if tx.condition != nil {
+		condition = &corepb.TransactionCondition{
+			Contract: tx.condition.Contract.address,
+			Function: tx.condition.Function,
+		}
+	}
+	var maxFeePerGas, maxPriorityFeePerGas []byte
+	if tx.maxFeePerGas != nil {
+		if maxFeePerGas, err = tx.maxFeePerGas.ToFixedSizeByteSlice(); err != nil {
+			return nil, err
+		}
+		if maxPriorityFeePerGas, err = tx.maxPriorityFeePerGas.ToFixedSizeByteSlice(); err != nil {
+			return nil, err
+		}
+	}
 	return &corepb.Transaction{
-		Hash:      tx.hash,
-		From:      tx.from.address,
-		To:        tx.to.address,
-		Value:     value,
-		Nonce:     tx.nonce,
-		Timestamp: tx.timestamp,
-		Data:      tx.data,
-		ChainId:   tx.chainID,
-		GasPrice:  gasPrice,
-		GasLimit:  gasLimit,
-		Alg:       uint32(tx.alg),
-		Sign:      tx.sign,
+		Hash:                 tx.hash,
+		From:                 tx.from.address,
+		To:                   tx.to.address,
+		Value:                value,
+		Nonce:                tx.nonce,
+		Timestamp:            tx.timestamp,
+		Data:                 tx.data,
+		ChainId:              tx.chainID,
+		GasPrice:             gasPrice,
+		GasLimit:             gasLimit,
+		NotBefore:            tx.notBefore,
+		FeeToken:             feeToken,
+		Nonceless:            tx.nonceless,
+		Condition:            condition,
+		MaxFeePerGas:         maxFeePerGas,
+		MaxPriorityFeePerGas: maxPriorityFeePerGas,
+		ExpiryTimestamp:      tx.expiry,
+		TypedSigning:         tx.typed,
+		Alg:                  uint32(tx.alg),
+		Sign:                 tx.sign,
 	}, nil
 }
 
@@ -161,13 +348,15 @@ func (tx *Transaction) FromProto(msg proto.Message) error {
 	if msg, ok := msg.(*corepb.Transaction); ok {
 		tx.hash = msg.Hash
 
-		from, err := AddressParseFromBytes(msg.From)
+		// the addresses in tx must be of the network of the tx chain.
+		prefix := NetworkAddressPrefix(msg.ChainId)
+		from, err := AddressParseFromBytesWithPrefix(msg.From, prefix)
 		if err != nil {
 			return err
 		}
 		tx.from = from
 
-		to, err := AddressParseFromBytes(msg.To)
+		to, err := AddressParseFromBytesWithPrefix(msg.To, prefix)
 		if err != nil {
 			return err
 		}
@@ -175,7 +364,7 @@ func (tx *Transaction) FromProto(msg proto.Message) error {
 
 		value, err := util.NewUint128FromFixedSizeByteSlice(msg.Value)
 		if err != nil {
-			return err
+			return &TxFieldError{Field: ErrInvalidTxValue, Err: err}
 		}
 		tx.value = value
 		tx.nonce = msg.Nonce
@@ -193,14 +382,50 @@ func (tx *Transaction) FromProto(msg proto.Message) error {
 		tx.chainID = msg.ChainId
 		gasPrice, err := util.NewUint128FromFixedSizeByteSlice(msg.GasPrice)
 		if err != nil {
-			return err
+			return &TxFieldError{Field: ErrInvalidTxGasPrice, Err: err}
 		}
 		tx.gasPrice = gasPrice
 		gasLimit, err := util.NewUint128FromFixedSizeByteSlice(msg.GasLimit)
 		if err != nil {
-			return err
+			return &TxFieldError{Field: ErrInvalidTxGasLimit, Err: err}
 		}
 		tx.gasLimit = gasLimit
+		tx.notBefore = msg.NotBefore
+		tx.feeToken = nil
+		if len(msg.FeeToken) > 0 {
+			feeToken, err := AddressParseFromBytesWithPrefix(msg.FeeToken, prefix)
+			if err != nil {
+				return err
+			}
+			tx.feeToken = feeToken
+		}
+		tx.nonceless = msg.Nonceless
+		tx.condition = nil
+		if msg.Condition != nil {
+			contract, err := AddressParseFromBytesWithPrefix(msg.Condition.Contract, prefix)
+			if err != nil {
+				return err
+			}
+			condition, err := NewCondition(contract, msg.Condition.Function)
+			if err != nil {
+				return err
+			}
+			tx.condition = condition
+		}
+		tx.maxFeePerGas, tx.maxPriorityFeePerGas = nil, nil
+		if len(msg.MaxFeePerGas) > 0 || len(msg.MaxPriorityFeePerGas) > 0 {
+			maxFeePerGas, err := util.NewUint128FromFixedSizeByteSlice(msg.MaxFeePerGas)
+			if err != nil {
+				return &TxFieldError{Field: ErrInvalidTxMaxFeePerGas, Err: err}
+			}
+			maxPriorityFeePerGas, err := util.NewUint128FromFixedSizeByteSlice(msg.MaxPriorityFeePerGas)
+			if err != nil {
+				return &TxFieldError{Field: ErrInvalidTxMaxPriorityFeePerGas, Err: err}
+			}
+			tx.maxFeePerGas, tx.maxPriorityFeePerGas = maxFeePerGas, maxPriorityFeePerGas
+		}
+		tx.expiry = msg.ExpiryTimestamp
+		tx.typed = msg.TypedSigning
 		tx.alg = keystore.Algorithm(msg.Alg)
 		tx.sign = msg.Sign
 		return nil
@@ -208,6 +433,19 @@ func (tx *Transaction) FromProto(msg proto.Message) error {
 	return ErrCannotConvertTransaction
 }
 
+// IsDeployTxProto return if the proto tx deploys a contract, only the payload type is inspected.
+func IsDeployTxProto(msg *corepb.Transaction) bool {
+	return msg.GetData().GetType() == TxPayloadDeployType
+}
+
+// CallTargetFromProto return the contract address bytes called by the proto tx, false if it is not a call tx.
+func CallTargetFromProto(msg *corepb.Transaction) ([]byte, bool) {
+	if msg.GetData().GetType() != TxPayloadCallType {
+		return nil, false
+	}
+	return msg.To, true
+}
+
 func (tx *Transaction) String() string {
 	return fmt.Sprintf(`{"chainID":%d, "hash":"%s", "from":"%s", "to":"%s", "nonce":%d, "value":"%s", "timestamp":%d, "gasprice": "%s", "gaslimit":"%s", "type":"%s"}`,
 		tx.chainID,
@@ -223,9 +461,427 @@ func (tx *Transaction) String() string {
 	)
 }
 
+// DecodeTransaction decode the transaction from raw proto bytes.
+func DecodeTransaction(raw []byte) (*Transaction, error) {
+	msg := new(corepb.Transaction)
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return nil, err
+	}
+	tx := new(Transaction)
+	if err := tx.FromProto(msg); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ToCompactString return the base58 encoded proto of the unsigned transaction,
+// short enough to be carried between devices by QR code.
+func (tx *Transaction) ToCompactString() (string, error) {
+	msg, err := tx.ToProto()
+	if err != nil {
+		return "", err
+	}
+	pbTx := msg.(*corepb.Transaction)
+	pbTx.Hash = nil
+	pbTx.Alg = 0
+	pbTx.Sign = nil
+	raw, err := proto.Marshal(pbTx)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(raw), nil
+}
+
+// ParseCompactTransaction decode the unsigned transaction from the string of ToCompactString.
+func ParseCompactTransaction(s string) (*Transaction, error) {
+	raw := base58.Decode(s)
+	if len(raw) == 0 {
+		return nil, ErrInvalidCompactTransaction
+	}
+	msg := new(corepb.Transaction)
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return nil, ErrInvalidCompactTransaction
+	}
+	tx := new(Transaction)
+	if err := tx.FromProto(msg); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// PrettyPrint return a multi-line human-readable dump of the transaction, with its decoded payload.
+func (tx *Transaction) PrettyPrint() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Hash:      %s\n", tx.hash.String())
+	fmt.Fprintf(&buf, "ChainID:   %d\n", tx.chainID)
+	fmt.Fprintf(&buf, "From:      %s\n", tx.from.String())
+	fmt.Fprintf(&buf, "To:        %s\n", tx.to.String())
+	fmt.Fprintf(&buf, "Value:     %s\n", tx.value.String())
+	fmt.Fprintf(&buf, "Nonce:     %d\n", tx.nonce)
+	fmt.Fprintf(&buf, "Timestamp: %d (%s)\n", tx.timestamp, time.Unix(tx.timestamp, 0).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "GasPrice:  %s\n", tx.gasPrice.String())
+	fmt.Fprintf(&buf, "GasLimit:  %s\n", tx.gasLimit.String())
+	if tx.notBefore != 0 {
+		fmt.Fprintf(&buf, "NotBefore: %d\n", tx.notBefore)
+	}
+	if tx.feeToken != nil {
+		fmt.Fprintf(&buf, "FeeToken:  %s\n", tx.feeToken.String())
+	}
+	if tx.nonceless {
+		fmt.Fprintf(&buf, "Nonceless: true\n")
+	}
+	if tx.condition != nil {
+		fmt.Fprintf(&buf, "Condition: %s.%s\n", tx.condition.Contract.String(), tx.condition.Function)
+	}
+	if tx.maxFeePerGas != nil {
+		fmt.Fprintf(&buf, "MaxFee:    %s\n", tx.maxFeePerGas.String())
+		fmt.Fprintf(&buf, "MaxTip:    %s\n", tx.maxPriorityFeePerGas.String())
+	}
+	if tx.expiry != 0 {
+		fmt.Fprintf(&buf, "Expiry:    %d\n", tx.expiry)
+	}
+	if tx.typed {
+		fmt.Fprintf(&buf, "Typed:     true\n")
+	}
+	fmt.Fprintf(&buf, "Type:      %s\n", tx.Type())
+
+	payload, err := tx.LoadPayload()
+	if err != nil {
+		fmt.Fprintf(&buf, "Payload:   invalid (%s), %d bytes\n", err, tx.DataLen())
+	} else {
+		switch payload := payload.(type) {
+		case *CallPayload:
+			fmt.Fprintf(&buf, "Function:  %s\n", payload.function)
+			fmt.Fprintf(&buf, "Args:      %s\n", payload.args)
+		case *DeployPayload:
+			fmt.Fprintf(&buf, "Source:    %s, %d bytes\n", payload.SourceType, len(payload.Source))
+			fmt.Fprintf(&buf, "Args:      %s\n", payload.Args)
+			if payload.Upgrade {
+				fmt.Fprintf(&buf, "Upgrade:   true\n")
+			}
+		case *BinaryPayload:
+			fmt.Fprintf(&buf, "Data:      %s\n", byteutils.Hex(payload.Data))
+		}
+	}
+	fmt.Fprintf(&buf, "Alg:       %d\n", tx.alg)
+	fmt.Fprintf(&buf, "Sign:      %s\n", tx.sign.String())
+	return buf.String()
+}
+
 // Transactions is an alias of Transaction array.
 type Transactions []*Transaction
 
+// SortByGasPrice sort txs in place by gas price descending, txs with the same gas price are
+// ordered by nonce ascending then by hash bytes, so all nodes sort the same txs identically.
+func (txs Transactions) SortByGasPrice() {
+	sort.SliceStable(txs, func(i, j int) bool {
+		if cmp := txs[i].gasPrice.Cmp(txs[j].gasPrice); cmp != 0 {
+			return cmp > 0
+		}
+		if txs[i].nonce != txs[j].nonce {
+			return txs[i].nonce < txs[j].nonce
+		}
+		return bytes.Compare(txs[i].hash, txs[j].hash) < 0
+	})
+}
+
+// TotalValue return the sum of value moved by txs, an error is returned if the sum overflows.
+func (txs Transactions) TotalValue() (*util.Uint128, error) {
+	total := util.NewUint128()
+	for _, tx := range txs {
+		sum, err := total.Add(tx.value)
+		if err != nil {
+			return nil, err
+		}
+		total = sum
+	}
+	return total, nil
+}
+
+// TotalGasLimit return the sum of max gas committed by txs, an error is returned if the sum overflows.
+func (txs Transactions) TotalGasLimit() (*util.Uint128, error) {
+	total := util.NewUint128()
+	for _, tx := range txs {
+		sum, err := total.Add(tx.gasLimit)
+		if err != nil {
+			return nil, err
+		}
+		total = sum
+	}
+	return total, nil
+}
+
+// Dedup return txs without the txs of a hash already seen, in first-seen order, and the count of txs removed.
+func (txs Transactions) Dedup() (Transactions, int) {
+	seen := make(map[byteutils.HexHash]bool, len(txs))
+	unique := make(Transactions, 0, len(txs))
+	for _, tx := range txs {
+		key := tx.hash.Hex()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, tx)
+	}
+	return unique, len(txs) - len(unique)
+}
+
+// NextNonceBySender return the next nonce of each sender after txs, keyed by the sender address,
+// which is the highest nonce + 1 among its txs. The nonces of a sender must be contiguous,
+// otherwise ErrNonceNotContiguous is returned. Nonceless txs don't consume the account nonce.
+func (txs Transactions) NextNonceBySender() (map[string]uint64, error) {
+	nonces := make(map[string][]uint64)
+	for _, tx := range txs {
+		if tx.nonceless {
+			continue
+		}
+		from := tx.from.String()
+		nonces[from] = append(nonces[from], tx.nonce)
+	}
+
+	next := make(map[string]uint64, len(nonces))
+	for from, list := range nonces {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		for i := 1; i < len(list); i++ {
+			if list[i] != list[i-1]+1 {
+				return nil, ErrNonceNotContiguous
+			}
+		}
+		next[from] = list[len(list)-1] + 1
+	}
+	return next, nil
+}
+
+// FilterValid return the txs passing integrity and pre-checks on the block in their original order,
+// and the reason of each dropped tx keyed by its hash.
+func (txs Transactions) FilterValid(block *Block, chainID uint32) (Transactions, map[string]error) {
+	valid := make(Transactions, 0, len(txs))
+	dropped := make(map[string]error)
+	for _, tx := range txs {
+		err := tx.VerifyIntegrity(chainID)
+		if err == nil {
+			err = tx.PreCheck(block)
+		}
+		if err != nil {
+			dropped[tx.hash.String()] = err
+			continue
+		}
+		valid = append(valid, tx)
+	}
+	return valid, dropped
+}
+
+// VerificationBudget limits the integrity verification failures per source, such as a peer,
+// to bound the signature recovery spent on invalid txs. Only the recent sources are tracked.
+type VerificationBudget struct {
+	mu       sync.Mutex
+	limit    int
+	failures *lru.Cache
+}
+
+// NewVerificationBudget create a budget allowing limit failures per source, tracking at most size sources.
+func NewVerificationBudget(limit, size int) (*VerificationBudget, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidArgument
+	}
+	failures, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &VerificationBudget{limit: limit, failures: failures}, nil
+}
+
+// Failures return the verification failures of the source
+func (b *VerificationBudget) Failures(source string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v, ok := b.failures.Get(source); ok {
+		return v.(int)
+	}
+	return 0
+}
+
+// Reset clear the verification failures of the source
+func (b *VerificationBudget) Reset(source string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures.Remove(source)
+}
+
+func (b *VerificationBudget) exhausted(source string) bool {
+	return b.Failures(source) >= b.limit
+}
+
+// fail record a failure of the source and return if its budget is exhausted
+func (b *VerificationBudget) fail(source string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := 1
+	if v, ok := b.failures.Get(source); ok {
+		count += v.(int)
+	}
+	b.failures.Add(source, count)
+	return count >= b.limit
+}
+
+// BatchVerifyIntegrity verify the integrity of txs from the source, the result of each tx is in the returned errors.
+// It stops with ErrVerificationBudgetExceeded once the failures of the source exhaust the budget,
+// the txs not verified are marked with ErrVerificationBudgetExceeded. A nil budget is unlimited.
+func (txs Transactions) BatchVerifyIntegrity(chainID uint32, source string, budget *VerificationBudget) ([]error, error) {
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		if budget != nil && budget.exhausted(source) {
+			for j := i; j < len(txs); j++ {
+				errs[j] = ErrVerificationBudgetExceeded
+			}
+			logging.VLog().WithFields(logrus.Fields{
+				"source":   source,
+				"failures": budget.Failures(source),
+				"skipped":  len(txs) - i,
+			}).Debug("Verification budget exceeded.")
+			return errs, ErrVerificationBudgetExceeded
+		}
+		errs[i] = tx.VerifyIntegrity(chainID)
+		if errs[i] != nil && budget != nil {
+			budget.fail(source)
+		}
+	}
+	return errs, nil
+}
+
+// SortByGasPriceThenNonce sort txs in place preferring higher gas price globally,
+// while txs from the same sender stay in nonce ascending order.
+func (txs Transactions) SortByGasPriceThenNonce() {
+	// group txs by sender in order of first appearance, each group sorted by nonce.
+	var senders []byteutils.HexHash
+	groups := make(map[byteutils.HexHash]Transactions)
+	for _, tx := range txs {
+		from := tx.from.address.Hex()
+		if _, ok := groups[from]; !ok {
+			senders = append(senders, from)
+		}
+		groups[from] = append(groups[from], tx)
+	}
+	for _, from := range senders {
+		group := groups[from]
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].nonce < group[j].nonce
+		})
+	}
+
+	// repeatedly take the head tx with the highest gas price among senders.
+	for i := range txs {
+		var best byteutils.HexHash
+		for _, from := range senders {
+			group := groups[from]
+			if len(group) == 0 {
+				continue
+			}
+			if len(best) == 0 || group[0].gasPrice.Cmp(groups[best][0].gasPrice) > 0 {
+				best = from
+			}
+		}
+		txs[i] = groups[best][0]
+		groups[best] = groups[best][1:]
+	}
+}
+
+// PartitionIndependent group txs into batches touching disjoint accounts on the block, so the batches
+// can be executed in parallel, txs keep their original order within a batch and batches are ordered
+// by their first tx. Txs which may touch any account, such as running contract code or spending
+// the fees collected by the coinbase, put all txs into a single batch.
+func (txs Transactions) PartitionIndependent(block *Block) ([]Transactions, error) {
+	if block == nil {
+		return nil, ErrNilArgument
+	}
+
+	// union the txs sharing an account, the root of a set is its first tx.
+	parent := make([]int, len(txs))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	owners := make(map[byteutils.HexHash]int)
+	for i, tx := range txs {
+		accounts, err := tx.touchedAccounts(block)
+		if err != nil {
+			return nil, err
+		}
+		if accounts == nil {
+			return []Transactions{append(Transactions{}, txs...)}, nil
+		}
+		for _, addr := range accounts {
+			key := addr.address.Hex()
+			j, ok := owners[key]
+			if !ok {
+				owners[key] = i
+				continue
+			}
+			if ri, rj := find(i), find(j); ri < rj {
+				parent[rj] = ri
+			} else if rj < ri {
+				parent[ri] = rj
+			}
+		}
+	}
+
+	var batches []Transactions
+	index := make(map[int]int)
+	for i, tx := range txs {
+		root := find(i)
+		idx, ok := index[root]
+		if !ok {
+			idx = len(batches)
+			index[root] = idx
+			batches = append(batches, nil)
+		}
+		batches[idx] = append(batches[idx], tx)
+	}
+	return batches, nil
+}
+
+// touchedAccounts return the accounts tx reads or writes when executed on the block,
+// nil is returned if tx may touch any account.
+func (tx *Transaction) touchedAccounts(block *Block) ([]*Address, error) {
+	if tx.feeToken != nil || tx.condition != nil || tx.nonceless || tx.from.Equals(block.Coinbase()) {
+		return nil, nil
+	}
+	accounts := []*Address{tx.from, tx.to}
+	switch tx.data.Type {
+	case TxPayloadBinaryType:
+	case TxPayloadBatchTransferType:
+		payload, err := LoadBatchTransferPayload(tx.data.Payload)
+		if err != nil {
+			return nil, nil
+		}
+		addrs, _, err := payload.outputs()
+		if err != nil {
+			return nil, nil
+		}
+		accounts = append(accounts, addrs...)
+	default:
+		return nil, nil
+	}
+	// value sent to a contract runs its receive hook.
+	for _, addr := range accounts {
+		if _, err := block.accState.GetContractAccount(addr.Bytes()); err == nil {
+			return nil, nil
+		} else if err != state.ErrAccountNotFound && err != state.ErrContractNotFound {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
 // NewTransaction create #Transaction instance.
 func NewTransaction(chainID uint32, from, to *Address, value *util.Uint128, nonce uint64, payloadType string, payload []byte, gasPrice *util.Uint128, gasLimit *util.Uint128) (*Transaction, error) {
 	//if gasPrice is not specified, use the default gasPrice
@@ -263,6 +919,19 @@ func NewTransaction(chainID uint32, from, to *Address, value *util.Uint128, nonc
 	return tx, nil
 }
 
+// BuildTransaction create #Transaction instance on the chain, the nonce is the next one of
+// the sender in the tail block state. Pending txs of the sender in tx pool are not counted.
+func BuildTransaction(chain *BlockChain, from, to *Address, value *util.Uint128, payloadType string, payload []byte, gasPrice, gasLimit *util.Uint128) (*Transaction, error) {
+	if chain == nil || from == nil {
+		return nil, ErrNilArgument
+	}
+	nonce, err := chain.TailBlock().GetNonce(from.address)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransaction(chain.ChainID(), from, to, value, nonce+1, payloadType, payload, gasPrice, gasLimit)
+}
+
 // Hash return the hash of transaction.
 func (tx *Transaction) Hash() byteutils.Hash {
 	return tx.hash
@@ -278,6 +947,39 @@ func (tx *Transaction) GasLimit() *util.Uint128 {
 	return tx.gasLimit
 }
 
+// ValueString return the decimal string of tx value
+func (tx *Transaction) ValueString() string {
+	return tx.value.String()
+}
+
+// GasPriceString return the decimal string of tx gasPrice
+func (tx *Transaction) GasPriceString() string {
+	return tx.gasPrice.String()
+}
+
+// GasLimitString return the decimal string of tx gasLimit
+func (tx *Transaction) GasLimitString() string {
+	return tx.gasLimit.String()
+}
+
+// ParseTxAmounts parse the decimal strings of tx value, gasPrice and gasLimit,
+// the error tells which field is invalid.
+func ParseTxAmounts(value, gasPrice, gasLimit string) (*util.Uint128, *util.Uint128, *util.Uint128, error) {
+	v, err := util.NewUint128FromString(value)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidTxValue
+	}
+	price, err := util.NewUint128FromString(gasPrice)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidTxGasPrice
+	}
+	limit, err := util.NewUint128FromString(gasLimit)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidTxGasLimit
+	}
+	return v, price, limit, nil
+}
+
 // PayloadGasLimit returns payload gasLimit
 func (tx *Transaction) PayloadGasLimit(payload TxPayload) (*util.Uint128, error) {
 	if payload == nil {
@@ -300,74 +1002,458 @@ func (tx *Transaction) PayloadGasLimit(payload TxPayload) (*util.Uint128, error)
 	return payloadGasLimit, nil
 }
 
-// MinBalanceRequired returns gasprice * gaslimit + tx.value.
+// MinBalanceRequired returns gasprice * gaslimit + the value tx transfers.
 func (tx *Transaction) MinBalanceRequired() (*util.Uint128, error) {
-	total, err := tx.GasPrice().Mul(tx.GasLimit())
+	value, err := tx.transferredValue()
 	if err != nil {
 		return nil, err
 	}
-	total, err = total.Add(tx.value)
-	if err != nil {
+	// gas fee paid in token is escrowed before execution.
+	if tx.feeToken != nil {
+		return value, nil
+	}
+	total := tx.feeCap().DeepCopy()
+	if err := total.MulInPlace(tx.gasLimit); err != nil {
+		return nil, err
+	}
+	if err := total.AddInPlace(value); err != nil {
 		return nil, err
 	}
 	return total, nil
 }
 
-// GasCountOfTxBase calculate the actual amount for a tx with data
-func (tx *Transaction) GasCountOfTxBase() (*util.Uint128, error) {
-	txGas := MinGasCountPerTransaction.DeepCopy()
-	if tx.DataLen() > 0 {
-		dataLen, err := util.NewUint128FromInt(int64(tx.DataLen()))
-		if err != nil {
-			return nil, err
-		}
-		dataGas, err := dataLen.Mul(GasCountPerByte)
-		if err != nil {
-			return nil, err
-		}
-		txGas, err = txGas.Add(dataGas)
-		if err != nil {
-			return nil, err
-		}
+// minBalanceRequiredAt return the balance tx.from needs to execute tx in block, only the transferred value
+// in gasless mode. For fee token tx it is MinBalanceRequired plus the base fee of gasLimit burned in native coin,
+// and the tip of the base gas charged in native coin if the fee token escrow fails.
+func (tx *Transaction) minBalanceRequiredAt(block *Block) (*util.Uint128, error) {
+	if IsGaslessMode(tx.chainID) {
+		return tx.transferredValue()
 	}
-	return txGas, nil
-}
-
-// DataLen return the length of payload
-func (tx *Transaction) DataLen() int {
-	return len(tx.data.Payload)
-}
-
-// LoadPayload returns tx's payload
-func (tx *Transaction) LoadPayload() (TxPayload, error) {
-	// execute payload
-	var (
-		payload TxPayload
+	required, err := tx.MinBalanceRequired()
+	if err != nil || tx.feeToken == nil {
+		return required, err
+	}
+	burned, err := block.BaseFee().Mul(tx.gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := tx.tipPerGas(block.BaseFee())
+	if err != nil {
+		return nil, err
+	}
+	baseGas, err := tx.GasCountOfTxBase()
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := tip.Mul(baseGas)
+	if err != nil {
+		return nil, err
+	}
+	if required, err = required.Add(burned); err != nil {
+		return nil, err
+	}
+	return required.Add(fallback)
+}
+
+// transferredValue return the value sent from tx.from, tx.value plus the outputs of batch transfer.
+// An invalid batch payload adds nothing, the tx fails to load it before any output is transferred.
+func (tx *Transaction) transferredValue() (*util.Uint128, error) {
+	total := tx.value.DeepCopy()
+	if tx.data.Type != TxPayloadBatchTransferType {
+		return total, nil
+	}
+	payload, err := LoadBatchTransferPayload(tx.data.Payload)
+	if err != nil {
+		return total, nil
+	}
+	_, values, err := payload.outputs()
+	if err != nil {
+		return total, nil
+	}
+	for _, value := range values {
+		if err := total.AddInPlace(value); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+// feeCap return the max gas price tx pays, maxFeePerGas of dynamic fee tx, otherwise gasPrice.
+func (tx *Transaction) feeCap() *util.Uint128 {
+	if tx.maxFeePerGas != nil {
+		return tx.maxFeePerGas
+	}
+	return tx.gasPrice
+}
+
+// tipCap return the max tip per gas paid to the coinbase, maxPriorityFeePerGas of dynamic fee tx, otherwise gasPrice.
+func (tx *Transaction) tipCap() *util.Uint128 {
+	if tx.maxFeePerGas != nil {
+		return tx.maxPriorityFeePerGas
+	}
+	return tx.gasPrice
+}
+
+// tipPerGas return the tip per gas paid to the coinbase against the base fee, min(tipCap, feeCap-baseFee).
+func (tx *Transaction) tipPerGas(baseFee *util.Uint128) (*util.Uint128, error) {
+	if tx.feeCap().Cmp(baseFee) < 0 {
+		return nil, ErrMaxFeeBelowBaseFee
+	}
+	tip, err := tx.feeCap().Sub(baseFee)
+	if err != nil {
+		return nil, err
+	}
+	if tx.tipCap().Cmp(tip) < 0 {
+		tip = tx.tipCap()
+	}
+	return tip, nil
+}
+
+// EffectiveGasPrice return the gas price tx pays against the base fee, baseFee + min(tipCap, feeCap-baseFee).
+// The tipCap and feeCap of legacy tx are both gasPrice.
+func (tx *Transaction) EffectiveGasPrice(baseFee *util.Uint128) (*util.Uint128, error) {
+	if baseFee == nil {
+		return nil, ErrNilArgument
+	}
+	tip, err := tx.tipPerGas(baseFee)
+	if err != nil {
+		return nil, err
+	}
+	return baseFee.Add(tip)
+}
+
+// MempoolPriority return the score of tx in mempool ordering at now, the higher the earlier packed.
+// It blends gasPrice and the age of tx by the mempool priority weights of the chain, tx from the
+// future is as old as a new one.
+func (tx *Transaction) MempoolPriority(now int64) float64 {
+	gasPrice, _ := new(big.Float).SetInt(tx.gasPrice.Int).Float64()
+	age := now - tx.timestamp
+	if age < 0 {
+		age = 0
+	}
+	gasPriceWeight, ageWeight := MempoolPriorityWeightsOf(tx.chainID)
+	return gasPriceWeight*gasPrice + ageWeight*float64(age)
+}
+
+// GasCountOfTxBase calculate the actual amount for a tx with data
+func (tx *Transaction) GasCountOfTxBase() (*util.Uint128, error) {
+	txGas := MinGasCountPerTransaction.DeepCopy()
+	if tx.DataLen() > 0 {
+		// the data gas is accumulated in place, GasCountOfTxBase is computed for every tx.
+		dataGas := util.NewUint128FromUint(uint64(tx.DataLen()))
+		if err := dataGas.MulInPlace(GasCountPerByte); err != nil {
+			return nil, err
+		}
+		if err := txGas.AddInPlace(dataGas); err != nil {
+			return nil, err
+		}
+	}
+	return txGas, nil
+}
+
+// CanAffordDataGas return if gasLimit covers GasCountOfTxBase, which grows with the data length,
+// for wallets to warn before submitting a tx rejected at the gasLimit check of VerifyExecution.
+func (tx *Transaction) CanAffordDataGas() (bool, error) {
+	gas, err := tx.GasCountOfTxBase()
+	if err != nil {
+		return false, err
+	}
+	return tx.gasLimit.Cmp(gas) >= 0, nil
+}
+
+// MinimumGasLimit return the floor gasLimit of tx, GasCountOfTxBase + payload.BaseGasCount, without executing it.
+func (tx *Transaction) MinimumGasLimit() (*util.Uint128, error) {
+	payload, err := tx.LoadPayload()
+	if err != nil {
+		return nil, err
+	}
+	gas, err := tx.GasCountOfTxBase()
+	if err != nil {
+		return nil, err
+	}
+	return gas.Add(payload.BaseGasCount())
+}
+
+// engineGasCount return the gas used by the engine execution of block,
+// gas = instructions + GasCountPerByte * storageBytesWritten
+func engineGasCount(block *Block) (*util.Uint128, error) {
+	engine := block.nvm
+	count, err := engine.ExecutionInstructions()
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := util.NewUint128FromInt(int64(count))
+	if err != nil {
+		return nil, err
+	}
+
+	// storage rent, charge for the state footprint left by the contract.
+	bytesWritten, err := engine.StorageBytesWritten()
+	if err != nil {
+		return nil, err
+	}
+	storageGas, err := util.NewUint128FromUint(bytesWritten).Mul(GasCountPerByte)
+	if err != nil {
+		return nil, err
+	}
+	if block.callTracer != nil {
+		if err := block.callTracer.charge(instructions, storageGas); err != nil {
+			return nil, err
+		}
+	}
+	return instructions.Add(storageGas)
+}
+
+// DataLen return the length of payload
+func (tx *Transaction) DataLen() int {
+	return len(tx.data.Payload)
+}
+
+// LoadPayload returns tx's payload
+func (tx *Transaction) LoadPayload() (TxPayload, error) {
+	// execute payload
+	var (
+		payload TxPayload
 		err     error
 	)
 	switch tx.data.Type {
 	case TxPayloadBinaryType:
 		payload, err = LoadBinaryPayload(tx.data.Payload)
 	case TxPayloadDeployType:
-		payload, err = LoadDeployPayload(tx.data.Payload)
+		deploy, deployErr := LoadDeployPayload(tx.data.Payload)
+		if deployErr != nil {
+			return nil, deployErr
+		}
+		// deploy tx must be sent to itself, except upgrading an existing contract.
+		if !deploy.Upgrade && !tx.from.Equals(tx.to) {
+			return nil, ErrContractTransactionAddressNotEqual
+		}
+		payload = deploy
 	case TxPayloadCallType:
 		payload, err = LoadCallPayload(tx.data.Payload)
+	case TxPayloadBatchTransferType:
+		payload, err = LoadBatchTransferPayload(tx.data.Payload)
 	default:
 		err = ErrInvalidTxPayloadType
 	}
 	return payload, err
 }
 
-// LocalExecution returns tx local execution
+// ValidatePayloadType check the payload bytes can be decoded as the declared payload type.
+// It is checked on txs entering the pool, a tx in block with such payload fails in execution.
+func ValidatePayloadType(payloadType string, payload []byte) error {
+	var err error
+	switch payloadType {
+	case TxPayloadBinaryType:
+		_, err = LoadBinaryPayload(payload)
+	case TxPayloadDeployType:
+		_, err = LoadDeployPayload(payload)
+	case TxPayloadCallType:
+		var call *CallPayload
+		// json of other payload types decodes as a call without function.
+		if call, err = LoadCallPayload(payload); err == nil && len(call.function) == 0 {
+			err = ErrPayloadTypeMismatch
+		}
+	case TxPayloadBatchTransferType:
+		_, err = LoadBatchTransferPayload(payload)
+	default:
+		return ErrInvalidTxPayloadType
+	}
+	switch err {
+	case nil, ErrEmptyContractSource, ErrContractSourceTooLarge, ErrUnsupportedCompression:
+		return err
+	}
+	return ErrPayloadTypeMismatch
+}
+
+// localExecutionResult the cached result of tx local execution
+type localExecutionResult struct {
+	gasUsed *util.Uint128
+	result  string
+	err     error
+}
+
+// ReentrancyWarning a contract invoked again before its first frame returns, found in local simulation.
+type ReentrancyWarning struct {
+	Contract string `json:"contract"`
+	Function string `json:"function"`
+	Depth    int    `json:"depth"` // depth of the re-entering frame, the outermost frame is 1
+}
+
+// StorageRead is a contract storage key read in local simulation.
+type StorageRead struct {
+	Contract string `json:"contract"`
+	Key      string `json:"key"`
+}
+
+// Operation categories of gas in local simulation.
+const (
+	OpBase    = "base"    // GasCountOfTxBase, including the tx data
+	OpPayload = "payload" // BaseGasCount of payload
+	OpCompute = "compute" // instructions executed by the engine
+	OpStorage = "storage" // GasCountPerByte * storage bytes written by the engine
+)
+
+// OpCost is the gas of an operation category in local simulation.
+type OpCost struct {
+	Op  string        `json:"op"`
+	Gas *util.Uint128 `json:"gas"`
+}
+
+// LocalExecutionDiagnostics is the result of tx local execution with diagnostics.
+type LocalExecutionDiagnostics struct {
+	GasUsed *util.Uint128 `json:"gas_used"`
+	Result  string        `json:"result"`
+	HotOps  []OpCost      `json:"hot_ops"` // operation categories ranked by gas, the most expensive first
+}
+
+// callTracer track the call stack of contract invocations in local simulation,
+// the storage keys read if traceStorage is set, and the gas charged by the engine executions.
+type callTracer struct {
+	stack    []byteutils.HexHash
+	warnings []*ReentrancyWarning
+
+	traceStorage bool
+	reads        []*StorageRead
+
+	computeGas *util.Uint128
+	storageGas *util.Uint128
+}
+
+func (t *callTracer) enter(contract *Address, function string) {
+	addr := contract.address.Hex()
+	for _, frame := range t.stack {
+		if frame == addr {
+			t.warnings = append(t.warnings, &ReentrancyWarning{
+				Contract: contract.String(),
+				Function: function,
+				Depth:    len(t.stack) + 1,
+			})
+			break
+		}
+	}
+	t.stack = append(t.stack, addr)
+}
+
+func (t *callTracer) exit() {
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *callTracer) read(contract *Address, keys []string) {
+	for _, key := range keys {
+		t.reads = append(t.reads, &StorageRead{Contract: contract.String(), Key: key})
+	}
+}
+
+func (t *callTracer) charge(computeGas, storageGas *util.Uint128) error {
+	if t.computeGas == nil {
+		t.computeGas, t.storageGas = util.NewUint128(), util.NewUint128()
+	}
+	var err error
+	if t.computeGas, err = t.computeGas.Add(computeGas); err != nil {
+		return err
+	}
+	t.storageGas, err = t.storageGas.Add(storageGas)
+	return err
+}
+
+// LocalExecution returns tx local execution, the result is cached by (tx hash, state root) if the chain enables cache
 func (tx *Transaction) LocalExecution(block *Block) (*util.Uint128, string, error) {
 	if block == nil {
 		return nil, "", ErrNilArgument
 	}
 
+	var cache *lru.Cache
+	if block.txPool != nil && block.txPool.bc != nil {
+		cache = block.txPool.bc.localExecutionCache
+	}
+	if cache == nil {
+		return tx.localExecution(context.Background(), block, nil)
+	}
+
+	// the state root in key makes the cached result invalid once state changes.
+	key := tx.hash.Hex() + block.StateRoot().Hex()
+	if value, ok := cache.Get(key); ok {
+		r := value.(*localExecutionResult)
+		return r.gasUsed, r.result, r.err
+	}
+	gasUsed, result, err := tx.localExecution(context.Background(), block, nil)
+	cache.Add(key, &localExecutionResult{gasUsed: gasUsed, result: result, err: err})
+	return gasUsed, result, err
+}
+
+// LocalExecutionCtx returns tx local execution like LocalExecution, once ctx is done the contract
+// execution aborts with ErrExecutionDeadlineExceeded. The result is never cached.
+func (tx *Transaction) LocalExecutionCtx(ctx context.Context, block *Block) (*util.Uint128, string, error) {
+	if ctx == nil || block == nil {
+		return nil, "", ErrNilArgument
+	}
+	return tx.localExecution(ctx, block, nil)
+}
+
+// LocalExecutionWithReentrancyCheck returns tx local execution like LocalExecution, and the contracts
+// re-entered before their first frame returns. The warnings are diagnostic only and never fail the execution.
+func (tx *Transaction) LocalExecutionWithReentrancyCheck(block *Block) (*util.Uint128, string, []*ReentrancyWarning, error) {
+	if block == nil {
+		return nil, "", nil, ErrNilArgument
+	}
+	tracer := &callTracer{}
+	gasUsed, result, err := tx.localExecution(context.Background(), block, tracer)
+	return gasUsed, result, tracer.warnings, err
+}
+
+// LocalExecutionWithStorageTrace returns tx local execution like LocalExecution, and the contract storage keys
+// read in order. The trace is diagnostic only and never affects the execution or gas.
+func (tx *Transaction) LocalExecutionWithStorageTrace(block *Block) (*util.Uint128, string, []*StorageRead, error) {
+	if block == nil {
+		return nil, "", nil, ErrNilArgument
+	}
+	tracer := &callTracer{traceStorage: true}
+	gasUsed, result, err := tx.localExecution(context.Background(), block, tracer)
+	return gasUsed, result, tracer.reads, err
+}
+
+// LocalExecutionWithDiagnostics returns tx local execution like LocalExecution, with the gas of operation
+// categories ranked in HotOps. The diagnostics never affect the execution or gas.
+func (tx *Transaction) LocalExecutionWithDiagnostics(block *Block) (*LocalExecutionDiagnostics, error) {
+	if block == nil {
+		return nil, ErrNilArgument
+	}
+	tracer := &callTracer{}
+	gasUsed, result, err := tx.localExecution(context.Background(), block, tracer)
+	if gasUsed == nil {
+		return nil, err
+	}
+
+	payload, loadErr := tx.LoadPayload()
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	baseGas, baseErr := tx.GasCountOfTxBase()
+	if baseErr != nil {
+		return nil, baseErr
+	}
+	hotOps := []OpCost{
+		{Op: OpBase, Gas: baseGas},
+		{Op: OpPayload, Gas: payload.BaseGasCount()},
+		{Op: OpCompute, Gas: util.NewUint128()},
+		{Op: OpStorage, Gas: util.NewUint128()},
+	}
+	if tracer.computeGas != nil {
+		hotOps[2].Gas, hotOps[3].Gas = tracer.computeGas, tracer.storageGas
+	}
+	sort.SliceStable(hotOps, func(i, j int) bool {
+		return hotOps[i].Gas.Cmp(hotOps[j].Gas) > 0
+	})
+	return &LocalExecutionDiagnostics{GasUsed: gasUsed, Result: result, HotOps: hotOps}, err
+}
+
+func (tx *Transaction) localExecution(ctx context.Context, block *Block, tracer *callTracer) (*util.Uint128, string, error) {
 	txBlock, err := block.Clone()
 	if err != nil {
 		return nil, "", err
 	}
+	txBlock.callTracer = tracer
 
 	txBlock.begin()
 	defer txBlock.rollback()
@@ -381,52 +1467,383 @@ func (tx *Transaction) LocalExecution(block *Block) (*util.Uint128, string, erro
 	if err != nil {
 		return nil, "", err
 	}
-	gasUsed, err = gasUsed.Add(payload.BaseGasCount())
-	if err != nil {
-		return nil, "", err
+	gasUsed, err = gasUsed.Add(payload.BaseGasCount())
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx = WithTraceID(ctx, tx.hash.String())
+	gasExecution, result, exeErr := payload.Execute(ctx, txBlock, tx)
+
+	gasUsed, err = gasUsed.Add(gasExecution)
+	if err != nil {
+		return nil, result, err
+	}
+	return gasUsed, result, exeErr
+}
+
+// ReplayAt execute tx in isolation against the pre-state of the block, for debugging a tx failure.
+// The state of the parent block is forked and, if tx is in the block, the coinbase reward and the txs before it
+// are re-executed, then tx is executed as LocalExecution. A tx not in the block is executed on the unchanged state
+// of the parent. The chain storage is never mutated.
+func (tx *Transaction) ReplayAt(chain *BlockChain, blockHash byteutils.Hash) (*util.Uint128, string, error) {
+	if chain == nil {
+		return nil, "", ErrNilArgument
+	}
+	block := chain.GetBlock(blockHash)
+	if block == nil {
+		return nil, "", ErrBlockNotFound
+	}
+	fork, err := block.forkPreState(chain)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fork.begin()
+	defer fork.rollback()
+	preceding := -1
+	for i, prev := range fork.transactions {
+		if prev.hash.Equals(tx.hash) {
+			preceding = i
+			break
+		}
+	}
+	if preceding < 0 {
+		// not in the block, the parent state is kept as is.
+		return tx.localExecution(context.Background(), fork, nil)
+	}
+
+	if err := fork.rewardCoinbase(); err != nil {
+		return nil, "", err
+	}
+	for _, prev := range fork.transactions[:preceding] {
+		if _, err := fork.executeTransaction(prev); err != nil {
+			return nil, "", err
+		}
+	}
+	return tx.localExecution(context.Background(), fork, nil)
+}
+
+// SuggestGasLimit simulate the tx and return a gasLimit sufficient to execute it, plus bufferPercent.
+// On out of gas the simulated gasLimit is doubled until the execution succeeds, capped at TransactionMaxGas.
+func (tx *Transaction) SuggestGasLimit(block *Block, bufferPercent int) (*util.Uint128, error) {
+	if block == nil {
+		return nil, ErrNilArgument
+	}
+	if bufferPercent < 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	gasLimit := tx.gasLimit
+	if gasLimit.Cmp(MinGasCountPerTransaction) < 0 {
+		gasLimit = MinGasCountPerTransaction
+	}
+	if gasLimit.Cmp(TransactionMaxGas) > 0 {
+		gasLimit = TransactionMaxGas
+	}
+	for {
+		// simulate on a copy, tx itself and its signature are never changed.
+		sim := *tx
+		sim.gasLimit = gasLimit
+		_, _, err := sim.localExecution(context.Background(), block, nil)
+		if err == nil {
+			break
+		}
+		if err != ErrInsufficientGas && err != ErrOutOfGasLimit {
+			return nil, err
+		}
+		if gasLimit.Cmp(TransactionMaxGas) >= 0 {
+			return nil, ErrOutOfGasLimit
+		}
+		if gasLimit, err = gasLimit.Mul(util.NewUint128FromUint(2)); err != nil {
+			return nil, err
+		}
+		if gasLimit.Cmp(TransactionMaxGas) > 0 {
+			gasLimit = TransactionMaxGas
+		}
+	}
+
+	buffer, err := gasLimit.Mul(util.NewUint128FromUint(uint64(bufferPercent)))
+	if err != nil {
+		return nil, err
+	}
+	if buffer, err = buffer.Div(util.NewUint128FromUint(100)); err != nil {
+		return nil, err
+	}
+	suggested, err := gasLimit.Add(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if suggested.Cmp(TransactionMaxGas) > 0 {
+		suggested = TransactionMaxGas
+	}
+	return suggested, nil
+}
+
+// PreCheck check the tx against the block without executing it, the block is never changed.
+// It covers time lock, timestamp, value receiver, base gas, balance and stale nonce.
+func (tx *Transaction) PreCheck(block *Block) error {
+	if block == nil {
+		return ErrNilArgument
+	}
+	if tx.notBefore > block.Timestamp() {
+		return ErrTransactionNotYetValid
+	}
+	if tx.IsExpired(block.Timestamp()) {
+		return ErrTransactionExpired
+	}
+	if drift := MaxTimestampDriftOf(tx.chainID); drift > 0 {
+		if err := tx.VerifyTimestamp(block.Timestamp(), drift); err != nil {
+			return err
+		}
+	}
+	if IsRejectingNoOpTransfers(tx.chainID) && tx.IsNoOp() {
+		return ErrNoOpTransaction
+	}
+	if tx.value.Cmp(util.NewUint128()) > 0 && IsReservedAddress(tx.chainID, block.Height(), tx.to) {
+		return ErrTransferToReservedAddress
+	}
+
+	gasUsed, err := tx.GasCountOfTxBase()
+	if err != nil {
+		return err
+	}
+	if tx.gasLimit.Cmp(gasUsed) < 0 {
+		return ErrOutOfGasLimit
+	}
+
+	minBalanceRequired, err := tx.minBalanceRequiredAt(block)
+	if err != nil {
+		return err
+	}
+	balance, err := block.GetBalance(tx.from.address)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(minBalanceRequired) < 0 {
+		return ErrInsufficientBalance
+	}
+
+	// nonce of nonceless tx is checked by the wallet contract in execution.
+	if tx.nonceless {
+		return nil
+	}
+	nonce, err := block.GetNonce(tx.from.address)
+	if err != nil {
+		return err
+	}
+	if tx.nonce <= nonce {
+		return ErrSmallTransactionNonce
+	}
+	return nil
+}
+
+// IsNoOp return if tx is a binary tx to self with zero value and empty payload, which does nothing but consume gas.
+func (tx *Transaction) IsNoOp() bool {
+	return tx.Type() == TxPayloadBinaryType &&
+		tx.from.Equals(tx.to) &&
+		tx.value.Cmp(util.NewUint128()) == 0 &&
+		len(tx.data.Payload) == 0
+}
+
+// VerifyTimestamp check the tx timestamp is not more than maxDrift seconds ahead of blockTime
+func (tx *Transaction) VerifyTimestamp(blockTime, maxDrift int64) error {
+	if tx.timestamp-blockTime > maxDrift {
+		return ErrTransactionTimestampTooFarAhead
+	}
+	return nil
+}
+
+// StaticCall execute the call tx in read-only mode and return the result, as STATICCALL of EVM.
+// Any state mutation in the call fails with ErrReadOnlyViolation, the block is never changed.
+func (tx *Transaction) StaticCall(block *Block) (string, error) {
+	if block == nil {
+		return "", ErrNilArgument
+	}
+	payload, err := tx.LoadPayload()
+	if err != nil {
+		return "", err
+	}
+	call, ok := payload.(*CallPayload)
+	if !ok {
+		return "", ErrInvalidTxPayloadType
+	}
+
+	txBlock, err := block.Clone()
+	if err != nil {
+		return "", err
+	}
+	txBlock.begin()
+	defer txBlock.rollback()
+
+	ctx := WithTraceID(context.Background(), tx.hash.String())
+	_, result, err := call.call(ctx, txBlock, tx, true)
+	return result, err
+}
+
+// ExecTraceStep one step of the gas accounting in VerifyExecution
+type ExecTraceStep struct {
+	Step     string
+	GasDelta *util.Uint128
+	Decision string
+}
+
+// ExecTrace the gas accounting trace of VerifyExecution
+type ExecTrace struct {
+	Steps []*ExecTraceStep
+}
+
+func (trace *ExecTrace) record(step string, gasDelta *util.Uint128, decision string) {
+	if gasDelta == nil {
+		gasDelta = util.NewUint128()
+	}
+	trace.Steps = append(trace.Steps, &ExecTraceStep{
+		Step:     step,
+		GasDelta: gasDelta,
+		Decision: decision,
+	})
+}
+
+type traceIDKey struct{}
+
+// WithTraceID return a copy of ctx carrying the trace ID, which is logged with the tx execution
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceID return the trace ID carried by ctx, empty if not set
+func TraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value(traceIDKey{}).(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// detachedContext carry the values of the context, e.g. the trace ID, without its deadline and cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// VerifyExecution transaction and return result.
+func (tx *Transaction) VerifyExecution(block *Block) (*util.Uint128, error) {
+	return tx.VerifyExecutionCtx(context.Background(), block)
+}
+
+// VerifyExecutionCtx verify transaction execution and return result, logs are correlated
+// by the trace ID in ctx, which is the tx hash if not set. The deadline and cancellation of ctx
+// never abort the execution, as the result must be the same on every node.
+func (tx *Transaction) VerifyExecutionCtx(ctx context.Context, block *Block) (*util.Uint128, error) {
+	gasUsed, _, err := tx.verifyExecution(ctx, block)
+	return gasUsed, err
+}
+
+// VerifyExecutionTraced verify transaction execution and return result with the gas accounting trace.
+func (tx *Transaction) VerifyExecutionTraced(block *Block) (*util.Uint128, *ExecTrace, error) {
+	return tx.verifyExecution(context.Background(), block)
+}
+
+func (tx *Transaction) verifyExecution(ctx context.Context, block *Block) (*util.Uint128, *ExecTrace, error) {
+	trace := &ExecTrace{}
+	if block == nil {
+		return nil, trace, ErrNilArgument
+	}
+	if TraceID(ctx) == "" {
+		ctx = WithTraceID(ctx, tx.hash.String())
+	}
+	ctx = detachedContext{ctx}
+
+	// step0. check time lock
+	if tx.notBefore > block.Timestamp() {
+		trace.record("time lock", nil, "not yet valid")
+		return nil, trace, ErrTransactionNotYetValid
+	}
+
+	// step0. check expiry, expired tx is dropped without charging any gas
+	if tx.IsExpired(block.Timestamp()) {
+		trace.record("expiry", nil, "expired")
+		return nil, trace, ErrTransactionExpired
+	}
+
+	// step0. check timestamp, tx from the far future is rejected
+	if drift := MaxTimestampDriftOf(tx.chainID); drift > 0 {
+		if err := tx.VerifyTimestamp(block.Timestamp(), drift); err != nil {
+			trace.record("timestamp", nil, "too far ahead")
+			return nil, trace, err
+		}
+	}
+
+	// step0. check blacklist, tx of blacklisted address is dropped without charging any gas
+	if blacklist, receivers := TransactionBlacklistOf(tx.chainID); blacklist != nil {
+		if blacklist.IsBlacklisted(block, tx.from) ||
+			(receivers && blacklist.IsBlacklisted(block, tx.to)) {
+			trace.record("blacklist", nil, "blacklisted address")
+			return nil, trace, ErrAddressBlacklisted
+		}
 	}
 
-	gasExecution, result, exeErr := payload.Execute(txBlock, tx)
+	// step0. check no-op transfer
+	if IsRejectingNoOpTransfers(tx.chainID) && tx.IsNoOp() {
+		trace.record("no-op", nil, "rejected")
+		return nil, trace, ErrNoOpTransaction
+	}
 
-	gasUsed, err = gasUsed.Add(gasExecution)
-	if err != nil {
-		return nil, result, err
+	// step0. check fee cap against the base fee of block
+	if tx.feeCap().Cmp(block.BaseFee()) < 0 {
+		trace.record("base fee", nil, "max fee below base fee")
+		return nil, trace, ErrMaxFeeBelowBaseFee
 	}
-	return gasUsed, result, exeErr
-}
 
-// VerifyExecution transaction and return result.
-func (tx *Transaction) VerifyExecution(block *Block) (*util.Uint128, error) {
-	if block == nil {
-		return nil, ErrNilArgument
+	// step0. check value receiver, reserved addresses never receive value of txs
+	if tx.value.Cmp(util.NewUint128()) > 0 && IsReservedAddress(tx.chainID, block.Height(), tx.to) {
+		trace.record("transfer", nil, "reserved address")
+		return nil, trace, ErrTransferToReservedAddress
 	}
 
 	// step1. check gasLimit >= GasCountOfTxBase()
 	gasUsed, err := tx.GasCountOfTxBase()
 	if err != nil {
-		return nil, err
+		return nil, trace, err
 	}
 	if tx.gasLimit.Cmp(gasUsed) < 0 {
 		logging.VLog().WithFields(logrus.Fields{
+			"traceID":     TraceID(ctx),
 			"error":       ErrOutOfGasLimit,
 			"transaction": tx,
 			"limit":       tx.gasLimit,
 			"used":        gasUsed,
 		}).Debug("Failed to check gasLimit.")
-		return nil, ErrOutOfGasLimit
+		trace.record("base gas", gasUsed, "out of gas limit")
+		return nil, trace, ErrOutOfGasLimit
+	}
+	trace.record("base gas", gasUsed, "checked")
+
+	// step1. reject malformed deploy before anything of the tx is executed or charged.
+	payload, payloadErr := tx.LoadPayload()
+	if payloadErr == ErrContractTransactionAddressNotEqual {
+		trace.record("payload", nil, "deploy address not equal")
+		return nil, trace, payloadErr
+	}
+	if payloadErr == ErrEmptyContractSource {
+		trace.record("payload", nil, "empty contract source")
+		return nil, trace, payloadErr
 	}
 
-	// step2. check balance >= gasLimit*gasPric + tx.value
-	minBalanceRequired, err := tx.MinBalanceRequired()
+	// step2. check balance >= gasLimit*gasPric + transferred value, the fee of failed tx is charged from it
+	minBalanceRequired, err := tx.minBalanceRequiredAt(block)
 	if err != nil {
-		return nil, err
+		return nil, trace, err
 	}
 	fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
 	if err != nil {
-		return nil, err
+		return nil, trace, err
 	}
 	if fromAcc.Balance().Cmp(minBalanceRequired) < 0 {
 		logging.VLog().WithFields(logrus.Fields{
+			"traceID":            TraceID(ctx),
 			"from":               fromAcc,
 			"minBalanceRequired": minBalanceRequired,
 			"error":              ErrInsufficientBalance,
@@ -434,191 +1851,723 @@ func (tx *Transaction) VerifyExecution(block *Block) (*util.Uint128, error) {
 			"limit":              tx.gasLimit.String(),
 			"used":               gasUsed.String(),
 		}).Debug("Failed to check from balance.")
-		return nil, ErrInsufficientBalance
+		trace.record("balance", nil, "insufficient balance")
+		return nil, trace, ErrInsufficientBalance
+	}
+	trace.record("balance", nil, "checked")
+
+	// step2. escrow the tip of gasLimit in fee token, the tx fails and pays the base gas in native coin
+	// if the token can't pay, the payload is never executed for free.
+	if tx.feeToken != nil && !IsGaslessMode(tx.chainID) {
+		if escrowErr := tx.escrowFeeToken(ctx, block); escrowErr != nil {
+			trace.record("fee token", nil, "escrow failed")
+
+			if err := tx.chargeNativeGas(block, gasUsed); err != nil {
+				return nil, trace, err
+			}
+			trace.record("consume gas", nil, "charged "+gasUsed.String())
+			if err := tx.recordResultEvent(block, gasUsed, "", escrowErr); err != nil {
+				return nil, trace, err
+			}
+
+			metricsTxExeFailed.Mark(1)
+			return gasUsed, trace, nil
+		}
+		trace.record("fee token", nil, "escrowed")
+	}
+
+	// step2. check nonce of nonceless tx by the wallet contract, the hook is always charged its gas cap
+	if tx.nonceless {
+		gasUsed, err = gasUsed.Add(ContractNonceGasLimit)
+		if err != nil {
+			return nil, trace, err
+		}
+		if tx.gasLimit.Cmp(gasUsed) < 0 {
+			trace.record("nonce", gasUsed, "out of gas limit")
+			return nil, trace, ErrOutOfGasLimit
+		}
+		if nonceErr := tx.validateContractNonce(ctx, block); nonceErr != nil {
+			trace.record("nonce", ContractNonceGasLimit, "rejected by contract")
+
+			if err := tx.chargeGas(ctx, block, gasUsed); err != nil {
+				return nil, trace, err
+			}
+			trace.record("consume gas", nil, "charged "+gasUsed.String())
+			if err := tx.recordResultEvent(block, gasUsed, "", nonceErr); err != nil {
+				return nil, trace, err
+			}
+
+			metricsTxExeFailed.Mark(1)
+			return gasUsed, trace, nil
+		}
+		trace.record("nonce", ContractNonceGasLimit, "checked by contract")
+	}
+
+	// step2. check condition by the predicate contract, skip the payload if not met
+	if tx.condition != nil {
+		if !tx.checkCondition(ctx, block) {
+			trace.record("condition", nil, "not met")
+
+			if err := tx.chargeGas(ctx, block, gasUsed); err != nil {
+				return nil, trace, err
+			}
+			trace.record("consume gas", nil, "charged "+gasUsed.String())
+			if err := tx.recordResultEvent(block, gasUsed, "", ErrConditionNotMet); err != nil {
+				return nil, trace, err
+			}
+			return gasUsed, trace, nil
+		}
+		trace.record("condition", nil, "met")
 	}
 
 	// step3. check payload vaild
-	payload, payloadErr := tx.LoadPayload()
 	if payloadErr != nil {
 		logging.VLog().WithFields(logrus.Fields{
+			"traceID":     TraceID(ctx),
 			"payloadErr":  payloadErr,
 			"block":       block,
 			"transaction": tx,
 		}).Debug("Failed to load payload.")
+		trace.record("payload", nil, "payload load failed")
 
-		gas, err := tx.gasPrice.Mul(gasUsed)
-		if err != nil {
-			return nil, err
-		}
-		if err := tx.transfer(block, tx.from, block.Coinbase(), gas); err != nil {
-			return nil, err
+		if err := tx.chargeGas(ctx, block, gasUsed); err != nil {
+			return nil, trace, err
 		}
-		if err := tx.recordResultEvent(block, gasUsed, payloadErr); err != nil {
-			return nil, err
+		trace.record("consume gas", nil, "charged "+gasUsed.String())
+		if err := tx.recordResultEvent(block, gasUsed, "", payloadErr); err != nil {
+			return nil, trace, err
 		}
 
 		metricsTxExeFailed.Mark(1)
-		return gasUsed, nil
+		return gasUsed, trace, nil
 	}
 
 	// step4. check gasLimit > gas + payload.baseGasCount
 	gasUsed, err = gasUsed.Add(payload.BaseGasCount())
 	if err != nil {
-		return nil, err
+		return nil, trace, err
 	}
 	if tx.gasLimit.Cmp(gasUsed) < 0 {
 		logging.VLog().WithFields(logrus.Fields{
-			"err":   ErrOutOfGasLimit,
-			"block": block,
-			"tx":    tx,
+			"traceID": TraceID(ctx),
+			"err":     ErrOutOfGasLimit,
+			"block":   block,
+			"tx":      tx,
 		}).Debug("Failed to check payload gas used.")
+		trace.record("payload base gas", payload.BaseGasCount(), "out of gas limit")
 
-		gas, err := tx.gasPrice.Mul(tx.gasLimit)
-		if err != nil {
-			return nil, err
-		}
-		if err := tx.transfer(block, tx.from, block.Coinbase(), gas); err != nil {
-			return nil, err
+		if err := tx.chargeGas(ctx, block, tx.gasLimit); err != nil {
+			return nil, trace, err
 		}
-		if err := tx.recordResultEvent(block, tx.gasLimit, ErrOutOfGasLimit); err != nil {
-			return nil, err
+		trace.record("consume gas", nil, "charged "+tx.gasLimit.String())
+		if err := tx.recordResultEvent(block, tx.gasLimit, "", ErrOutOfGasLimit); err != nil {
+			return nil, trace, err
 		}
 
 		metricsTxExeFailed.Mark(1)
-		return tx.gasLimit, nil
+		return tx.gasLimit, trace, nil
 	}
+	trace.record("payload base gas", payload.BaseGasCount(), "checked")
 
 	// step5. transfer tx value
 	// block begin
 	txBlock, err := block.Clone()
 	if err != nil {
-		return util.NewUint128(), err
+		return util.NewUint128(), trace, err
 	}
 
 	if err := tx.transfer(txBlock, tx.from, tx.to, tx.value); err != nil {
-		return nil, err
+		return nil, trace, err
 	}
+	trace.record("transfer", nil, "transferred "+tx.value.String())
 
 	// step6. execute payload
 	// execute smart contract and sub the calcute gas.
-	gasExecution, _, exeErr := payload.Execute(txBlock, tx)
+	gasExecution, result, exeErr := payload.Execute(ctx, txBlock, tx)
 
 	// step7. gas + gasExecution
 	// gas = tx.GasCountOfTxBase() +  gasExecution
 	gasUsed, gasErr := gasUsed.Add(gasExecution)
 	if gasErr != nil {
-		return nil, gasErr
+		return nil, trace, gasErr
 	}
 
 	if tx.gasLimit.Cmp(gasUsed) < 0 {
 		gasUsed = tx.gasLimit
 		exeErr = ErrOutOfGasLimit
 	}
+	if exeErr != nil {
+		trace.record("execute", gasExecution, exeErr.Error())
+	} else {
+		trace.record("execute", gasExecution, "executed")
+	}
+
+	// step8. consume gas, the fee of successful execution is charged before its state is merged.
+	if exeErr == nil {
+		if err := tx.chargeGas(ctx, txBlock, gasUsed); err != nil {
+			return nil, trace, err
+		}
+	}
 
 	// only execute success, merge the state to use
 	if exeErr == nil {
 		block.Merge(txBlock)
+		trace.record("merge", nil, "merged")
+	} else {
+		trace.record("merge", nil, "discarded")
+		if err := tx.chargeGas(ctx, block, gasUsed); err != nil {
+			return nil, trace, err
+		}
+	}
+	trace.record("consume gas", nil, "charged "+gasUsed.String())
+
+	if exeErr != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":      TraceID(ctx),
+			"exeErr":       exeErr,
+			"block":        block,
+			"tx":           tx,
+			"gasUsed":      gasUsed,
+			"gasExecution": gasExecution,
+		}).Debug("Failed to execute payload.")
+
+		metricsTxExeFailed.Mark(1)
+	} else {
+		metricsTxExeSuccess.Mark(1)
+	}
+
+	fee, err := tx.gasPrice.Mul(gasUsed)
+	if err != nil {
+		return nil, trace, err
+	}
+	if err := tx.recordResultEventWithFee(block, gasUsed, fee, result, exeErr); err != nil {
+		return nil, trace, err
+	}
+	return gasUsed, trace, nil
+}
+
+// chargeGas charge the fee of gasUsed from tx.from, baseFee*gasUsed is burned in native coin,
+// and the tip min(tipCap, feeCap-baseFee)*gasUsed is paid to the coinbase. The tip of fee token tx
+// is escrowed before execution, the tip of the unused gas is refunded in token.
+// Nothing is charged in gasless mode.
+func (tx *Transaction) chargeGas(ctx context.Context, block *Block, gasUsed *util.Uint128) error {
+	if IsGaslessMode(tx.chainID) {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID": TraceID(ctx),
+			"tx":      tx.hash,
+			"gasUsed": gasUsed,
+		}).Debug("Skip charging gas in gasless mode.")
+		return nil
+	}
+	if tx.feeToken == nil {
+		return tx.chargeNativeGas(block, gasUsed)
+	}
+
+	if err := tx.burnBaseFee(block, gasUsed); err != nil {
+		return err
+	}
+	tip, err := tx.tipPerGas(block.BaseFee())
+	if err != nil {
+		return err
+	}
+	unused, err := tx.gasLimit.Sub(gasUsed)
+	if err != nil {
+		return err
+	}
+	refund, err := tip.Mul(unused)
+	if err != nil {
+		return err
+	}
+	// the coinbase keeps the escrow if the token fails to refund it.
+	if err := tx.transferFeeToken(ctx, block, block.Coinbase(), tx.from, refund); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"tx":       tx.hash,
+			"feeToken": tx.feeToken,
+			"refund":   refund,
+		}).Debug("Failed to refund the escrowed gas fee in fee token.")
+	}
+	return nil
+}
+
+// chargeNativeGas charge the fee of gasUsed from tx.from in native coin, baseFee*gasUsed is burned,
+// and the tip is paid to the coinbase.
+func (tx *Transaction) chargeNativeGas(block *Block, gasUsed *util.Uint128) error {
+	tip, err := tx.tipPerGas(block.BaseFee())
+	if err != nil {
+		return err
+	}
+	if err := tx.burnBaseFee(block, gasUsed); err != nil {
+		return err
+	}
+	gas, err := tip.Mul(gasUsed)
+	if err != nil {
+		return err
+	}
+	return tx.transfer(block, tx.from, block.Coinbase(), gas)
+}
+
+// burnBaseFee burn baseFee*gasUsed from the native balance of tx.from.
+func (tx *Transaction) burnBaseFee(block *Block, gasUsed *util.Uint128) error {
+	baseFee := block.BaseFee()
+	if baseFee.Cmp(util.NewUint128()) == 0 {
+		return nil
+	}
+	burned, err := baseFee.Mul(gasUsed)
+	if err != nil {
+		return err
+	}
+	fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
+	if err != nil {
+		return err
+	}
+	return fromAcc.SubBalance(burned)
+}
+
+// escrowFeeToken transfer the tip of gasLimit from tx.from to the coinbase in fee token before execution.
+func (tx *Transaction) escrowFeeToken(ctx context.Context, block *Block) error {
+	tip, err := tx.tipPerGas(block.BaseFee())
+	if err != nil {
+		return err
+	}
+	escrow, err := tip.Mul(tx.gasLimit)
+	if err != nil {
+		return err
+	}
+	return tx.transferFeeToken(ctx, block, tx.from, block.Coinbase(), escrow)
+}
+
+// transferFeeToken transfer amount of the fee token by calling its transfer on behalf of from.
+func (tx *Transaction) transferFeeToken(ctx context.Context, block *Block, from, to *Address, amount *util.Uint128) error {
+	if amount.Cmp(util.NewUint128()) == 0 {
+		return nil
+	}
+	args, err := json.Marshal([]string{to.String(), amount.String()})
+	if err != nil {
+		return err
+	}
+	payload := NewCallPayload(FeeTokenTransferFunction, string(args))
+	data, err := payload.ToBytes()
+	if err != nil {
+		return err
+	}
+	// the token transfer runs as a call from the payer, its execution is limited by FeeTokenTransferGasLimit.
+	feeTx := &Transaction{
+		hash:      tx.hash,
+		from:      from,
+		to:        tx.feeToken,
+		value:     util.NewUint128(),
+		nonce:     tx.nonce,
+		timestamp: tx.timestamp,
+		data:      &corepb.Data{Type: TxPayloadCallType, Payload: data},
+		chainID:   tx.chainID,
+		gasPrice:  tx.gasPrice,
+		gasLimit:  FeeTokenTransferGasLimit,
+	}
+	feeBlock, err := block.Clone()
+	if err != nil {
+		return err
+	}
+	if _, _, err := payload.Execute(ctx, feeBlock, feeTx); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"err":      err,
+			"tx":       tx,
+			"feeToken": tx.feeToken,
+			"from":     from,
+			"amount":   amount,
+		}).Debug("Failed to transfer gas fee in fee token.")
+		return ErrFeeTokenTransferFailed
+	}
+	block.Merge(feeBlock)
+	return nil
+}
+
+// validateContractNonce call the nonce hook of the wallet contract at tx.to for the nonceless tx.
+// The hook is called read-only on a copy of block, it never changes the state of block.
+func (tx *Transaction) validateContractNonce(ctx context.Context, block *Block) error {
+	if _, err := block.CheckContract(tx.to); err != nil {
+		return ErrContractNonceRejected
+	}
+
+	args, err := json.Marshal([]string{tx.from.String(), strconv.FormatUint(tx.nonce, 10)})
+	if err != nil {
+		return err
+	}
+	payload := NewCallPayload(ContractNonceFunction, string(args))
+	data, err := payload.ToBytes()
+	if err != nil {
+		return err
+	}
+	nonceTx := &Transaction{
+		hash:      tx.hash,
+		from:      tx.from,
+		to:        tx.to,
+		value:     util.NewUint128(),
+		nonce:     tx.nonce,
+		timestamp: tx.timestamp,
+		data:      &corepb.Data{Type: TxPayloadCallType, Payload: data},
+		chainID:   tx.chainID,
+		gasPrice:  tx.gasPrice,
+		gasLimit:  ContractNonceGasLimit,
+	}
+	nonceBlock, err := block.Clone()
+	if err != nil {
+		return err
+	}
+	if _, _, err := payload.call(ctx, nonceBlock, nonceTx, true); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"err":      err,
+			"tx":       tx,
+			"contract": tx.to,
+		}).Debug("Contract rejected the nonce.")
+		return ErrContractNonceRejected
+	}
+	return nil
+}
+
+// checkCondition call the predicate function of the condition contract, the condition is met
+// only if it returns true. The predicate never changes the state of block.
+func (tx *Transaction) checkCondition(ctx context.Context, block *Block) bool {
+	if _, err := block.CheckContract(tx.condition.Contract); err != nil {
+		return false
+	}
+
+	payload := NewCallPayload(tx.condition.Function, "")
+	data, err := payload.ToBytes()
+	if err != nil {
+		return false
+	}
+	conditionTx := &Transaction{
+		hash:      tx.hash,
+		from:      tx.from,
+		to:        tx.condition.Contract,
+		value:     util.NewUint128(),
+		nonce:     tx.nonce,
+		timestamp: tx.timestamp,
+		data:      &corepb.Data{Type: TxPayloadCallType, Payload: data},
+		chainID:   tx.chainID,
+		gasPrice:  tx.gasPrice,
+		gasLimit:  ConditionGasLimit,
+	}
+	conditionBlock, err := block.Clone()
+	if err != nil {
+		return false
+	}
+	_, result, err := payload.Execute(ctx, conditionBlock, conditionTx)
+	if err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":   TraceID(ctx),
+			"err":       err,
+			"tx":        tx,
+			"condition": tx.condition.Contract,
+		}).Debug("Failed to check transaction condition.")
+		return false
+	}
+	return result == "true"
+}
+
+func (tx *Transaction) transfer(block *Block, from, to *Address, value *util.Uint128) error {
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	if err != nil {
+		return err
+	}
+
+	toAcc, err := block.accState.GetOrCreateUserAccount(to.address)
+	if err != nil {
+		return err
+	}
+
+	err = fromAcc.SubBalance(value)
+	if err != nil {
+		return err
+	}
+	err = toAcc.AddBalance(value)
+	return err
+}
+
+func (tx *Transaction) recordResultEvent(block *Block, gasUsed *util.Uint128, result string, err error) error {
+	return tx.recordResultEventWithFee(block, gasUsed, gasUsed, result, err)
+}
+
+// recordResultEventWithFee record the result event, the legacy gas_used field carries fee.
+func (tx *Transaction) recordResultEventWithFee(block *Block, gasUsed, fee *util.Uint128, result string, err error) error {
+	txEvent := &TransactionEvent{
+		Hash:       tx.hash.String(),
+		GasUsed:    fee.String(),
+		GasCount:   gasUsed.String(),
+		ResultHash: byteutils.Hash(hash.Sha3256([]byte(result))).String(),
+	}
+	if err == ErrConditionNotMet {
+		txEvent.Status = TxExecutionConditionNotMet
+		txEvent.Error = err.Error()
+	} else if err != nil {
+		txEvent.Status = TxExecutionFailed
+		txEvent.Error = err.Error()
+		txEvent.Revert = RevertReasonOf(err)
+	} else {
+		txEvent.Status = TxExecutionSuccess
+	}
+
+	txData, err := json.Marshal(txEvent)
+	if err != nil {
+		return err
+	}
+
+	if err := block.recordReceipt(tx.hash, txData); err != nil {
+		return err
+	}
+
+	event := &Event{
+		Topic: TopicTransactionExecutionResult,
+		Data:  string(txData)}
+	return block.recordEvent(tx.hash, event)
+}
+
+// Sign sign transaction,sign algorithm is
+func (tx *Transaction) Sign(signature keystore.Signature) error {
+	if signature == nil {
+		return ErrNilArgument
+	}
+	hash, err := HashTransaction(tx)
+	if err != nil {
+		return err
+	}
+	sign, err := signature.Sign(hash)
+	if err != nil {
+		return err
+	}
+	tx.hash = hash
+	tx.alg = signature.Algorithm()
+	tx.sign = sign
+	return nil
+}
+
+// CanReplace return true if tx can replace the old pending tx, which means they have the same from and nonce,
+// and tx's gasPrice is higher than old's by at least minBumpPercent.
+func (tx *Transaction) CanReplace(old *Transaction, minBumpPercent int) bool {
+	if old == nil || minBumpPercent < 0 {
+		return false
+	}
+	if !tx.from.Equals(old.from) || tx.nonce != old.nonce {
+		return false
+	}
+	if tx.gasPrice.Cmp(old.gasPrice) <= 0 {
+		return false
+	}
+
+	// minGasPrice = old.gasPrice * (100 + minBumpPercent) / 100
+	minGasPrice, err := old.gasPrice.Mul(util.NewUint128FromUint(uint64(100 + minBumpPercent)))
+	if err != nil {
+		return false
+	}
+	minGasPrice, err = minGasPrice.Div(util.NewUint128FromUint(100))
+	if err != nil {
+		return false
+	}
+	return tx.gasPrice.Cmp(minGasPrice) >= 0
+}
+
+// InvalidateSign clear the hash and signature of transaction, so it can be modified and signed again.
+func (tx *Transaction) InvalidateSign() {
+	tx.hash = nil
+	tx.alg = 0
+	tx.sign = nil
+}
+
+// WithNonce return a new unsigned transaction with the given nonce.
+func (tx *Transaction) WithNonce(nonce uint64) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
+	if err != nil {
+		return nil, err
+	}
+	ntx.nonce = nonce
+	return ntx, nil
+}
+
+// WithGasPrice return a new unsigned transaction with the given gasPrice.
+func (tx *Transaction) WithGasPrice(gasPrice *util.Uint128) (*Transaction, error) {
+	if gasPrice == nil {
+		return nil, ErrNilArgument
+	}
+	ntx, err := tx.unsignedCopy()
+	if err != nil {
+		return nil, err
 	}
+	ntx.gasPrice = gasPrice
+	return ntx, nil
+}
 
-	// step8. consume gas
-	gas, err := tx.gasPrice.Mul(gasUsed)
+// WithGasLimit return a new unsigned transaction with the given gasLimit.
+func (tx *Transaction) WithGasLimit(gasLimit *util.Uint128) (*Transaction, error) {
+	if gasLimit == nil {
+		return nil, ErrNilArgument
+	}
+	ntx, err := tx.unsignedCopy()
 	if err != nil {
 		return nil, err
 	}
-	if err := tx.transfer(block, tx.from, block.Coinbase(), gas); err != nil {
+	ntx.gasLimit = gasLimit
+	return ntx, nil
+}
+
+// WithNotBefore return a new unsigned transaction locked until the given timestamp, 0 means no lock.
+func (tx *Transaction) WithNotBefore(notBefore int64) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
+	if err != nil {
 		return nil, err
 	}
+	ntx.notBefore = notBefore
+	return ntx, nil
+}
 
-	if exeErr != nil {
-		logging.VLog().WithFields(logrus.Fields{
-			"exeErr":       exeErr,
-			"block":        block,
-			"tx":           tx,
-			"gasUsed":      gasUsed,
-			"gasExecution": gasExecution,
-		}).Debug("Failed to execute payload.")
-
-		metricsTxExeFailed.Mark(1)
-	} else {
-		metricsTxExeSuccess.Mark(1)
+// WithExpiryTimestamp return a new unsigned transaction expired after the given timestamp, 0 means no expiry.
+func (tx *Transaction) WithExpiryTimestamp(expiry int64) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
+	if err != nil {
+		return nil, err
 	}
+	ntx.expiry = expiry
+	return ntx, nil
+}
 
-	if err := tx.recordResultEvent(block, gas, exeErr); err != nil {
+// WithTypedSigning return a new unsigned transaction signed over the typed data hash if typed,
+// otherwise over the legacy hash.
+func (tx *Transaction) WithTypedSigning(typed bool) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
+	if err != nil {
 		return nil, err
 	}
+	ntx.typed = typed
+	return ntx, nil
+}
 
-	return gasUsed, nil
+// IsExpired return if the tx is expired at the given block timestamp.
+func (tx *Transaction) IsExpired(blockTimestamp int64) bool {
+	return tx.expiry != 0 && blockTimestamp > tx.expiry
 }
 
-func (tx *Transaction) transfer(block *Block, from, to *Address, value *util.Uint128) error {
-	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+// WithFeeToken return a new unsigned transaction paying gas fee in the token contract, nil means native coin.
+func (tx *Transaction) WithFeeToken(feeToken *Address) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	ntx.feeToken = feeToken
+	return ntx, nil
+}
 
-	toAcc, err := block.accState.GetOrCreateUserAccount(to.address)
-	if err != nil {
-		return err
+// WithDynamicFee return a new unsigned transaction paying the base fee of block plus a tip up to
+// maxPriorityFeePerGas, capped at maxFeePerGas per gas. gasPrice is set to maxFeePerGas
+// for the pool to bound and order the tx.
+func (tx *Transaction) WithDynamicFee(maxFeePerGas, maxPriorityFeePerGas *util.Uint128) (*Transaction, error) {
+	if maxFeePerGas == nil || maxPriorityFeePerGas == nil {
+		return nil, ErrNilArgument
 	}
-
-	err = fromAcc.SubBalance(value)
+	if maxPriorityFeePerGas.Cmp(maxFeePerGas) > 0 {
+		return nil, ErrInvalidArgument
+	}
+	ntx, err := tx.unsignedCopy()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = toAcc.AddBalance(value)
-	return err
+	ntx.gasPrice = maxFeePerGas
+	ntx.maxFeePerGas = maxFeePerGas
+	ntx.maxPriorityFeePerGas = maxPriorityFeePerGas
+	return ntx, nil
 }
 
-func (tx *Transaction) recordResultEvent(block *Block, gasUsed *util.Uint128, err error) error {
-
-	txEvent := &TransactionEvent{
-		Hash:    tx.hash.String(),
-		GasUsed: gasUsed.String(),
-	}
+// WithNonceless return a new unsigned transaction whose nonce is validated by the wallet contract at tx.to.
+func (tx *Transaction) WithNonceless(nonceless bool) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
 	if err != nil {
-		txEvent.Status = TxExecutionFailed
-		txEvent.Error = err.Error()
-	} else {
-		txEvent.Status = TxExecutionSuccess
+		return nil, err
 	}
+	ntx.nonceless = nonceless
+	return ntx, nil
+}
 
-	txData, err := json.Marshal(txEvent)
+// WithCondition return a new unsigned transaction executed only if the condition holds, nil means unconditional.
+func (tx *Transaction) WithCondition(condition *Condition) (*Transaction, error) {
+	ntx, err := tx.unsignedCopy()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	event := &Event{
-		Topic: TopicTransactionExecutionResult,
-		Data:  string(txData)}
-	return block.recordEvent(tx.hash, event)
+	ntx.condition = condition
+	return ntx, nil
 }
 
-// Sign sign transaction,sign algorithm is
-func (tx *Transaction) Sign(signature keystore.Signature) error {
-	if signature == nil {
-		return ErrNilArgument
+// unsignedCopy copy the transaction without hash and signature,
+// signed transaction must be invalidated by InvalidateSign first.
+func (tx *Transaction) unsignedCopy() (*Transaction, error) {
+	if tx.sign != nil {
+		return nil, ErrTransactionSigned
 	}
-	hash, err := HashTransaction(tx)
-	if err != nil {
-		return err
+	ntx := &Transaction{
+		from:      tx.from,
+		to:        tx.to,
+		value:     tx.value,
+		nonce:     tx.nonce,
+		timestamp: tx.timestamp,
+		chainID:   tx.chainID,
+		gasPrice:  tx.gasPrice,
+		gasLimit:  tx.gasLimit,
+		notBefore: tx.notBefore,
+		expiry:    tx.expiry,
+		feeToken:  tx.feeToken,
+		nonceless: tx.nonceless,
+		condition: tx.condition,
+		typed:     tx.typed,
+
+		maxFeePerGas:         tx.maxFeePerGas,
+		maxPriorityFeePerGas: tx.maxPriorityFeePerGas,
 	}
-	sign, err := signature.Sign(hash)
-	if err != nil {
-		return err
+	if tx.data != nil {
+		ntx.data = &corepb.Data{Type: tx.data.Type, Payload: append([]byte(nil), tx.data.Payload...)}
 	}
-	tx.hash = hash
-	tx.alg = signature.Algorithm()
-	tx.sign = sign
-	return nil
+	return ntx, nil
+}
+
+// SupportedSignatureAlgorithms return the signature algorithms accepted for txs on the chain.
+func SupportedSignatureAlgorithms(chainID uint32) []keystore.Algorithm {
+	// all chains only accept secp256k1 now, add new algorithms such as ed25519 here.
+	return []keystore.Algorithm{keystore.SECP256K1}
+}
+
+func isSupportedSignatureAlgorithm(chainID uint32, alg keystore.Algorithm) bool {
+	for _, v := range SupportedSignatureAlgorithms(chainID) {
+		if v == alg {
+			return true
+		}
+	}
+	return false
 }
 
 // VerifyIntegrity return transaction verify result, including Hash and Signature.
 func (tx *Transaction) VerifyIntegrity(chainID uint32) error {
+	return tx.verifyIntegrity(chainID, nil)
+}
+
+// verifiedSigner is the signature cache entry of a verified tx.
+type verifiedSigner struct {
+	from *Address
+	sign []byte
+}
+
+// verifyIntegrity verify the tx like VerifyIntegrity, the signer verified before is taken from
+// the cache keyed by tx hash to skip the signature recovery. nil cache always recovers.
+func (tx *Transaction) verifyIntegrity(chainID uint32, signers *lru.Cache) error {
 	// check ChainID.
 	if tx.chainID != chainID {
 		return ErrInvalidChainID
 	}
 
+	// check Algorithm.
+	if !isSupportedSignatureAlgorithm(chainID, tx.alg) {
+		return ErrUnsupportedSignatureAlgorithm
+	}
+
 	// check Hash.
 	wantedHash, err := HashTransaction(tx)
 	if err != nil {
@@ -628,25 +2577,65 @@ func (tx *Transaction) VerifyIntegrity(chainID uint32) error {
 		return ErrInvalidTransactionHash
 	}
 
-	// check Signature.
+	// check Signature, the hash is confirmed above, so a cached entry with same signer and signature is verified.
+	key := tx.hash.Hex()
+	if signers != nil {
+		if v, ok := signers.Get(key); ok {
+			entry := v.(*verifiedSigner)
+			if tx.from.Equals(entry.from) && bytes.Equal(tx.sign, entry.sign) {
+				return nil
+			}
+		}
+	}
+	if err := tx.verifySign(); err != nil {
+		return err
+	}
+	if signers != nil {
+		signers.Add(key, &verifiedSigner{from: tx.from, sign: tx.sign})
+	}
+	return nil
+}
+
+// VerifySignatureOnly verify the signature against the stored hash, skip chainID and hash recomputation.
+// The stored hash is trusted, so it's only for txs from our own sealed blocks.
+// NEVER use it on untrusted network input, use VerifyIntegrity instead.
+func (tx *Transaction) VerifySignatureOnly() error {
 	return tx.verifySign()
+}
 
+// recoverSigner recover the address signed the tx, it's a var for tests to spy on.
+var recoverSigner = func(tx *Transaction) (*Address, error) {
+	return addressFromSignatureWithPrefix(tx.alg, tx.hash, tx.sign, NetworkAddressPrefix(tx.chainID))
 }
 
-func (tx *Transaction) verifySign() error {
-	signature, err := crypto.NewSignature(tx.alg)
-	if err != nil {
-		return err
+// secp256k1HalfN half the order of secp256k1, the S of a low-S signature is not above it.
+var secp256k1HalfN = new(big.Int).Rsh(secp256k1.S256().Params().N, 1)
+
+// IsMalleableSignature return if the signature has a twin signing the same hash by the same key,
+// a secp256k1 signature [R || S || V] with high S is malleable into [R || N-S || V^1].
+func IsMalleableSignature(alg keystore.Algorithm, sign []byte) bool {
+	if alg != keystore.SECP256K1 || len(sign) != 65 {
+		return false
 	}
-	pub, err := signature.RecoverPublic(tx.hash, tx.sign)
-	if err != nil {
-		return err
+	s := new(big.Int).SetBytes(sign[32:64])
+	return s.Cmp(secp256k1HalfN) > 0
+}
+
+// verifyLowS reject the malleable signature of tx in the block of height, from the low-S signature height
+// of the chain on.
+func (tx *Transaction) verifyLowS(height uint64) error {
+	lowSHeight := LowSSignatureHeightOf(tx.chainID)
+	if lowSHeight == 0 || height < lowSHeight {
+		return nil
 	}
-	pubdata, err := pub.Encoded()
-	if err != nil {
-		return err
+	if IsMalleableSignature(tx.alg, tx.sign) {
+		return ErrMalleableSignature
 	}
-	addr, err := NewAddressFromPublicKey(pubdata)
+	return nil
+}
+
+func (tx *Transaction) verifySign() error {
+	addr, err := recoverSigner(tx)
 	if err != nil {
 		return err
 	}
@@ -662,11 +2651,12 @@ func (tx *Transaction) verifySign() error {
 
 // GenerateContractAddress according to tx.from and tx.nonce.
 func (tx *Transaction) GenerateContractAddress() (*Address, error) {
-	return NewContractAddressFromHash(hash.Sha3256(tx.from.Bytes(), byteutils.FromUint64(tx.nonce)))
+	h := hash.Sha3256(tx.from.Bytes(), byteutils.FromUint64(tx.nonce))
+	return NewAddressWithPrefix(h[len(h)-AddressDataLength:], NetworkAddressPrefix(tx.chainID))
 }
 
-// HashTransaction hash the transaction.
-func HashTransaction(tx *Transaction) (byteutils.Hash, error) {
+// SigningPreimage returns the bytes fed into the chain TxHasher by HashTransaction, before hashing.
+func (tx *Transaction) SigningPreimage() ([]byte, error) {
 	value, err := tx.value.ToFixedSizeByteSlice()
 	if err != nil {
 		return nil, err
@@ -683,7 +2673,7 @@ func HashTransaction(tx *Transaction) (byteutils.Hash, error) {
 	if err != nil {
 		return nil, err
 	}
-	return hash.Sha3256(
+	fields := [][]byte{
 		tx.from.address,
 		tx.to.address,
 		value,
@@ -693,5 +2683,374 @@ func HashTransaction(tx *Transaction) (byteutils.Hash, error) {
 		byteutils.FromUint32(tx.chainID),
 		gasPrice,
 		gasLimit,
-	), nil
+	}
+	// the optional fields are mixed in only if set, keep the hash of other txs unchanged.
+	if tx.notBefore != 0 {
+		fields = append(fields, optionalPreimageField("notBefore", byteutils.FromInt64(tx.notBefore)))
+	}
+	if tx.feeToken != nil {
+		fields = append(fields, optionalPreimageField("feeToken", tx.feeToken.address))
+	}
+	if tx.nonceless {
+		fields = append(fields, optionalPreimageField("nonceless", []byte{1}))
+	}
+	if tx.condition != nil {
+		fields = append(fields,
+			optionalPreimageField("condition.contract", tx.condition.Contract.address),
+			optionalPreimageField("condition.function", []byte(tx.condition.Function)))
+	}
+	if tx.maxFeePerGas != nil {
+		maxFeePerGas, err := tx.maxFeePerGas.ToFixedSizeByteSlice()
+		if err != nil {
+			return nil, err
+		}
+		maxPriorityFeePerGas, err := tx.maxPriorityFeePerGas.ToFixedSizeByteSlice()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields,
+			optionalPreimageField("maxFeePerGas", maxFeePerGas),
+			optionalPreimageField("maxPriorityFeePerGas", maxPriorityFeePerGas))
+	}
+	if tx.expiry != 0 {
+		fields = append(fields, optionalPreimageField("expiry", byteutils.FromInt64(tx.expiry)))
+	}
+	return bytes.Join(fields, nil), nil
+}
+
+// optionalPreimageField encode an optional field of the signing preimage as its tag, value length and value,
+// so that the preimages of txs setting different optional fields never collide.
+func optionalPreimageField(tag string, value []byte) []byte {
+	return bytes.Join([][]byte{[]byte(tag), byteutils.FromUint32(uint32(len(value))), value}, nil)
+}
+
+// HashTransaction hash the transaction, typed txs are hashed by HashTypedTransaction in the default domain.
+func HashTransaction(tx *Transaction) (byteutils.Hash, error) {
+	if tx.typed {
+		return HashTypedTransaction(tx, DefaultTypedDataDomain(tx.chainID))
+	}
+	preimage, err := tx.SigningPreimage()
+	if err != nil {
+		return nil, err
+	}
+	return TxHasherOf(tx.chainID)(preimage), nil
+}
+
+// ResignTransaction recompute the hash of tx in the current format and sign it again, replacing the
+// stale hash and signature, such as after a change of the signing preimage. The signer must be tx.from,
+// otherwise ErrInvalidTransactionSigner is returned and tx is left unchanged.
+func ResignTransaction(tx *Transaction, signature keystore.Signature) error {
+	if tx == nil || signature == nil {
+		return ErrNilArgument
+	}
+	hash, err := HashTransaction(tx)
+	if err != nil {
+		return err
+	}
+	sign, err := signature.Sign(hash)
+	if err != nil {
+		return err
+	}
+	signer, err := addressFromSignatureWithPrefix(signature.Algorithm(), hash, sign, NetworkAddressPrefix(tx.chainID))
+	if err != nil {
+		return err
+	}
+	if !signer.Equals(tx.from) {
+		return ErrInvalidTransactionSigner
+	}
+	tx.hash = hash
+	tx.alg = signature.Algorithm()
+	tx.sign = sign
+	return nil
+}
+
+// TxHasher hash function used to compute transaction hash.
+type TxHasher func(args ...[]byte) []byte
+
+// Transaction hash algorithms supported in chain config.
+const (
+	TxHasherSha3256 = "SHA3256"
+	TxHasherSha256  = "SHA256"
+)
+
+var (
+	txHashers = map[string]TxHasher{
+		TxHasherSha3256: hash.Sha3256,
+		TxHasherSha256:  hash.Sha256,
+	}
+
+	chainTxHashers     = make(map[uint32]TxHasher)
+	chainTxHashersLock sync.RWMutex
+
+	gaslessChains     = make(map[uint32]bool)
+	gaslessChainsLock sync.RWMutex
+
+	noOpRejectingChains     = make(map[uint32]bool)
+	noOpRejectingChainsLock sync.RWMutex
+
+	chainBlacklists     = make(map[uint32]*chainBlacklist)
+	chainBlacklistsLock sync.RWMutex
+
+	lowSSignatureHeights     = make(map[uint32]uint64)
+	lowSSignatureHeightsLock sync.RWMutex
+
+	maxTimestampDrifts     = make(map[uint32]int64)
+	maxTimestampDriftsLock sync.RWMutex
+
+	mempoolPriorityWeights     = make(map[uint32][2]float64)
+	mempoolPriorityWeightsLock sync.RWMutex
+
+	eventBufferLimits     = make(map[uint32][2]int)
+	eventBufferLimitsLock sync.RWMutex
+
+	contractGasCaps     = make(map[uint32]map[byteutils.HexHash]*util.Uint128)
+	contractGasCapsLock sync.RWMutex
+)
+
+type chainBlacklist struct {
+	blacklist AddressBlacklist
+	receivers bool
+}
+
+// SetGaslessMode enable or disable the gasless mode of the chain. In gasless mode the balance is
+// only checked against the tx value and no gas fee is charged, the payload execution is still
+// limited by the gas limit of the tx.
+func SetGaslessMode(chainID uint32, enabled bool) {
+	gaslessChainsLock.Lock()
+	defer gaslessChainsLock.Unlock()
+
+	if !enabled {
+		delete(gaslessChains, chainID)
+		return
+	}
+	gaslessChains[chainID] = true
+}
+
+// IsGaslessMode return whether the chain runs in gasless mode.
+func IsGaslessMode(chainID uint32) bool {
+	gaslessChainsLock.RLock()
+	defer gaslessChainsLock.RUnlock()
+
+	return gaslessChains[chainID]
+}
+
+// SetRejectNoOpTransfers enable or disable rejecting the binary txs of the chain doing nothing
+// but consume gas, sent to self with zero value and empty payload.
+func SetRejectNoOpTransfers(chainID uint32, enabled bool) {
+	noOpRejectingChainsLock.Lock()
+	defer noOpRejectingChainsLock.Unlock()
+
+	if !enabled {
+		delete(noOpRejectingChains, chainID)
+		return
+	}
+	noOpRejectingChains[chainID] = true
+}
+
+// IsRejectingNoOpTransfers return whether the chain rejects no-op transfers.
+func IsRejectingNoOpTransfers(chainID uint32) bool {
+	noOpRejectingChainsLock.RLock()
+	defer noOpRejectingChainsLock.RUnlock()
+
+	return noOpRejectingChains[chainID]
+}
+
+// SetTransactionBlacklist set the blacklist consulted in VerifyExecution of txs of the chain, txs from
+// the blacklisted addresses are rejected, and txs to them as well if receivers is true. nil removes it.
+func SetTransactionBlacklist(chainID uint32, blacklist AddressBlacklist, receivers bool) {
+	chainBlacklistsLock.Lock()
+	defer chainBlacklistsLock.Unlock()
+
+	if blacklist == nil {
+		delete(chainBlacklists, chainID)
+		return
+	}
+	chainBlacklists[chainID] = &chainBlacklist{blacklist: blacklist, receivers: receivers}
+}
+
+// TransactionBlacklistOf return the blacklist of the chain and whether the receivers are checked,
+// nil means not checked.
+func TransactionBlacklistOf(chainID uint32) (AddressBlacklist, bool) {
+	chainBlacklistsLock.RLock()
+	defer chainBlacklistsLock.RUnlock()
+
+	if b, ok := chainBlacklists[chainID]; ok {
+		return b.blacklist, b.receivers
+	}
+	return nil, false
+}
+
+// SetLowSSignatureHeight set the height from which high-S secp256k1 signatures of txs of the chain
+// are rejected, 0 means never.
+func SetLowSSignatureHeight(chainID uint32, height uint64) {
+	lowSSignatureHeightsLock.Lock()
+	defer lowSSignatureHeightsLock.Unlock()
+
+	if height == 0 {
+		delete(lowSSignatureHeights, chainID)
+		return
+	}
+	lowSSignatureHeights[chainID] = height
+}
+
+// LowSSignatureHeightOf return the height from which high-S signatures are rejected on the chain.
+func LowSSignatureHeightOf(chainID uint32) uint64 {
+	lowSSignatureHeightsLock.RLock()
+	defer lowSSignatureHeightsLock.RUnlock()
+
+	return lowSSignatureHeights[chainID]
+}
+
+// SetMaxTimestampDrift set the max seconds of tx timestamp ahead of block timestamp on the chain,
+// 0 means not checked in VerifyExecution.
+func SetMaxTimestampDrift(chainID uint32, drift int64) {
+	maxTimestampDriftsLock.Lock()
+	defer maxTimestampDriftsLock.Unlock()
+
+	if drift <= 0 {
+		delete(maxTimestampDrifts, chainID)
+		return
+	}
+	maxTimestampDrifts[chainID] = drift
+}
+
+// MaxTimestampDriftOf return the max seconds of tx timestamp ahead of block timestamp on the chain.
+func MaxTimestampDriftOf(chainID uint32) int64 {
+	maxTimestampDriftsLock.RLock()
+	defer maxTimestampDriftsLock.RUnlock()
+
+	return maxTimestampDrifts[chainID]
+}
+
+// SetMempoolPriorityWeights set the weights of gasPrice and tx age in seconds in MempoolPriority of
+// txs of the chain, both 0 resets them to the default weights.
+func SetMempoolPriorityWeights(chainID uint32, gasPriceWeight, ageWeight float64) {
+	mempoolPriorityWeightsLock.Lock()
+	defer mempoolPriorityWeightsLock.Unlock()
+
+	if gasPriceWeight == 0 && ageWeight == 0 {
+		delete(mempoolPriorityWeights, chainID)
+		return
+	}
+	mempoolPriorityWeights[chainID] = [2]float64{gasPriceWeight, ageWeight}
+}
+
+// MempoolPriorityWeightsOf return the weights of gasPrice and tx age in MempoolPriority of the chain.
+func MempoolPriorityWeightsOf(chainID uint32) (float64, float64) {
+	mempoolPriorityWeightsLock.RLock()
+	defer mempoolPriorityWeightsLock.RUnlock()
+
+	if weights, ok := mempoolPriorityWeights[chainID]; ok {
+		return weights[0], weights[1]
+	}
+	return DefaultMempoolPriorityGasPriceWeight, DefaultMempoolPriorityAgeWeight
+}
+
+// SetEventBufferLimits set the max contract events and the max total bytes of their topic and data
+// recorded in a block of the chain, 0 means the default.
+func SetEventBufferLimits(chainID uint32, maxEvents, maxBytes int) {
+	eventBufferLimitsLock.Lock()
+	defer eventBufferLimitsLock.Unlock()
+
+	if maxEvents == 0 && maxBytes == 0 {
+		delete(eventBufferLimits, chainID)
+		return
+	}
+	eventBufferLimits[chainID] = [2]int{maxEvents, maxBytes}
+}
+
+// EventBufferLimitsOf return the max contract events and their bytes recorded in a block of the chain.
+func EventBufferLimitsOf(chainID uint32) (int, int) {
+	eventBufferLimitsLock.RLock()
+	defer eventBufferLimitsLock.RUnlock()
+
+	maxEvents, maxBytes := eventBufferLimits[chainID][0], eventBufferLimits[chainID][1]
+	if maxEvents == 0 {
+		maxEvents = DefaultMaxEventsPerBlock
+	}
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxEventBytesPerBlock
+	}
+	return maxEvents, maxBytes
+}
+
+// ParseContractGasCaps parse the gas caps of contracts in the form of "<contract address>:<gas>".
+func ParseContractGasCaps(entries []string) (map[byteutils.HexHash]*util.Uint128, error) {
+	caps := make(map[byteutils.HexHash]*util.Uint128)
+	for _, entry := range entries {
+		fields := strings.Split(entry, ":")
+		if len(fields) != 2 {
+			return nil, ErrInvalidArgument
+		}
+		addr, err := AddressParse(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		gasCap, err := util.NewUint128FromString(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		caps[addr.address.Hex()] = gasCap
+	}
+	return caps, nil
+}
+
+// SetContractGasCaps set the max gas any single call to the contracts of the chain consumes, keyed by
+// the contract address, empty caps removes them.
+func SetContractGasCaps(chainID uint32, caps map[byteutils.HexHash]*util.Uint128) {
+	contractGasCapsLock.Lock()
+	defer contractGasCapsLock.Unlock()
+
+	if len(caps) == 0 {
+		delete(contractGasCaps, chainID)
+		return
+	}
+	chainCaps := make(map[byteutils.HexHash]*util.Uint128, len(caps))
+	for addr, gasCap := range caps {
+		chainCaps[addr] = gasCap
+	}
+	contractGasCaps[chainID] = chainCaps
+}
+
+// ContractGasCapOf return the gas cap of the contract on the chain, nil means not capped.
+func ContractGasCapOf(chainID uint32, contract *Address) *util.Uint128 {
+	contractGasCapsLock.RLock()
+	defer contractGasCapsLock.RUnlock()
+
+	return contractGasCaps[chainID][contract.address.Hex()]
+}
+
+// ParseTxHasher return the TxHasher of the algorithm name, empty name means the default Sha3256.
+func ParseTxHasher(name string) (TxHasher, error) {
+	if len(name) == 0 {
+		return hash.Sha3256, nil
+	}
+	hasher, ok := txHashers[name]
+	if !ok {
+		return nil, ErrUnsupportedTxHasher
+	}
+	return hasher, nil
+}
+
+// SetTxHasher set the hasher used to hash txs of the chain, nil resets it to the default Sha3256.
+func SetTxHasher(chainID uint32, hasher TxHasher) {
+	chainTxHashersLock.Lock()
+	defer chainTxHashersLock.Unlock()
+
+	if hasher == nil {
+		delete(chainTxHashers, chainID)
+		return
+	}
+	chainTxHashers[chainID] = hasher
+}
+
+// TxHasherOf return the hasher used to hash txs of the chain.
+func TxHasherOf(chainID uint32) TxHasher {
+	chainTxHashersLock.RLock()
+	defer chainTxHashersLock.RUnlock()
+
+	if hasher, ok := chainTxHashers[chainID]; ok {
+		return hasher
+	}
+	return hash.Sha3256
 }
@@ -0,0 +1,152 @@
+// Copyright (C) 2017 go-nebulas authors
+//
+// This file is part of the go-nebulas library.
+//
+// the go-nebulas library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// the go-nebulas library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with the go-nebulas library.  If not, see <http://www.gnu.org/licenses/>.
+//
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nebulasio/go-nebulas/core/state"
+	"github.com/nebulasio/go-nebulas/util"
+)
+
+var (
+	// BatchTransferGasCountPerOutput gas count charged for each output of batch transfer
+	BatchTransferGasCountPerOutput, _ = util.NewUint128FromInt(5000)
+
+	// MaxBatchTransferOutputs max outputs in one batch transfer
+	MaxBatchTransferOutputs = 256
+)
+
+// BatchTransferOutput a recipient of batch transfer and the value sent to it
+type BatchTransferOutput struct {
+	To    string
+	Value string
+}
+
+// BatchTransferPayload carry the outputs transferred from tx.from in one tx
+type BatchTransferPayload struct {
+	Outputs []*BatchTransferOutput
+}
+
+// LoadBatchTransferPayload from bytes, every output must carry a valid address and value
+func LoadBatchTransferPayload(bytes []byte) (*BatchTransferPayload, error) {
+	payload := &BatchTransferPayload{}
+	if err := json.Unmarshal(bytes, payload); err != nil {
+		return nil, err
+	}
+	if _, _, err := payload.outputs(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// NewBatchTransferPayload without outputs
+func NewBatchTransferPayload() *BatchTransferPayload {
+	return &BatchTransferPayload{}
+}
+
+// AddOutput append an output sending value to the address
+func (payload *BatchTransferPayload) AddOutput(to *Address, value *util.Uint128) *BatchTransferPayload {
+	payload.Outputs = append(payload.Outputs, &BatchTransferOutput{
+		To:    to.String(),
+		Value: value.String(),
+	})
+	return payload
+}
+
+// outputs return the parsed recipients and values of payload.
+func (payload *BatchTransferPayload) outputs() ([]*Address, []*util.Uint128, error) {
+	if len(payload.Outputs) == 0 {
+		return nil, nil, ErrEmptyBatchTransfer
+	}
+	if len(payload.Outputs) > MaxBatchTransferOutputs {
+		return nil, nil, ErrTooManyBatchTransferOutputs
+	}
+	addrs := make([]*Address, len(payload.Outputs))
+	values := make([]*util.Uint128, len(payload.Outputs))
+	for i, output := range payload.Outputs {
+		if output == nil {
+			return nil, nil, ErrInvalidArgument
+		}
+		addr, err := AddressParse(output.To)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := util.NewUint128FromString(output.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		addrs[i] = addr
+		values[i] = value
+	}
+	return addrs, values, nil
+}
+
+// ToBytes serialize payload
+func (payload *BatchTransferPayload) ToBytes() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// BaseGasCount returns base gas count, scaled with the number of outputs
+func (payload *BatchTransferPayload) BaseGasCount() *util.Uint128 {
+	count, err := util.NewUint128FromInt(int64(len(payload.Outputs)))
+	if err != nil {
+		return util.NewUint128()
+	}
+	gas, err := BatchTransferGasCountPerOutput.Mul(count)
+	if err != nil {
+		return util.NewUint128()
+	}
+	return gas
+}
+
+// Execute batch transfer payload in tx, all the outputs are transferred from tx.from or none of them is.
+func (payload *BatchTransferPayload) Execute(ctx context.Context, block *Block, tx *Transaction) (*util.Uint128, string, error) {
+	if block == nil || tx == nil {
+		return util.NewUint128(), "", ErrNilArgument
+	}
+
+	addrs, values, err := payload.outputs()
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+
+	// the transfers are done in a cloned block, it is kept only if all of them succeed.
+	batchBlock, err := block.Clone()
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	for i, to := range addrs {
+		if IsReservedAddress(tx.chainID, block.Height(), to) {
+			return util.NewUint128(), "", ErrTransferToReservedAddress
+		}
+		// outputs are plain transfers, the receive hook of contracts is not called.
+		if _, err := batchBlock.accState.GetContractAccount(to.Bytes()); err == nil {
+			return util.NewUint128(), "", ErrBatchTransferToContract
+		} else if err != state.ErrAccountNotFound && err != state.ErrContractNotFound {
+			return util.NewUint128(), "", err
+		}
+		if err := tx.transfer(batchBlock, tx.from, to, values[i]); err != nil {
+			return util.NewUint128(), "", err
+		}
+	}
+	block.Merge(batchBlock)
+	return util.NewUint128(), "", nil
+}
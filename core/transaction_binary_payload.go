@@ -19,7 +19,16 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/nebulasio/go-nebulas/core/state"
 	"github.com/nebulasio/go-nebulas/util"
+	"github.com/nebulasio/go-nebulas/util/logging"
+	"github.com/sirupsen/logrus"
 )
 
 // BinaryPayload carry some data
@@ -39,6 +48,48 @@ func NewBinaryPayload(data []byte) *BinaryPayload {
 	}
 }
 
+// memoHMACPrefix marks the binary payload carrying a memo and its HMAC,
+// the layout is prefix + memo + HMAC-SHA256(key, memo).
+var memoHMACPrefix = []byte("memo-hmac:")
+
+// NewMemoHMACPayload with the memo and its HMAC keyed by the secret of recipient,
+// so that the recipient can detect a tampered memo.
+func NewMemoHMACPayload(memo []byte, key []byte) (*BinaryPayload, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidArgument
+	}
+	data := append(append([]byte{}, memoHMACPrefix...), memo...)
+	return NewBinaryPayload(append(data, memoHMAC(memo, key)...)), nil
+}
+
+// ParseMemoHMACPayload return the memo and HMAC carried by the binary payload bytes,
+// ErrInvalidMemoHMACPayload is returned if the payload is not built by NewMemoHMACPayload.
+func ParseMemoHMACPayload(payload []byte) ([]byte, []byte, error) {
+	if !bytes.HasPrefix(payload, memoHMACPrefix) || len(payload) < len(memoHMACPrefix)+sha256.Size {
+		return nil, nil, ErrInvalidMemoHMACPayload
+	}
+	split := len(payload) - sha256.Size
+	return payload[len(memoHMACPrefix):split], payload[split:], nil
+}
+
+// VerifyMemoHMAC return if the memo in the binary payload bytes matches its HMAC under key.
+func VerifyMemoHMAC(payload []byte, key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, ErrInvalidArgument
+	}
+	memo, mac, err := ParseMemoHMACPayload(payload)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(mac, memoHMAC(memo, key)), nil
+}
+
+func memoHMAC(memo []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(memo)
+	return mac.Sum(nil)
+}
+
 // ToBytes serialize payload
 func (payload *BinaryPayload) ToBytes() ([]byte, error) {
 	return payload.Data, nil
@@ -49,7 +100,67 @@ func (payload *BinaryPayload) BaseGasCount() *util.Uint128 {
 	return util.NewUint128()
 }
 
-// Execute the payload in tx
-func (payload *BinaryPayload) Execute(block *Block, tx *Transaction) (*util.Uint128, string, error) {
-	return util.NewUint128(), "", nil
+// Execute the payload in tx, value sent to a contract is passed to its receive hook which may reject it
+func (payload *BinaryPayload) Execute(ctx context.Context, block *Block, tx *Transaction) (*util.Uint128, string, error) {
+	if block == nil || tx == nil {
+		return util.NewUint128(), "", ErrNilArgument
+	}
+	if tx.value.Cmp(util.NewUint128()) <= 0 {
+		return util.NewUint128(), "", nil
+	}
+
+	contract, err := block.accState.GetContractAccount(tx.to.Bytes())
+	if err == state.ErrAccountNotFound || err == state.ErrContractNotFound {
+		// plain transfer to user account.
+		return util.NewUint128(), "", nil
+	}
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	owner, deploy, err := loadContractDeploy(block, contract)
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	if !strings.Contains(deploy.Source, ContractReceiveFunction) {
+		// contract without receive hook accepts the value as a plain transfer, no engine is run.
+		return util.NewUint128(), "", nil
+	}
+
+	payloadGasLimit, err := tx.PayloadGasLimit(payload)
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	// payloadGasLimit <= 0, v8 engine not limit the execution instructions
+	if payloadGasLimit.Cmp(util.NewUint128()) <= 0 {
+		return util.NewUint128(), "", ErrOutOfGasLimit
+	}
+
+	if err := block.nvm.CreateEngine(block, tx, owner, contract, block.accState); err != nil {
+		return util.NewUint128(), "", err
+	}
+	defer block.nvm.DisposeEngine()
+	defer block.enterContract(tx.to, ContractReceiveFunction)()
+
+	if err := block.nvm.SetEngineExecutionLimits(payloadGasLimit.Uint64()); err != nil {
+		return util.NewUint128(), "", err
+	}
+	if err := block.nvm.SetEngineExecutionContext(ctx); err != nil {
+		return util.NewUint128(), "", err
+	}
+
+	_, exeErr := block.nvm.ReceiveEngine(deploy.Source, deploy.SourceType)
+	gasCout, err := engineGasCount(block)
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	if exeErr != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"contract": tx.to,
+			"value":    tx.value,
+			"err":      exeErr,
+		}).Debug("Contract rejected the value.")
+		return gasCout, "", ErrContractRejectedValue
+	}
+	return gasCout, "", nil
 }
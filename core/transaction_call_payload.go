@@ -19,17 +19,80 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/nebulasio/go-nebulas/core/state"
 	"github.com/nebulasio/go-nebulas/util"
+	"github.com/nebulasio/go-nebulas/util/logging"
+	"github.com/sirupsen/logrus"
 )
 
 // CallPayload carry function call information
 type CallPayload struct {
+	function string
+	args     string
+}
+
+// callPayloadJSON the serialized form of CallPayload
+type callPayloadJSON struct {
 	Function string
 	Args     string
 }
 
+// MarshalJSON encode the payload as {"Function": ..., "Args": ...}
+func (payload *CallPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&callPayloadJSON{Function: payload.function, Args: payload.args})
+}
+
+// UnmarshalJSON decode the payload from {"Function": ..., "Args": ...}
+func (payload *CallPayload) UnmarshalJSON(data []byte) error {
+	v := new(callPayloadJSON)
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	payload.function = v.Function
+	payload.args = v.Args
+	return nil
+}
+
+// Function return the name of the called contract function
+func (payload *CallPayload) Function() string {
+	return payload.function
+}
+
+// Args return the raw json args of the call
+func (payload *CallPayload) Args() string {
+	return payload.args
+}
+
+// DecodedArgs parse the json args array into go values, numbers are decoded as json.Number
+// to keep their precision. Empty args means no argument.
+func (payload *CallPayload) DecodedArgs() ([]interface{}, error) {
+	if len(strings.TrimSpace(payload.args)) == 0 {
+		return []interface{}{}, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(payload.args))
+	decoder.UseNumber()
+	var args []interface{}
+	if err := decoder.Decode(&args); err != nil {
+		return nil, ErrInvalidCallArgs
+	}
+	if decoder.More() {
+		return nil, ErrInvalidCallArgs
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	return args, nil
+}
+
 // LoadCallPayload from bytes
 func LoadCallPayload(bytes []byte) (*CallPayload, error) {
 	payload := &CallPayload{}
@@ -42,9 +105,118 @@ func LoadCallPayload(bytes []byte) (*CallPayload, error) {
 // NewCallPayload with function & args
 func NewCallPayload(function, args string) *CallPayload {
 	return &CallPayload{
-		Function: function,
-		Args:     args,
+		function: function,
+		args:     args,
+	}
+}
+
+var callFunctionRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// NewCallPayloadFromABI with function & args, the args are encoded as a json array.
+// Numbers are encoded as decimal strings to avoid the precision loss of javascript numbers.
+func NewCallPayloadFromABI(function string, args ...interface{}) (*CallPayload, error) {
+	if !callFunctionRegexp.MatchString(function) {
+		return nil, ErrInvalidCallFunction
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := abiValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return NewCallPayload(function, string(bytes)), nil
+}
+
+// abiValue convert the arg to a json value, with numbers as decimal strings.
+func abiValue(arg interface{}) (interface{}, error) {
+	switch v := arg.(type) {
+	case nil, bool, string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case *big.Int:
+		if v == nil {
+			return nil, nil
+		}
+		return v.String(), nil
+	case *util.Uint128:
+		if v == nil {
+			return nil, nil
+		}
+		return v.String(), nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrInvalidCallArgument
+		}
+		return strconv.FormatFloat(f, 'f', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		values := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			value, err := abiValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, ErrInvalidCallArgument
+		}
+		if rv.IsNil() {
+			return nil, nil
+		}
+		values := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			value, err := abiValue(rv.MapIndex(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[key.String()] = value
+		}
+		return values, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return abiValue(rv.Elem().Interface())
+	case reflect.Struct:
+		// follow the json tags of the struct, then convert the numbers in it.
+		bytes, err := json.Marshal(arg)
+		if err != nil {
+			return nil, ErrInvalidCallArgument
+		}
+		decoder := json.NewDecoder(strings.NewReader(string(bytes)))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, ErrInvalidCallArgument
+		}
+		return abiValue(value)
 	}
+	return nil, ErrInvalidCallArgument
 }
 
 // ToBytes serialize payload
@@ -58,10 +230,18 @@ func (payload *CallPayload) BaseGasCount() *util.Uint128 {
 }
 
 // Execute the call payload in tx, call a function
-func (payload *CallPayload) Execute(block *Block, tx *Transaction) (*util.Uint128, string, error) {
+func (payload *CallPayload) Execute(ctx context.Context, block *Block, tx *Transaction) (*util.Uint128, string, error) {
+	return payload.call(ctx, block, tx, false)
+}
+
+// call the function of contract, any state mutation fails in read-only call.
+func (payload *CallPayload) call(ctx context.Context, block *Block, tx *Transaction, readOnly bool) (*util.Uint128, string, error) {
 	if block == nil || tx == nil {
 		return util.NewUint128(), "", ErrNilArgument
 	}
+	if payload.function == ContractReceiveFunction {
+		return util.NewUint128(), "", ErrReceiveHookNotCallable
+	}
 
 	//add gas limit and memory use limit
 	payloadGasLimit, err := tx.PayloadGasLimit(payload)
@@ -74,19 +254,19 @@ func (payload *CallPayload) Execute(block *Block, tx *Transaction) (*util.Uint12
 	}
 
 	contract, err := block.CheckContract(tx.to)
-	if err != nil {
-		return util.NewUint128(), "", err
+	if err == state.ErrAccountNotFound || err == state.ErrContractNotFound {
+		return util.NewUint128(), "", ErrContractNotFound
 	}
-
-	birthTx, err := block.GetTransaction(contract.BirthPlace())
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	owner, err := block.accState.GetOrCreateUserAccount(birthTx.from.Bytes())
-	if err != nil {
-		return util.NewUint128(), "", err
+
+	// the call is clamped to the gas cap of the contract, if lower than the payload gas limit.
+	if gasCap := ContractGasCapOf(tx.chainID, tx.to); gasCap != nil && gasCap.Cmp(payloadGasLimit) < 0 {
+		payloadGasLimit = gasCap
 	}
-	deploy, err := LoadDeployPayload(birthTx.data.Payload) // ToConfirm: move deploy payload in ctx.
+
+	owner, deploy, err := loadContractDeploy(block, contract)
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
@@ -95,19 +275,52 @@ func (payload *CallPayload) Execute(block *Block, tx *Transaction) (*util.Uint12
 		return util.NewUint128(), "", err
 	}
 	defer block.nvm.DisposeEngine()
+	defer block.enterContract(tx.to, payload.function)()
 
 	if err := block.nvm.SetEngineExecutionLimits(payloadGasLimit.Uint64()); err != nil {
 		return util.NewUint128(), "", err
 	}
+	if err := block.nvm.SetEngineExecutionContext(ctx); err != nil {
+		return util.NewUint128(), "", err
+	}
+	if readOnly {
+		if err := block.nvm.SetEngineReadOnly(true); err != nil {
+			return util.NewUint128(), "", err
+		}
+	}
 
-	result, exeErr := block.nvm.CallEngine(deploy.Source, deploy.SourceType, payload.Function, payload.Args)
-	gasCout, err := block.nvm.ExecutionInstructions()
+	result, exeErr := block.nvm.CallEngine(deploy.Source, deploy.SourceType, payload.function, payload.args)
+	gasCout, err := engineGasCount(block)
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	instructions, err := util.NewUint128FromInt(int64(gasCout))
+	if exeErr != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"contract": tx.to,
+			"function": payload.function,
+			"err":      exeErr,
+		}).Debug("Failed to call contract.")
+	}
+	return gasCout, result, exeErr
+}
+
+// loadContractDeploy load the owner and deploy payload of the contract from its birth tx
+func loadContractDeploy(block *Block, contract state.Account) (state.Account, *DeployPayload, error) {
+	birthTx, err := block.GetTransaction(contract.BirthPlace())
 	if err != nil {
-		return util.NewUint128(), "", err
+		return nil, nil, err
+	}
+	owner, err := block.accState.GetOrCreateUserAccount(birthTx.from.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	deploy, err := LoadDeployPayload(birthTx.data.Payload) // ToConfirm: move deploy payload in ctx.
+	if err == ErrEmptyContractSource {
+		return nil, nil, ErrContractNotFound
+	}
+	if err != nil {
+		return nil, nil, err
 	}
-	return instructions, result, exeErr
+	return owner, deploy, nil
 }
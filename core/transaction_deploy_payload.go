@@ -19,9 +19,28 @@
 package core
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 
+	"github.com/nebulasio/go-nebulas/core/state"
 	"github.com/nebulasio/go-nebulas/util"
+	"github.com/nebulasio/go-nebulas/util/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// Compression algorithms of contract source in deploy payload.
+const (
+	CompressionGzip = "gzip"
+)
+
+var (
+	// MaxContractSourceLength max length of the contract source decompressed from deploy payload
+	MaxContractSourceLength = 4 * 1024 * 1024
 )
 
 // DeployPayload carry contract deploy information
@@ -29,17 +48,89 @@ type DeployPayload struct {
 	SourceType string
 	Source     string
 	Args       string
+	// Upgrade replace the code of the existing contract at tx.to, only the contract owner can upgrade.
+	Upgrade bool `json:",omitempty"`
+	// Compression the algorithm Source is compressed by in payload bytes, always empty once loaded.
+	Compression string `json:",omitempty"`
 }
 
-// LoadDeployPayload from bytes
+// LoadDeployPayload from bytes, deploy without source is rejected.
+// The compressed source is decompressed, capped at MaxContractSourceLength.
 func LoadDeployPayload(bytes []byte) (*DeployPayload, error) {
 	payload := &DeployPayload{}
 	if err := json.Unmarshal(bytes, payload); err != nil {
 		return nil, err
 	}
+	if len(payload.Compression) > 0 {
+		source, err := decompressSource(payload.Compression, payload.Source)
+		if err != nil {
+			return nil, err
+		}
+		payload.Source, payload.Compression = source, ""
+	}
+	if len(payload.Source) == 0 {
+		return nil, ErrEmptyContractSource
+	}
 	return payload, nil
 }
 
+// decompressSource decompress the base64 encoded source, the reader stops right after
+// MaxContractSourceLength bytes, a decompression bomb never expands in memory.
+func decompressSource(compression, encoded string) (string, error) {
+	if compression != CompressionGzip {
+		return "", ErrUnsupportedCompression
+	}
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	source, err := ioutil.ReadAll(io.LimitReader(reader, int64(MaxContractSourceLength)+1))
+	if err != nil {
+		return "", err
+	}
+	if len(source) > MaxContractSourceLength {
+		return "", ErrContractSourceTooLarge
+	}
+	return string(source), nil
+}
+
+// CompressedDeployPayload carry contract deploy information with the source gzip compressed,
+// its bytes are loaded as DeployPayload by LoadDeployPayload.
+type CompressedDeployPayload struct {
+	SourceType  string
+	Source      []byte // compressed source, base64 encoded in json
+	Args        string
+	Compression string
+}
+
+// NewCompressedDeployPayload with source compressed & args
+func NewCompressedDeployPayload(source, sourceType, args string) (*CompressedDeployPayload, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(source)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &CompressedDeployPayload{
+		SourceType:  sourceType,
+		Source:      buf.Bytes(),
+		Args:        args,
+		Compression: CompressionGzip,
+	}, nil
+}
+
+// ToBytes serialize payload in canonical json
+func (payload *CompressedDeployPayload) ToBytes() ([]byte, error) {
+	return canonicalJSON(payload)
+}
+
 // NewDeployPayload with source & args
 func NewDeployPayload(source, sourceType, args string) *DeployPayload { // ToCheck: add version in sourceType.
 	return &DeployPayload{
@@ -49,9 +140,25 @@ func NewDeployPayload(source, sourceType, args string) *DeployPayload { // ToChe
 	}
 }
 
-// ToBytes serialize payload
+// ToBytes serialize payload in canonical json, the tx hash never depends on the encoder.
 func (payload *DeployPayload) ToBytes() ([]byte, error) {
-	return json.Marshal(payload)
+	return canonicalJSON(payload)
+}
+
+// canonicalJSON encode v in json with the object keys sorted at every level and the numbers
+// kept as written, so the bytes depend on neither the struct field order nor the map iteration.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
 }
 
 // BaseGasCount returns base gas count
@@ -60,13 +167,13 @@ func (payload *DeployPayload) BaseGasCount() *util.Uint128 {
 }
 
 // Execute deploy payload in tx, deploy a new contract
-func (payload *DeployPayload) Execute(block *Block, tx *Transaction) (*util.Uint128, string, error) {
+func (payload *DeployPayload) Execute(ctx context.Context, block *Block, tx *Transaction) (*util.Uint128, string, error) {
 
 	if block == nil || tx == nil {
 		return util.NewUint128(), "", ErrNilArgument
 	}
 
-	if !tx.From().Equals(tx.To()) {
+	if !payload.Upgrade && !tx.From().Equals(tx.To()) {
 		return util.NewUint128(), "", ErrContractTransactionAddressNotEqual
 	}
 
@@ -79,37 +186,136 @@ func (payload *DeployPayload) Execute(block *Block, tx *Transaction) (*util.Uint
 		return util.NewUint128(), "", ErrOutOfGasLimit
 	}
 
+	if payload.Upgrade {
+		return payload.upgrade(ctx, block, tx, payloadGasLimit)
+	}
+
 	addr, err := tx.GenerateContractAddress()
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	owner, err := block.accState.GetOrCreateUserAccount(tx.from.Bytes())
+	// never overwrite the existing contract at the generated address.
+	if _, err := block.accState.GetContractAccount(addr.Bytes()); err == nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"contract": addr,
+		}).Debug("Found contract address collision.")
+		return util.NewUint128(), "", ErrContractAddressCollision
+	} else if err != state.ErrAccountNotFound && err != state.ErrContractNotFound {
+		return util.NewUint128(), "", err
+	}
+	// the contract account is created in a cloned block, it is kept only if init succeeds.
+	deployBlock, err := block.Clone()
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	owner, err := deployBlock.accState.GetOrCreateUserAccount(tx.from.Bytes())
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	contract, err := block.accState.CreateContractAccount(addr.Bytes(), tx.Hash())
+	contract, err := deployBlock.accState.CreateContractAccount(addr.Bytes(), tx.Hash())
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
 
-	if err := block.nvm.CreateEngine(block, tx, owner, contract, block.accState); err != nil {
+	if err := deployBlock.nvm.CreateEngine(deployBlock, tx, owner, contract, deployBlock.accState); err != nil {
 		return util.NewUint128(), "", err
 	}
-	defer block.nvm.DisposeEngine()
+	defer deployBlock.nvm.DisposeEngine()
+	defer deployBlock.enterContract(addr, "init")()
 
-	if err := block.nvm.SetEngineExecutionLimits(payloadGasLimit.Uint64()); err != nil {
+	if err := deployBlock.nvm.SetEngineExecutionLimits(payloadGasLimit.Uint64()); err != nil {
+		return util.NewUint128(), "", err
+	}
+	if err := deployBlock.nvm.SetEngineExecutionContext(ctx); err != nil {
 		return util.NewUint128(), "", err
 	}
 
 	// Deploy and Init.
-	result, exeErr := block.nvm.DeployAndInitEngine(payload.Source, payload.SourceType, payload.Args)
-	gasCout, err := block.nvm.ExecutionInstructions()
+	result, exeErr := deployBlock.nvm.DeployAndInitEngine(payload.Source, payload.SourceType, payload.Args)
+	gasCout, err := engineGasCount(deployBlock)
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	if exeErr != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"contract": addr,
+			"err":      exeErr,
+		}).Debug("Failed to deploy contract.")
+		// the half-deployed contract is dropped, gas used by init is still charged.
+		return gasCout, result, exeErr
+	}
+	block.Merge(deployBlock)
+	return gasCout, result, nil
+}
+
+// upgrade replace the code of the contract at tx.to with payload source, the contract state is preserved.
+// The new source is loaded in a read-only engine within payloadGasLimit first, a source failing to load is rejected.
+func (payload *DeployPayload) upgrade(ctx context.Context, block *Block, tx *Transaction, payloadGasLimit *util.Uint128) (*util.Uint128, string, error) {
+	contract, err := block.CheckContract(tx.to)
+	if err == state.ErrAccountNotFound || err == state.ErrContractNotFound {
+		return util.NewUint128(), "", ErrContractNotFound
+	}
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+
+	birthTx, err := block.GetTransaction(contract.BirthPlace())
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	if !birthTx.from.Equals(tx.from) {
+		return util.NewUint128(), "", ErrUnauthorizedUpgrade
+	}
+
+	// the contract is upgraded in a cloned block, it is kept only if the new source loads.
+	upgradeBlock, err := block.Clone()
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	instructions, err := util.NewUint128FromInt(int64(gasCout))
+	owner, err := upgradeBlock.accState.GetOrCreateUserAccount(tx.from.Bytes())
 	if err != nil {
 		return util.NewUint128(), "", err
 	}
-	return instructions, result, exeErr
+	contract, err = upgradeBlock.accState.GetContractAccount(tx.to.Bytes())
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+
+	if err := upgradeBlock.nvm.CreateEngine(upgradeBlock, tx, owner, contract, upgradeBlock.accState); err != nil {
+		return util.NewUint128(), "", err
+	}
+	defer upgradeBlock.nvm.DisposeEngine()
+	defer upgradeBlock.enterContract(tx.to, "upgrade")()
+
+	if err := upgradeBlock.nvm.SetEngineExecutionLimits(payloadGasLimit.Uint64()); err != nil {
+		return util.NewUint128(), "", err
+	}
+	if err := upgradeBlock.nvm.SetEngineExecutionContext(ctx); err != nil {
+		return util.NewUint128(), "", err
+	}
+	if err := upgradeBlock.nvm.SetEngineReadOnly(true); err != nil {
+		return util.NewUint128(), "", err
+	}
+
+	result, exeErr := upgradeBlock.nvm.LoadEngine(payload.Source, payload.SourceType)
+	gasCout, err := engineGasCount(upgradeBlock)
+	if err != nil {
+		return util.NewUint128(), "", err
+	}
+	if exeErr != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"traceID":  TraceID(ctx),
+			"contract": tx.to,
+			"err":      exeErr,
+		}).Debug("Failed to load upgraded contract.")
+		return gasCout, result, exeErr
+	}
+
+	if _, err := upgradeBlock.accState.UpgradeContractAccount(tx.to.Bytes(), tx.Hash()); err != nil {
+		return util.NewUint128(), "", err
+	}
+	block.Merge(upgradeBlock)
+	return gasCout, result, nil
 }
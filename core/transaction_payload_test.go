@@ -19,6 +19,11 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/nebulasio/go-nebulas/crypto"
@@ -71,6 +76,45 @@ func TestLoadBinaryPayload(t *testing.T) {
 
 }
 
+func TestBinaryPayload_MemoHMAC(t *testing.T) {
+	key := []byte("recipient secret")
+	payload, err := NewMemoHMACPayload([]byte("deposit:10086"), key)
+	assert.Nil(t, err)
+	data, err := payload.ToBytes()
+	assert.Nil(t, err)
+
+	memo, _, err := ParseMemoHMACPayload(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("deposit:10086"), memo)
+
+	tampered := append([]byte{}, data...)
+	copy(tampered[len(memoHMACPrefix):], "deposit:10087")
+	tests := []struct {
+		name    string
+		payload []byte
+		key     []byte
+		valid   bool
+		wantErr error
+	}{
+		{"valid memo", data, key, true, nil},
+		{"tampered memo", tampered, key, false, nil},
+		{"wrong key", data, []byte("other secret"), false, nil},
+		{"plain binary payload", []byte("deposit:10086"), key, false, ErrInvalidMemoHMACPayload},
+		{"truncated", data[:len(memoHMACPrefix)+8], key, false, ErrInvalidMemoHMACPayload},
+		{"empty key", data, nil, false, ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := VerifyMemoHMAC(tt.payload, tt.key)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.valid, valid)
+		})
+	}
+
+	_, err = NewMemoHMACPayload([]byte("memo"), nil)
+	assert.Equal(t, ErrInvalidArgument, err)
+}
+
 func TestLoadCallPayload(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -146,6 +190,86 @@ func TestLoadCallPayload(t *testing.T) {
 
 }
 
+func TestCallPayload_DecodedArgs(t *testing.T) {
+	bytes, err := NewCallPayload("transfer", `["n1abc", 10, "1000000000000000000000", true, null, {"memo": "hi"}, [1, 2]]`).ToBytes()
+	assert.Nil(t, err)
+	// the serialized form is unchanged by the accessors.
+	assert.Equal(t, `{"Function":"transfer","Args":"[\"n1abc\", 10, \"1000000000000000000000\", true, null, {\"memo\": \"hi\"}, [1, 2]]"}`, string(bytes))
+	payload, err := LoadCallPayload(bytes)
+	assert.Nil(t, err)
+	assert.Equal(t, "transfer", payload.Function())
+
+	args, err := payload.DecodedArgs()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		"n1abc",
+		json.Number("10"),
+		"1000000000000000000000",
+		true,
+		nil,
+		map[string]interface{}{"memo": "hi"},
+		[]interface{}{json.Number("1"), json.Number("2")},
+	}, args)
+
+	tests := []struct {
+		name   string
+		args   string
+		want   []interface{}
+		wanted error
+	}{
+		{"no args", "", []interface{}{}, nil},
+		{"empty array", "[]", []interface{}{}, nil},
+		{"null", "null", []interface{}{}, nil},
+		{"not an array", `{"to": "n1abc"}`, nil, ErrInvalidCallArgs},
+		{"malformed", `["n1abc",`, nil, ErrInvalidCallArgs},
+		{"trailing value", `[1] [2]`, nil, ErrInvalidCallArgs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := NewCallPayload("f", tt.args).DecodedArgs()
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestNewCallPayloadFromABI(t *testing.T) {
+	amount, _ := util.NewUint128FromString("1000000000000000000000")
+	type transfer struct {
+		To    string `json:"to"`
+		Value uint64 `json:"value"`
+	}
+	tests := []struct {
+		name     string
+		function string
+		args     []interface{}
+		want     string
+		wanted   error
+	}{
+		{"no args", "totalSupply", nil, `[]`, nil},
+		{"string", "balanceOf", []interface{}{"n1abc"}, `["n1abc"]`, nil},
+		{"numbers", "transfer", []interface{}{int64(-1), uint64(18446744073709551615), 1.5, amount}, `["-1","18446744073709551615","1.5","1000000000000000000000"]`, nil},
+		{"nested object", "batch", []interface{}{map[string]interface{}{"to": []interface{}{"n1abc", 2}, "ok": true}}, `[{"ok":true,"to":["n1abc","2"]}]`, nil},
+		{"struct", "send", []interface{}{&transfer{To: "n1abc", Value: 9007199254740993}}, `[{"to":"n1abc","value":"9007199254740993"}]`, nil},
+		{"null", "reset", []interface{}{nil}, `[null]`, nil},
+		{"invalid function", "1transfer", nil, "", ErrInvalidCallFunction},
+		{"empty function", "", nil, "", ErrInvalidCallFunction},
+		{"function with space", "transfer from", nil, "", ErrInvalidCallFunction},
+		{"invalid arg", "transfer", []interface{}{make(chan int)}, "", ErrInvalidCallArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := NewCallPayloadFromABI(tt.function, tt.args...)
+			assert.Equal(t, tt.wanted, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.function, payload.Function())
+			assert.Equal(t, tt.want, payload.Args())
+		})
+	}
+}
+
 func TestLoadDeployPayload(t *testing.T) {
 
 	deployTx := mockDeployTransaction(0, 0)
@@ -175,6 +299,14 @@ func TestLoadDeployPayload(t *testing.T) {
 			wantEqual: false,
 		},
 
+		{
+			name:      "empty source",
+			bytes:     []byte(`{"SourceType":"js","Source":"","Args":""}`),
+			parse:     false,
+			want:      nil,
+			wantEqual: false,
+		},
+
 		{
 			name:      "deploy",
 			bytes:     deployData,
@@ -201,6 +333,78 @@ func TestLoadDeployPayload(t *testing.T) {
 	}
 }
 
+func TestDeployPayload_ToBytesCanonical(t *testing.T) {
+	payload := NewDeployPayload("module.exports = {};", "js", `["a", 1]`)
+	data, err := payload.ToBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Args":"[\"a\", 1]","Source":"module.exports = {};","SourceType":"js"}`, string(data))
+	for i := 0; i < 10; i++ {
+		again, err := payload.ToBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, data, again)
+	}
+
+	// map-like args are kept as written and stable.
+	payload = NewDeployPayload("module.exports = {};", "js", `{"b": 1, "a": {"d": 2, "c": 3}}`)
+	payload.Upgrade = true
+	data, err = payload.ToBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Args":"{\"b\": 1, \"a\": {\"d\": 2, \"c\": 3}}","Source":"module.exports = {};","SourceType":"js","Upgrade":true}`, string(data))
+	for i := 0; i < 10; i++ {
+		again, err := payload.ToBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, data, again)
+	}
+	loaded, err := LoadDeployPayload(data)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, loaded)
+
+	// the map keys are sorted at every level, and the numbers kept as written.
+	data, err = canonicalJSON(map[string]interface{}{"z": 1, "y": map[string]interface{}{"b": 1.50, "a": uint64(1) << 63}})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"y":{"a":9223372036854775808,"b":1.5},"z":1}`, string(data))
+}
+
+func TestDeployPayload_Compressed(t *testing.T) {
+	source := "module.exports = {init: function() {}};"
+	compressed, err := NewCompressedDeployPayload(source, "js", "[]")
+	assert.Nil(t, err)
+	data, err := compressed.ToBytes()
+	assert.Nil(t, err)
+
+	loaded, err := LoadDeployPayload(data)
+	assert.Nil(t, err)
+	assert.Equal(t, NewDeployPayload(source, "js", "[]"), loaded)
+	assert.Nil(t, ValidatePayloadType(TxPayloadDeployType, data))
+
+	// unknown algorithm is rejected.
+	raw := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &raw))
+	raw["Compression"] = "lz4"
+	unknown, _ := json.Marshal(raw)
+	_, err = LoadDeployPayload(unknown)
+	assert.Equal(t, ErrUnsupportedCompression, err)
+
+	// source larger than the cap after decompression is rejected, though the payload is tiny.
+	bomb, err := NewCompressedDeployPayload(strings.Repeat(" ", 64*1024), "js", "")
+	assert.Nil(t, err)
+	data, err = bomb.ToBytes()
+	assert.Nil(t, err)
+	assert.True(t, len(data) < 1024)
+
+	max := MaxContractSourceLength
+	MaxContractSourceLength = 64*1024 - 1
+	defer func() { MaxContractSourceLength = max }()
+	_, err = LoadDeployPayload(data)
+	assert.Equal(t, ErrContractSourceTooLarge, err)
+	assert.Equal(t, ErrContractSourceTooLarge, ValidatePayloadType(TxPayloadDeployType, data))
+
+	MaxContractSourceLength = 64 * 1024
+	loaded, err = LoadDeployPayload(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 64*1024, len(loaded.Source))
+}
+
 func TestPayload_Execute(t *testing.T) {
 
 	type testPayload struct {
@@ -274,7 +478,7 @@ func TestPayload_Execute(t *testing.T) {
 
 			txblock, _ := block.Clone()
 
-			got, _, err := tt.payload.Execute(txblock, tt.tx)
+			got, _, err := tt.payload.Execute(context.Background(), txblock, tt.tx)
 			assert.Equal(t, tt.wantErr, err)
 			assert.Equal(t, tt.want, got)
 
@@ -288,3 +492,471 @@ func TestPayload_Execute(t *testing.T) {
 
 	block.rollback()
 }
+
+func TestPayload_ExecuteStorageGas(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+
+	gas := make(map[uint64]*util.Uint128)
+	for _, bytesWritten := range []uint64{0, 100} {
+		deployTx := mockDeployTransaction(bc.chainID, 0)
+		signTransaction(t, deployTx)
+		deployPayload, err := deployTx.LoadPayload()
+		assert.Nil(t, err)
+
+		block.nvm = &mockNvm{storageBytesWritten: bytesWritten}
+		txBlock, err := block.Clone()
+		assert.Nil(t, err)
+		txBlock.begin()
+		gas[bytesWritten], _, err = deployPayload.Execute(context.Background(), txBlock, deployTx)
+		assert.Nil(t, err)
+		txBlock.rollback()
+	}
+
+	storageGas, _ := util.NewUint128FromUint(100).Mul(GasCountPerByte)
+	wanted, _ := gas[0].Add(storageGas)
+	assert.Equal(t, wanted.String(), gas[100].String())
+}
+
+func TestCallPayload_ContractNotFound(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	user := mockAddress()
+	_, err = block.accState.GetOrCreateUserAccount(user.address)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		to      *Address
+		wantGas *util.Uint128
+		wantErr error
+	}{
+		{"new account", mockAddress(), util.NewUint128(), ErrContractNotFound},
+		{"user account", user, util.NewUint128(), ErrContractNotFound},
+		{"contract", contract, util.NewUint128FromUint(100), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callTx := mockCallTransaction(bc.chainID, 2, "totalSupply", "")
+			callTx.to = tt.to
+			signTransaction(t, callTx)
+			callPayload, err := callTx.LoadPayload()
+			assert.Nil(t, err)
+
+			gas, _, err := callPayload.Execute(context.Background(), block, callTx)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantGas.String(), gas.String())
+		})
+	}
+}
+
+func TestCallPayload_ContractGasCap(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	_, err = ParseContractGasCaps([]string{contract.String()})
+	assert.Equal(t, ErrInvalidArgument, err)
+	_, err = ParseContractGasCaps([]string{contract.String() + ":-1"})
+	assert.NotNil(t, err)
+
+	nvm := &mockNvm{instructions: 5000}
+	block.nvm = nvm
+	tests := []struct {
+		name      string
+		gasCap    uint64
+		callLimit uint64
+		wantLimit uint64
+		wantErr   error
+	}{
+		{"cap lower than tx limit", 1000, 10000, 1000, ErrInsufficientGas},
+		{"cap higher than tx limit", 1000000, 3000, 3000, ErrInsufficientGas},
+		{"cap higher than call", 1000000, 10000, 10000, nil},
+		{"no cap", 0, 10000, 10000, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.gasCap > 0 {
+				gasCaps, err := ParseContractGasCaps([]string{fmt.Sprintf("%s:%d", contract, tt.gasCap)})
+				assert.Nil(t, err)
+				SetContractGasCaps(bc.chainID, gasCaps)
+				defer SetContractGasCaps(bc.chainID, nil)
+				assert.Nil(t, ContractGasCapOf(bc.chainID+1, contract))
+			}
+
+			callTx := mockCallTransaction(bc.chainID, 2, "totalSupply", "")
+			callTx.to = contract
+			callPayload, err := callTx.LoadPayload()
+			assert.Nil(t, err)
+			baseGas, err := callTx.GasCountOfTxBase()
+			assert.Nil(t, err)
+			callTx.gasLimit, err = baseGas.Add(util.NewUint128FromUint(tt.callLimit))
+			assert.Nil(t, err)
+			signTransaction(t, callTx)
+
+			_, _, err = callPayload.Execute(context.Background(), block, callTx)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantLimit, nvm.limit)
+		})
+	}
+}
+
+func TestDeployPayload_Upgrade(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	addr, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	contract, err := block.accState.GetContractAccount(addr.address)
+	assert.Nil(t, err)
+	assert.Nil(t, contract.Put([]byte("key"), []byte("value")))
+
+	upgradeTx := func(from *Address, nonce uint64) *Transaction {
+		deploy := NewDeployPayload("module.exports = function(){};", "js", "")
+		deploy.Upgrade = true
+		payload, err := deploy.ToBytes()
+		assert.Nil(t, err)
+		tx, err := NewTransaction(bc.chainID, from, addr, util.NewUint128(), nonce, TxPayloadDeployType, payload, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		signTransaction(t, tx)
+		return tx
+	}
+
+	// unauthorized upgrade is rejected.
+	other := upgradeTx(mockAddress(), 1)
+	otherPayload, err := other.LoadPayload()
+	assert.Nil(t, err)
+	_, _, err = otherPayload.Execute(context.Background(), block, other)
+	assert.Equal(t, ErrUnauthorizedUpgrade, err)
+	contract, err = block.accState.GetContractAccount(addr.address)
+	assert.Nil(t, err)
+	assert.Equal(t, deployTx.hash, contract.BirthPlace())
+
+	// the new source failing to load is rejected, the gas of loading it is still charged.
+	nvm := block.nvm.(*mockNvm)
+	readOnly := false
+	nvm.readOnly = &readOnly
+	nvm.instructions = 300
+	defer func() { nvm.readOnly, nvm.instructions, nvm.callErr = nil, 0, nil }()
+	nvm.callErr = errors.New("source throws")
+	failed := upgradeTx(deployTx.from, 2)
+	failedPayload, err := failed.LoadPayload()
+	assert.Nil(t, err)
+	gasExecution, _, err := failedPayload.Execute(context.Background(), block, failed)
+	assert.Equal(t, nvm.callErr, err)
+	assert.Equal(t, uint64(300), gasExecution.Uint64())
+	assert.True(t, readOnly)
+	contract, err = block.CheckContract(addr)
+	assert.Nil(t, err)
+	assert.Equal(t, deployTx.hash, contract.BirthPlace())
+
+	// the source is loaded within the gas limit of tx.
+	nvm.callErr = nil
+	limited := upgradeTx(deployTx.from, 2)
+	limited.gasLimit, err = limited.GasCountOfTxBase()
+	assert.Nil(t, err)
+	limited.gasLimit, err = limited.gasLimit.Add(util.NewUint128FromUint(100))
+	assert.Nil(t, err)
+	limitedPayload, err := limited.LoadPayload()
+	assert.Nil(t, err)
+	_, _, err = limitedPayload.Execute(context.Background(), block, limited)
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	// authorized upgrade replaces the code and keeps the state.
+	upgrade := upgradeTx(deployTx.from, 2)
+	gasUsed, err := upgrade.VerifyExecution(block)
+	assert.Nil(t, err)
+	baseGas, err := upgrade.GasCountOfTxBase()
+	assert.Nil(t, err)
+	assert.True(t, gasUsed.Cmp(baseGas) > 0)
+	assert.Nil(t, block.acceptTransaction(upgrade))
+	contract, err = block.CheckContract(addr)
+	assert.Nil(t, err)
+	assert.Equal(t, upgrade.hash, contract.BirthPlace())
+	value, err := contract.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestDeployPayload_InitReverted(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	initErr := errors.New("init throws")
+	block.nvm = &mockNvm{initErr: initErr}
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	addr, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	// the reverted init leaves no contract account behind.
+	payload, err := deployTx.LoadPayload()
+	assert.Nil(t, err)
+	gasExecution, _, err := payload.Execute(context.Background(), block, deployTx)
+	assert.Equal(t, initErr, err)
+	assert.True(t, gasExecution.Cmp(util.NewUint128()) > 0)
+	_, err = block.accState.GetContractAccount(addr.address)
+	assert.NotNil(t, err)
+
+	// the gas used by init is charged.
+	gasUsed, err := deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	baseGas, err := deployTx.GasCountOfTxBase()
+	assert.Nil(t, err)
+	expected, err := baseGas.Add(gasExecution)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, gasUsed)
+	txEvent, err := block.fetchExecutionResult(deployTx.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, int8(TxExecutionFailed), txEvent.Status)
+	assert.Equal(t, initErr.Error(), txEvent.Error)
+	_, err = block.accState.GetContractAccount(addr.address)
+	assert.NotNil(t, err)
+}
+
+func TestDeployPayload_AddressCollision(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	addr, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	contract, err := block.accState.GetContractAccount(addr.address)
+	assert.Nil(t, err)
+	assert.Nil(t, contract.Put([]byte("key"), []byte("value")))
+
+	// same from and nonce reused, generating the same contract address.
+	replay, err := NewTransaction(bc.chainID, deployTx.from, deployTx.to, util.NewUint128(), deployTx.nonce, TxPayloadDeployType, deployTx.data.Payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	replay.timestamp = deployTx.timestamp + 1
+	signTransaction(t, replay)
+	replayAddr, err := replay.GenerateContractAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, addr, replayAddr)
+
+	payload, err := replay.LoadPayload()
+	assert.Nil(t, err)
+	_, _, err = payload.Execute(context.Background(), block, replay)
+	assert.Equal(t, ErrContractAddressCollision, err)
+
+	// the deploy fails in execution, the existing contract is not overwritten.
+	_, err = replay.VerifyExecution(block)
+	assert.Nil(t, err)
+	txEvent, err := block.fetchExecutionResult(replay.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, int8(TxExecutionFailed), txEvent.Status)
+	assert.Equal(t, ErrContractAddressCollision.Error(), txEvent.Error)
+	contract, err = block.accState.GetContractAccount(addr.address)
+	assert.Nil(t, err)
+	assert.Equal(t, deployTx.hash, contract.BirthPlace())
+	value, err := contract.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestBinaryPayload_ReceiveHook(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	calls := 0
+	nvm := &mockNvm{calls: &calls}
+	block.nvm = nvm
+
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	deploy := func(deployTx *Transaction) *Address {
+		signTransaction(t, deployTx)
+		fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, fromAcc.AddBalance(balance))
+		_, err = deployTx.VerifyExecution(block)
+		assert.Nil(t, err)
+		assert.Nil(t, block.acceptTransaction(deployTx))
+		contract, err := deployTx.GenerateContractAddress()
+		assert.Nil(t, err)
+		return contract
+	}
+	withoutHook := deploy(mockDeployTransaction(bc.chainID, 1))
+	hookSource, _ := NewDeployPayload(`var C = function(){}; C.prototype = {init: function(){}, __nebulas_receive: function(){}}; module.exports = C;`, "js", "").ToBytes()
+	withHook := deploy(mockTransaction(bc.chainID, 1, TxPayloadDeployType, hookSource))
+
+	from := mockAddress()
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+
+	value := util.NewUint128FromUint(100)
+	hookCall, _ := NewCallPayload(ContractReceiveFunction, "").ToBytes()
+	tests := []struct {
+		name        string
+		to          *Address
+		payloadType string
+		payload     []byte
+		callErr     error
+		wanted      *util.Uint128
+		calls       int
+		status      int8
+		err         string
+	}{
+		{"plain transfer to user", mockAddress(), TxPayloadBinaryType, nil, nil, value, 0, TxExecutionSuccess, ""},
+		{"contract without hook", withoutHook, TxPayloadBinaryType, nil, errors.New("reject"), value, 0, TxExecutionSuccess, ""},
+		{"contract accepts", withHook, TxPayloadBinaryType, nil, nil, value, 1, TxExecutionSuccess, ""},
+		{"contract reverts", withHook, TxPayloadBinaryType, nil, errors.New("reject"), util.NewUint128(), 1, TxExecutionFailed, ErrContractRejectedValue.Error()},
+		{"hook called by call tx", withHook, TxPayloadCallType, hookCall, nil, util.NewUint128(), 0, TxExecutionFailed, ErrReceiveHookNotCallable.Error()},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toAcc, err := block.accState.GetOrCreateUserAccount(tt.to.address)
+			assert.Nil(t, err)
+			before := toAcc.Balance()
+
+			tx, err := NewTransaction(bc.chainID, from, tt.to, value, uint64(i+1), tt.payloadType, tt.payload, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+			nvm.callErr, calls = tt.callErr, 0
+			_, err = tx.VerifyExecution(block)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.calls, calls)
+
+			toAcc, err = block.accState.GetOrCreateUserAccount(tt.to.address)
+			assert.Nil(t, err)
+			received, err := toAcc.Balance().Sub(before)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wanted.String(), received.String())
+
+			events, err := block.FetchEvents(tx.hash)
+			assert.Nil(t, err)
+			txEvent := TransactionEvent{}
+			assert.Nil(t, json.Unmarshal([]byte(events[len(events)-1].Data), &txEvent))
+			assert.Equal(t, tt.status, txEvent.Status)
+			assert.Equal(t, tt.err, txEvent.Error)
+		})
+	}
+}
+
+func TestBatchTransferPayload(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	from := mockAddress()
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(util.NewUint128FromUint(1000)))
+
+	balanceOf := func(addr *Address) string {
+		acc, err := block.accState.GetOrCreateUserAccount(addr.address)
+		assert.Nil(t, err)
+		return acc.Balance().String()
+	}
+	execute := func(payload *BatchTransferPayload) error {
+		data, err := payload.ToBytes()
+		assert.Nil(t, err)
+		tx, err := NewTransaction(bc.chainID, from, from, util.NewUint128(), 1, TxPayloadBatchTransferType, data, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		loaded, err := tx.LoadPayload()
+		assert.Nil(t, err)
+		assert.Equal(t, "10000", loaded.BaseGasCount().String())
+		_, _, err = loaded.Execute(context.Background(), block, tx)
+		return err
+	}
+
+	// all the outputs are transferred.
+	a, b := mockAddress(), mockAddress()
+	payload := NewBatchTransferPayload().
+		AddOutput(a, util.NewUint128FromUint(100)).
+		AddOutput(b, util.NewUint128FromUint(200))
+	assert.Nil(t, execute(payload))
+	assert.Equal(t, "700", balanceOf(from))
+	assert.Equal(t, "100", balanceOf(a))
+	assert.Equal(t, "200", balanceOf(b))
+
+	// insufficient balance of the second output rolls back the first one.
+	c, d := mockAddress(), mockAddress()
+	payload = NewBatchTransferPayload().
+		AddOutput(c, util.NewUint128FromUint(500)).
+		AddOutput(d, util.NewUint128FromUint(500))
+	assert.NotNil(t, execute(payload))
+	assert.Equal(t, "700", balanceOf(from))
+	assert.Equal(t, "0", balanceOf(c))
+	assert.Equal(t, "0", balanceOf(d))
+
+	// the balance required covers the outputs, a sender funding only the gas is rejected before execution.
+	data, err := payload.ToBytes()
+	assert.Nil(t, err)
+	tx, err := NewTransaction(bc.chainID, from, from, util.NewUint128FromUint(1), 1, TxPayloadBatchTransferType, data, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	fee, err := TransactionGasPrice.Mul(TransactionMaxGas)
+	assert.Nil(t, err)
+	expect, err := fee.Add(util.NewUint128FromUint(1001))
+	assert.Nil(t, err)
+	required, err := tx.MinBalanceRequired()
+	assert.Nil(t, err)
+	assert.Equal(t, expect.String(), required.String())
+	fromAcc, err = block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(fee))
+	_, err = tx.VerifyExecution(block)
+	assert.Equal(t, ErrInsufficientBalance, err)
+	assert.Equal(t, "0", balanceOf(c))
+
+	_, err = LoadBatchTransferPayload([]byte(`{"Outputs":[]}`))
+	assert.Equal(t, ErrEmptyBatchTransfer, err)
+	assert.Nil(t, ValidatePayloadType(TxPayloadBatchTransferType, []byte(`{"Outputs":[{"To":"`+a.String()+`","Value":"1"}]}`)))
+}
@@ -236,7 +236,16 @@ func (pool *TransactionPool) Push(tx *Transaction) error { //ToRefine, change to
 	}
 
 	// verify hash & sign of tx
-	if err := tx.VerifyIntegrity(pool.bc.chainID); err != nil {
+	if err := tx.verifyIntegrity(pool.bc.chainID, pool.bc.signatureCache); err != nil {
+		metricsInvalidTx.Inc(1)
+		return err
+	}
+	if err := ValidatePayloadType(tx.data.Type, tx.data.Payload); err != nil {
+		metricsInvalidTx.Inc(1)
+		return err
+	}
+	// the tx is packed into the block next to the tail at the earliest.
+	if err := tx.verifyLowS(pool.bc.TailBlock().Height() + 1); err != nil {
 		metricsInvalidTx.Inc(1)
 		return err
 	}
@@ -19,8 +19,13 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,8 +37,12 @@ import (
 	"github.com/nebulasio/go-nebulas/crypto"
 	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
+	"github.com/nebulasio/go-nebulas/crypto/keystore/secp256k1"
+	"github.com/nebulasio/go-nebulas/neblet/pb"
 	"github.com/nebulasio/go-nebulas/util"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
+	"github.com/nebulasio/go-nebulas/util/logging"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -67,6 +76,16 @@ func mockTransaction(chainID uint32, nonce uint64, payloadType string, payload [
 	return tx
 }
 
+// signTransaction sign tx with the unlocked key of tx.from in the default keystore.
+func signTransaction(t *testing.T, tx *Transaction) {
+	key, err := keystore.DefaultKS.GetUnlocked(tx.from.String())
+	assert.Nil(t, err)
+	signature, err := crypto.NewSignature(keystore.SECP256K1)
+	assert.Nil(t, err)
+	assert.Nil(t, signature.InitSign(key.(keystore.PrivateKey)))
+	assert.Nil(t, tx.Sign(signature))
+}
+
 func TestTransaction(t *testing.T) {
 	type fields struct {
 		hash      byteutils.Hash
@@ -173,6 +192,502 @@ func TestTransaction_VerifyIntegrity(t *testing.T) {
 	}
 }
 
+func TestTransaction_VerifyIntegrityAlgorithm(t *testing.T) {
+	from := mockAddress()
+
+	assert.Equal(t, []keystore.Algorithm{keystore.SECP256K1}, SupportedSignatureAlgorithms(1))
+
+	tests := []struct {
+		name   string
+		alg    keystore.Algorithm
+		wanted error
+	}{
+		{"supported alg", keystore.SECP256K1, nil},
+		{"unsupported alg", keystore.Algorithm(2), ErrUnsupportedSignatureAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gasLimit, _ := util.NewUint128FromInt(200000)
+			tx, _ := NewTransaction(1, from, mockAddress(), util.NewUint128(), 10, TxPayloadBinaryType, []byte("datadata"), TransactionGasPrice, gasLimit)
+			signTransaction(t, tx)
+			tx.alg = tt.alg
+			assert.Equal(t, tt.wanted, tx.VerifyIntegrity(1))
+		})
+	}
+}
+
+func TestTransaction_VerifySignatureOnly(t *testing.T) {
+	from := mockAddress()
+
+	tx, _ := NewTransaction(1, from, mockAddress(), util.NewUint128(), 10, TxPayloadBinaryType, []byte("datadata"), TransactionGasPrice, nil)
+	signTransaction(t, tx)
+	assert.Nil(t, tx.VerifySignatureOnly())
+
+	// tampered value is not caught, the stored hash is trusted.
+	tx.value, _ = util.NewUint128FromInt(100)
+	assert.Nil(t, tx.VerifySignatureOnly())
+	assert.Equal(t, ErrInvalidTransactionHash, tx.VerifyIntegrity(1))
+
+	// tampered signer is caught.
+	tx.from = mockAddress()
+	assert.Equal(t, ErrInvalidTransactionSigner, tx.VerifySignatureOnly())
+}
+
+func TestTransaction_VerifyIntegritySignatureCache(t *testing.T) {
+	recovered := 0
+	origin := recoverSigner
+	recoverSigner = func(tx *Transaction) (*Address, error) {
+		recovered++
+		return origin(tx)
+	}
+	defer func() { recoverSigner = origin }()
+
+	bc := testNeb(t).chain
+	assert.Nil(t, bc.EnableSignatureCache(1))
+	signers := bc.signatureCache
+
+	from := mockAddress()
+	newTx := func(nonce uint64) *Transaction {
+		tx, _ := NewTransaction(bc.ChainID(), from, mockAddress(), util.NewUint128(), nonce, TxPayloadBinaryType, nil, TransactionGasPrice, nil)
+		signTransaction(t, tx)
+		return tx
+	}
+
+	// the second verification takes the signer from cache.
+	tx1 := newTx(1)
+	assert.Nil(t, tx1.verifyIntegrity(bc.ChainID(), signers))
+	assert.Nil(t, tx1.verifyIntegrity(bc.ChainID(), signers))
+	assert.Equal(t, 1, recovered)
+
+	// same hash with another signature is recovered again.
+	forged := newTx(1)
+	forged.hash = tx1.hash
+	forged.sign = append([]byte{}, tx1.sign...)
+	forged.sign[0] ^= 0xff
+	assert.NotNil(t, forged.verifyIntegrity(bc.ChainID(), signers))
+	assert.Equal(t, 2, recovered)
+
+	// tampered tx fails the hash check before the cache.
+	tampered := newTx(1)
+	tampered.hash, tampered.sign = tx1.hash, tx1.sign
+	tampered.value, _ = util.NewUint128FromInt(100)
+	assert.Equal(t, ErrInvalidTransactionHash, tampered.verifyIntegrity(bc.ChainID(), signers))
+	assert.Equal(t, 2, recovered)
+
+	// evicted entry is recovered again.
+	tx2 := newTx(2)
+	assert.Nil(t, tx2.verifyIntegrity(bc.ChainID(), signers))
+	assert.Nil(t, tx1.verifyIntegrity(bc.ChainID(), signers))
+	assert.Equal(t, 4, recovered)
+
+	// disabled cache always recovers.
+	assert.Nil(t, bc.EnableSignatureCache(0))
+	assert.Nil(t, bc.signatureCache)
+	assert.Nil(t, tx1.VerifyIntegrity(bc.ChainID()))
+	assert.Nil(t, tx1.VerifyIntegrity(bc.ChainID()))
+	assert.Equal(t, 6, recovered)
+}
+
+func TestTransaction_SigningPreimage(t *testing.T) {
+	from, _ := NewAddress(bytes.Repeat([]byte{0x01}, AddressDataLength))
+	to, _ := NewAddress(bytes.Repeat([]byte{0x02}, AddressDataLength))
+	value, _ := util.NewUint128FromInt(10)
+	gasLimit, _ := util.NewUint128FromInt(20000)
+	tx, err := NewTransaction(1, from, to, value, 3, TxPayloadBinaryType, []byte("data"), TransactionGasPrice, gasLimit)
+	assert.Nil(t, err)
+	tx.timestamp = 1514736000
+
+	preimage, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	assert.Equal(t, "0101010101010101010101010101010101010101724fcdb802020202020202020202020202020202020202027ad8757d0000000000000000000000000000000a0000000000000003000000005a4909800a0662696e61727912046461746100000001000000000000000000000000000f424000000000000000000000000000004e20", byteutils.Hex(preimage))
+
+	txHash, err := HashTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, hash.Sha3256(preimage), []byte(txHash))
+
+	// optional fields are tagged and length prefixed.
+	feeToken, _ := NewAddress(bytes.Repeat([]byte{0x03}, AddressDataLength))
+	tx.feeToken = feeToken
+	withFeeToken, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	assert.Equal(t, preimage, withFeeToken[:len(preimage)])
+	assert.Equal(t, "feeToken", string(withFeeToken[len(preimage):len(preimage)+8]))
+	assert.Equal(t, byteutils.FromUint32(uint32(len(feeToken.address))), withFeeToken[len(preimage)+8:len(preimage)+12])
+
+	// a condition on the fee token address with an empty function doesn't collide with the fee token.
+	tx.feeToken = nil
+	tx.condition = &Condition{Contract: feeToken, Function: ""}
+	withCondition, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	assert.NotEqual(t, withFeeToken, withCondition)
+
+	// the function never absorbs the following fields.
+	tx.condition.Function = "f"
+	tx.maxFeePerGas, tx.maxPriorityFeePerGas = TransactionGasPrice, TransactionGasPrice
+	withDynamicFee, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	maxFee, err := TransactionGasPrice.ToFixedSizeByteSlice()
+	assert.Nil(t, err)
+	tx.condition.Function = "f" + string(maxFee)
+	tx.maxFeePerGas, tx.maxPriorityFeePerGas = nil, nil
+	absorbed, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	assert.NotEqual(t, withDynamicFee, absorbed)
+}
+
+func TestTransaction_With(t *testing.T) {
+	from := mockAddress()
+
+	gasPrice, _ := util.NewUint128FromInt(2000000)
+	gasLimit, _ := util.NewUint128FromInt(300000)
+
+	tx, _ := NewTransaction(1, from, mockAddress(), util.NewUint128(), 10, TxPayloadBinaryType, []byte("datadata"), TransactionGasPrice, MinGasCountPerTransaction)
+	signTransaction(t, tx)
+
+	_, err := tx.WithNonce(11)
+	assert.Equal(t, ErrTransactionSigned, err)
+	_, err = tx.WithGasPrice(gasPrice)
+	assert.Equal(t, ErrTransactionSigned, err)
+	_, err = tx.WithGasLimit(gasLimit)
+	assert.Equal(t, ErrTransactionSigned, err)
+
+	tx.InvalidateSign()
+	assert.Nil(t, tx.Hash())
+
+	tests := []struct {
+		name   string
+		with   func() (*Transaction, error)
+		modify func(*Transaction)
+	}{
+		{"nonce", func() (*Transaction, error) { return tx.WithNonce(11) }, func(tx *Transaction) { tx.nonce = 11 }},
+		{"gasPrice", func() (*Transaction, error) { return tx.WithGasPrice(gasPrice) }, func(tx *Transaction) { tx.gasPrice = gasPrice }},
+		{"gasLimit", func() (*Transaction, error) { return tx.WithGasLimit(gasLimit) }, func(tx *Transaction) { tx.gasLimit = gasLimit }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ntx, err := tt.with()
+			assert.Nil(t, err)
+			assert.Nil(t, ntx.hash)
+			assert.Nil(t, ntx.sign)
+
+			wanted := *tx
+			tt.modify(&wanted)
+			assert.Equal(t, &wanted, ntx)
+			assert.Equal(t, uint64(10), tx.nonce)
+
+			signTransaction(t, ntx)
+			assert.Nil(t, ntx.VerifyIntegrity(1))
+		})
+	}
+}
+
+func TestTransaction_CanReplace(t *testing.T) {
+	from := mockAddress()
+	to := mockAddress()
+	bumped, _ := util.NewUint128FromInt(1100000)
+	lowBumped, _ := util.NewUint128FromInt(1099999)
+
+	old, _ := NewTransaction(1, from, to, util.NewUint128(), 10, TxPayloadBinaryType, nil, TransactionGasPrice, nil)
+	tests := []struct {
+		name     string
+		nonce    uint64
+		gasPrice *util.Uint128
+		from     *Address
+		wanted   bool
+	}{
+		{"same price", 10, TransactionGasPrice, from, false},
+		{"+10% price", 10, bumped, from, true},
+		{"less than +10% price", 10, lowBumped, from, false},
+		{"different nonce", 11, bumped, from, false},
+		{"different from", 10, bumped, mockAddress(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, _ := NewTransaction(1, tt.from, to, util.NewUint128(), tt.nonce, TxPayloadBinaryType, nil, tt.gasPrice, nil)
+			assert.Equal(t, tt.wanted, tx.CanReplace(old, 10))
+		})
+	}
+}
+
+func TestTransactions_SortByGasPrice(t *testing.T) {
+	mockTx := func(from *Address, nonce uint64, gasPrice uint64) *Transaction {
+		tx, _ := NewTransaction(1, from, mockAddress(), util.NewUint128(), nonce, TxPayloadBinaryType, nil, util.NewUint128FromUint(gasPrice), TransactionMaxGas)
+		return tx
+	}
+	a, b := mockAddress(), mockAddress()
+
+	txs := Transactions{mockTx(a, 1, 1), mockTx(b, 1, 3), mockTx(a, 2, 2), mockTx(b, 2, 3)}
+	txs.SortByGasPrice()
+	for i := 1; i < len(txs); i++ {
+		assert.True(t, txs[i-1].gasPrice.Cmp(txs[i].gasPrice) >= 0)
+	}
+	// equal gas price is ordered by nonce.
+	assert.Equal(t, uint64(1), txs[0].nonce)
+	assert.Equal(t, uint64(2), txs[1].nonce)
+
+	// equal gas price and nonce is ordered by hash, whatever the input order is.
+	var signed Transactions
+	for i := 0; i < 5; i++ {
+		tx := mockTx(mockAddress(), 1, 3)
+		signTransaction(t, tx)
+		signed = append(signed, tx)
+	}
+	signed = append(signed, mockTx(a, 0, 3), mockTx(a, 1, 4))
+	signed.SortByGasPrice()
+	assert.Equal(t, uint64(4), signed[0].gasPrice.Uint64())
+	assert.Equal(t, uint64(0), signed[1].nonce)
+	for i := 3; i < len(signed); i++ {
+		assert.True(t, bytes.Compare(signed[i-1].hash, signed[i].hash) < 0)
+	}
+	for i := 0; i < 3; i++ {
+		shuffled := append(Transactions{}, signed...)
+		for j := range shuffled {
+			k := (j*7 + i) % len(shuffled)
+			shuffled[j], shuffled[k] = shuffled[k], shuffled[j]
+		}
+		shuffled.SortByGasPrice()
+		assert.Equal(t, signed, shuffled)
+	}
+
+	// a's nonce 2 pays more than nonce 1, but must not be packed before it.
+	txs = Transactions{mockTx(a, 2, 5), mockTx(b, 1, 3), mockTx(a, 1, 1), mockTx(b, 2, 4), mockTx(a, 3, 2)}
+	txs.SortByGasPriceThenNonce()
+
+	type item struct {
+		from     *Address
+		nonce    uint64
+		gasPrice uint64
+	}
+	wanted := []item{{b, 1, 3}, {b, 2, 4}, {a, 1, 1}, {a, 2, 5}, {a, 3, 2}}
+	assert.Equal(t, len(wanted), len(txs))
+	for i, w := range wanted {
+		assert.True(t, w.from.Equals(txs[i].from))
+		assert.Equal(t, w.nonce, txs[i].nonce)
+		assert.Equal(t, util.NewUint128FromUint(w.gasPrice).String(), txs[i].gasPrice.String())
+	}
+}
+
+func TestTransactions_Totals(t *testing.T) {
+	var txs Transactions
+	for i := 1; i <= 3; i++ {
+		value, _ := util.NewUint128FromInt(int64(i * 100))
+		gasLimit, _ := util.NewUint128FromInt(int64(i * 20000))
+		tx, err := NewTransaction(1, mockAddress(), mockAddress(), value, uint64(i), TxPayloadBinaryType, nil, TransactionGasPrice, gasLimit)
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+	}
+	total, err := txs.TotalValue()
+	assert.Nil(t, err)
+	assert.Equal(t, "600", total.String())
+	gas, err := txs.TotalGasLimit()
+	assert.Nil(t, err)
+	assert.Equal(t, "120000", gas.String())
+
+	total, err = Transactions{}.TotalValue()
+	assert.Nil(t, err)
+	assert.True(t, total.Cmp(util.NewUint128()) == 0)
+
+	max, _ := util.NewUint128FromString("340282366920938463463374607431768211455")
+	tx, err := NewTransaction(1, mockAddress(), mockAddress(), max, 4, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	tx.gasLimit = max
+	txs = append(txs, tx)
+	_, err = txs.TotalValue()
+	assert.Equal(t, util.ErrUint128Overflow, err)
+	_, err = txs.TotalGasLimit()
+	assert.Equal(t, util.ErrUint128Overflow, err)
+}
+
+func TestTransactions_Dedup(t *testing.T) {
+	newTx := func(nonce uint64) *Transaction {
+		tx := mockNormalTransaction(1, nonce)
+		hash, err := HashTransaction(tx)
+		assert.Nil(t, err)
+		tx.hash = hash
+		return tx
+	}
+	a, b, c := newTx(1), newTx(2), newTx(3)
+
+	unique, removed := Transactions{a, b, c}.Dedup()
+	assert.Equal(t, Transactions{a, b, c}, unique)
+	assert.Equal(t, 0, removed)
+
+	// the same tx decoded twice shares the hash.
+	copied := *b
+	unique, removed = Transactions{b, a, &copied, b, c, a}.Dedup()
+	assert.Equal(t, Transactions{b, a, c}, unique)
+	assert.Equal(t, 3, removed)
+
+	unique, removed = Transactions{}.Dedup()
+	assert.Empty(t, unique)
+	assert.Equal(t, 0, removed)
+}
+
+func TestTransactions_NextNonceBySender(t *testing.T) {
+	a, b := mockAddress(), mockAddress()
+	newTx := func(from *Address, nonce uint64) *Transaction {
+		tx, err := NewTransaction(1, from, mockAddress(), util.NewUint128(), nonce, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		return tx
+	}
+
+	next, err := Transactions{newTx(a, 3), newTx(b, 1), newTx(a, 2), newTx(a, 4)}.NextNonceBySender()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]uint64{a.String(): 5, b.String(): 2}, next)
+
+	_, err = Transactions{newTx(a, 1), newTx(b, 1), newTx(a, 3)}.NextNonceBySender()
+	assert.Equal(t, ErrNonceNotContiguous, err)
+
+	_, err = Transactions{newTx(a, 1), newTx(a, 1)}.NextNonceBySender()
+	assert.Equal(t, ErrNonceNotContiguous, err)
+}
+
+func TestTransactions_PartitionIndependent(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	a, b, c, d, e := mockAddress(), mockAddress(), mockAddress(), mockAddress(), mockAddress()
+	value, _ := util.NewUint128FromInt(1)
+	newTx := func(from, to *Address, payloadType string, payload []byte) *Transaction {
+		tx, err := NewTransaction(bc.ChainID(), from, to, value, 1, payloadType, payload, TransactionGasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		return tx
+	}
+
+	// a->b and b->c share b, d->e is independent.
+	aToB := newTx(a, b, TxPayloadBinaryType, nil)
+	dToE := newTx(d, e, TxPayloadBinaryType, nil)
+	bToC := newTx(b, c, TxPayloadBinaryType, nil)
+	batches, err := Transactions{aToB, dToE, bToC}.PartitionIndependent(block)
+	assert.Nil(t, err)
+	assert.Equal(t, []Transactions{{aToB, bToC}, {dToE}}, batches)
+
+	// the outputs of batch transfer are touched too.
+	payload, err := NewBatchTransferPayload().AddOutput(e, value).ToBytes()
+	assert.Nil(t, err)
+	batch := newTx(mockAddress(), mockAddress(), TxPayloadBatchTransferType, payload)
+	batches, err = Transactions{aToB, dToE, batch}.PartitionIndependent(block)
+	assert.Nil(t, err)
+	assert.Equal(t, []Transactions{{aToB}, {dToE, batch}}, batches)
+
+	// contract calls and txs from the coinbase may touch any account.
+	call, err := NewCallPayload("f", "").ToBytes()
+	assert.Nil(t, err)
+	for _, global := range []*Transaction{
+		newTx(mockAddress(), mockAddress(), TxPayloadCallType, call),
+		newTx(block.Coinbase(), mockAddress(), TxPayloadBinaryType, nil),
+	} {
+		batches, err = Transactions{aToB, dToE, global}.PartitionIndependent(block)
+		assert.Nil(t, err)
+		assert.Equal(t, []Transactions{{aToB, dToE, global}}, batches)
+	}
+
+	batches, err = Transactions{}.PartitionIndependent(block)
+	assert.Nil(t, err)
+	assert.Nil(t, batches)
+	_, err = Transactions{aToB}.PartitionIndependent(nil)
+	assert.Equal(t, ErrNilArgument, err)
+}
+
+func TestTransactions_FilterValid(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	mockTx := func(funded bool) *Transaction {
+		from := mockAddress()
+		tx, _ := NewTransaction(bc.chainID, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		signTransaction(t, tx)
+		if funded {
+			acc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, acc.AddBalance(balance))
+		}
+		return tx
+	}
+
+	valid1, valid2 := mockTx(true), mockTx(true)
+	badSign := mockTx(true)
+	badSign.sign[0] ^= 0xff
+	poor := mockTx(false)
+	txs := Transactions{valid1, badSign, poor, valid2}
+
+	stateRoot, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	valid, dropped := txs.FilterValid(block, bc.chainID)
+	assert.Equal(t, Transactions{valid1, valid2}, valid)
+	assert.Equal(t, 2, len(dropped))
+	assert.NotNil(t, dropped[badSign.hash.String()])
+	assert.Equal(t, ErrInsufficientBalance, dropped[poor.hash.String()])
+	root, err := block.accState.RootHash()
+	assert.Nil(t, err)
+	assert.Equal(t, stateRoot, root)
+
+	// all dropped on another chain.
+	valid, dropped = txs.FilterValid(block, bc.chainID+1)
+	assert.Equal(t, 0, len(valid))
+	assert.Equal(t, ErrInvalidChainID, dropped[valid1.hash.String()])
+}
+
+func TestTransactions_BatchVerifyIntegrity(t *testing.T) {
+	mockTx := func(valid bool) *Transaction {
+		from := mockAddress()
+		tx, _ := NewTransaction(1, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+		signTransaction(t, tx)
+		if !valid {
+			tx.sign[0] ^= 0xff
+		}
+		return tx
+	}
+
+	budget, err := NewVerificationBudget(3, 16)
+	assert.Nil(t, err)
+
+	// failures under budget are reported per tx.
+	txs := Transactions{mockTx(true), mockTx(false), mockTx(true), mockTx(false)}
+	errs, err := txs.BatchVerifyIntegrity(1, "peer1", budget)
+	assert.Nil(t, err)
+	assert.Nil(t, errs[0])
+	assert.NotNil(t, errs[1])
+	assert.Nil(t, errs[2])
+	assert.NotNil(t, errs[3])
+	assert.Equal(t, 2, budget.Failures("peer1"))
+
+	// the third failure exhausts the budget, the rest txs are not verified.
+	txs = Transactions{mockTx(false), mockTx(true), mockTx(true)}
+	errs, err = txs.BatchVerifyIntegrity(1, "peer1", budget)
+	assert.Equal(t, ErrVerificationBudgetExceeded, err)
+	assert.NotNil(t, errs[0])
+	assert.NotEqual(t, ErrVerificationBudgetExceeded, errs[0])
+	assert.Equal(t, ErrVerificationBudgetExceeded, errs[1])
+	assert.Equal(t, ErrVerificationBudgetExceeded, errs[2])
+
+	// exhausted source is rejected before any verification.
+	errs, err = Transactions{mockTx(true)}.BatchVerifyIntegrity(1, "peer1", budget)
+	assert.Equal(t, ErrVerificationBudgetExceeded, err)
+	assert.Equal(t, ErrVerificationBudgetExceeded, errs[0])
+
+	// other sources keep their own budget.
+	errs, err = Transactions{mockTx(true)}.BatchVerifyIntegrity(1, "peer2", budget)
+	assert.Nil(t, err)
+	assert.Nil(t, errs[0])
+
+	budget.Reset("peer1")
+	_, err = Transactions{mockTx(true)}.BatchVerifyIntegrity(1, "peer1", budget)
+	assert.Nil(t, err)
+
+	// no budget, no limit.
+	txs = Transactions{mockTx(false), mockTx(false), mockTx(false), mockTx(false)}
+	errs, err = txs.BatchVerifyIntegrity(1, "peer1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(errs))
+
+	_, err = NewVerificationBudget(0, 16)
+	assert.Equal(t, ErrInvalidArgument, err)
+}
+
 func TestTransaction_VerifyExecution(t *testing.T) {
 	type testTx struct {
 		name            string
@@ -438,90 +953,2109 @@ func TestTransaction_VerifyExecution(t *testing.T) {
 
 }
 
-func TestTransaction_LocalExecution(t *testing.T) {
-	type testCase struct {
-		name    string
-		tx      *Transaction
-		gasUsed *util.Uint128
-		result  string
-		wanted  error
-	}
+func TestTransaction_TxHasher(t *testing.T) {
+	chainID := uint32(1001)
+	defer SetTxHasher(chainID, nil)
 
-	tests := []testCase{}
+	neb := testNeb(t)
+	neb.config = &nebletpb.Config{Chain: &nebletpb.ChainConfig{ChainId: chainID, TxHasher: "unknown"}}
+	_, err := NewBlockChain(neb)
+	assert.Equal(t, ErrUnsupportedTxHasher, err)
 
-	bc := testNeb(t).chain
+	neb.config.Chain.TxHasher = TxHasherSha256
+	_, err = NewBlockChain(neb)
+	assert.Nil(t, err)
 
-	normalTx := mockNormalTransaction(bc.chainID, 0)
-	normalTx.value, _ = util.NewUint128FromInt(1000000)
-	tests = append(tests, testCase{
-		name:    "normal tx",
-		tx:      normalTx,
-		gasUsed: MinGasCountPerTransaction,
-		result:  "",
-		wanted:  nil,
-	})
+	tx := mockNormalTransaction(chainID, 0)
+	signTransaction(t, tx)
 
-	deployTx := mockDeployTransaction(bc.chainID, 0)
-	deployTx.value = util.NewUint128()
-	gasUsed, _ := util.NewUint128FromInt(21143)
-	tests = append(tests, testCase{
-		name:    "contract deploy tx",
-		tx:      deployTx,
-		gasUsed: gasUsed,
-		result:  "",
-		wanted:  nil,
-	})
+	preimage, err := tx.SigningPreimage()
+	assert.Nil(t, err)
+	assert.Equal(t, byteutils.Hash(hash.Sha256(preimage)), tx.Hash())
+	assert.Nil(t, tx.VerifyIntegrity(chainID))
 
-	// contract call tx
-	callTx := mockCallTransaction(bc.chainID, 1, "totalSupply", "")
-	callTx.value = util.NewUint128()
-	gasUsed, _ = util.NewUint128FromInt(20036)
-	tests = append(tests, testCase{
-		name:    "contract call tx",
-		tx:      callTx,
-		gasUsed: gasUsed,
-		result:  "",
-		wanted:  state.ErrAccountNotFound,
-	})
+	// verify with another hasher fails.
+	SetTxHasher(chainID, nil)
+	assert.Equal(t, ErrInvalidTransactionHash, tx.VerifyIntegrity(chainID))
+}
 
-	block := bc.tailBlock
+func TestResignTransaction(t *testing.T) {
+	chainID := uint32(1001)
+	defer SetTxHasher(chainID, nil)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
+	tx := mockNormalTransaction(chainID, 0)
+	key, _ := keystore.DefaultKS.GetUnlocked(tx.from.String())
+	signature, _ := crypto.NewSignature(keystore.SECP256K1)
+	signature.InitSign(key.(keystore.PrivateKey))
+	assert.Nil(t, tx.Sign(signature))
+	assert.Nil(t, tx.VerifyIntegrity(chainID))
 
-			fromAcc, err := block.accState.GetOrCreateUserAccount(tt.tx.from.address)
-			assert.Nil(t, err)
-			fromBefore := fromAcc.Balance()
+	// the hash format changes, the old signature is stale.
+	SetTxHasher(chainID, hash.Sha256)
+	assert.Equal(t, ErrInvalidTransactionHash, tx.VerifyIntegrity(chainID))
+	staleHash := tx.Hash()
 
-			toAcc, err := block.accState.GetOrCreateUserAccount(tt.tx.to.address)
-			assert.Nil(t, err)
-			toBefore := toAcc.Balance()
+	// signed by another account is refused.
+	other := mockAddress()
+	otherKey, _ := keystore.DefaultKS.GetUnlocked(other.String())
+	otherSignature, _ := crypto.NewSignature(keystore.SECP256K1)
+	otherSignature.InitSign(otherKey.(keystore.PrivateKey))
+	assert.Equal(t, ErrInvalidTransactionSigner, ResignTransaction(tx, otherSignature))
+	assert.Equal(t, staleHash, tx.Hash())
 
-			coinbaseAcc, err := block.accState.GetOrCreateUserAccount(block.header.coinbase.address)
-			assert.Nil(t, err)
-			coinbaseBefore := coinbaseAcc.Balance()
+	assert.Nil(t, ResignTransaction(tx, signature))
+	assert.NotEqual(t, staleHash, tx.Hash())
+	assert.Nil(t, tx.VerifyIntegrity(chainID))
 
-			gasUsed, result, err := tt.tx.LocalExecution(block)
+	assert.Equal(t, ErrNilArgument, ResignTransaction(nil, signature))
+	assert.Equal(t, ErrNilArgument, ResignTransaction(tx, nil))
+}
 
-			assert.Equal(t, tt.wanted, err)
-			assert.Equal(t, tt.result, result)
-			assert.Equal(t, tt.gasUsed, gasUsed)
+func TestTransaction_NotBefore(t *testing.T) {
+	bc := testNeb(t).chain
+	balance, _ := util.NewUint128FromString("1000000000000000000")
 
-			fromAcc, err = block.accState.GetOrCreateUserAccount(tt.tx.from.address)
-			assert.Nil(t, err)
-			assert.Equal(t, fromBefore, fromAcc.Balance())
+	lockTime := time.Now().Unix()
+	tx, err := mockNormalTransaction(bc.chainID, 0).WithNotBefore(lockTime)
+	assert.Nil(t, err)
+	assert.Equal(t, lockTime, tx.NotBefore())
 
-			toAcc, err = block.accState.GetOrCreateUserAccount(tt.tx.to.address)
-			assert.Nil(t, err)
-			assert.Equal(t, toBefore, toAcc.Balance())
+	signTransaction(t, tx)
 
-			coinbaseAcc, err = block.accState.GetOrCreateUserAccount(block.header.coinbase.address)
+	// notBefore is carried by proto and mixed into the hash.
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	ntx := new(Transaction)
+	assert.Nil(t, ntx.FromProto(msg))
+	assert.Equal(t, lockTime, ntx.NotBefore())
+	assert.Nil(t, ntx.VerifyIntegrity(bc.chainID))
+	ntx.notBefore = 0
+	assert.Equal(t, ErrInvalidTransactionHash, ntx.VerifyIntegrity(bc.chainID))
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		wanted    error
+	}{
+		{"before lock", lockTime - 1, ErrTransactionNotYetValid},
+		{"at lock", lockTime, nil},
+		{"after lock", lockTime + 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := bc.tailBlock
+			timestamp := block.header.timestamp
+			block.header.timestamp = tt.timestamp
+			block.begin()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
 			assert.Nil(t, err)
-			assert.Equal(t, coinbaseBefore, coinbaseAcc.Balance())
+			fromAcc.AddBalance(balance)
+
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+
+			block.rollback()
+			block.header.timestamp = timestamp
 		})
 	}
 }
 
-func Test1(t *testing.T) {
-	fmt.Println(len(hash.Sha3256([]byte("abc"))))
+func TestTransaction_TypedSigning(t *testing.T) {
+	bc := testNeb(t).chain
+	tx, err := mockNormalTransaction(bc.chainID, 1).WithTypedSigning(true)
+	assert.Nil(t, err)
+	assert.True(t, tx.TypedSigning())
+
+	signTransaction(t, tx)
+
+	// typed tx is signed over the typed hash in the default domain.
+	typedHash, err := HashTypedTransaction(tx, DefaultTypedDataDomain(bc.chainID))
+	assert.Nil(t, err)
+	assert.Equal(t, typedHash, tx.Hash())
+	otherDomain, err := HashTypedTransaction(tx, DefaultTypedDataDomain(bc.chainID+1))
+	assert.Nil(t, err)
+	assert.NotEqual(t, typedHash, otherDomain)
+	data, err := tx.TypedData(DefaultTypedDataDomain(bc.chainID))
+	assert.Nil(t, err)
+	assert.Equal(t, tx.from.String(), data.Fields[0].Value)
+
+	// the flag survives proto round trip and the typed signature verifies.
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	ntx := new(Transaction)
+	assert.Nil(t, ntx.FromProto(msg))
+	assert.True(t, ntx.TypedSigning())
+	assert.Nil(t, ntx.VerifyIntegrity(bc.chainID))
+
+	// the typed signature doesn't verify as legacy tx.
+	ntx.typed = false
+	assert.Equal(t, ErrInvalidTransactionHash, ntx.VerifyIntegrity(bc.chainID))
+	ntx.hash, err = HashTransaction(ntx)
+	assert.Nil(t, err)
+	assert.NotNil(t, ntx.VerifyIntegrity(bc.chainID))
+
+	// legacy txs keep verifying.
+	legacy := mockNormalTransaction(bc.chainID, 1)
+	legacy.from = tx.from
+	signTransaction(t, legacy)
+	assert.Nil(t, legacy.VerifyIntegrity(bc.chainID))
+}
+
+func TestTransaction_Expiry(t *testing.T) {
+	bc := testNeb(t).chain
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	expiry := time.Now().Unix()
+	tx, err := mockNormalTransaction(bc.chainID, 0).WithExpiryTimestamp(expiry)
+	assert.Nil(t, err)
+	assert.Equal(t, expiry, tx.ExpiryTimestamp())
+
+	signTransaction(t, tx)
+
+	// expiry is carried by proto and mixed into the hash.
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	ntx := new(Transaction)
+	assert.Nil(t, ntx.FromProto(msg))
+	assert.Equal(t, expiry, ntx.ExpiryTimestamp())
+	assert.Nil(t, ntx.VerifyIntegrity(bc.chainID))
+	ntx.expiry = 0
+	assert.Equal(t, ErrInvalidTransactionHash, ntx.VerifyIntegrity(bc.chainID))
+
+	// expiry and notBefore of the same value hash differently.
+	locked, err := mockNormalTransaction(bc.chainID, 0).WithNotBefore(expiry)
+	assert.Nil(t, err)
+	expiring, err := mockNormalTransaction(bc.chainID, 0).WithExpiryTimestamp(expiry)
+	assert.Nil(t, err)
+	locked.timestamp, expiring.timestamp = tx.timestamp, tx.timestamp
+	lockedHash, err := HashTransaction(locked)
+	assert.Nil(t, err)
+	expiringHash, err := HashTransaction(expiring)
+	assert.Nil(t, err)
+	assert.NotEqual(t, lockedHash, expiringHash)
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		wanted    error
+	}{
+		{"before expiry", expiry - 1, nil},
+		{"at expiry", expiry, nil},
+		{"after expiry", expiry + 1, ErrTransactionExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := bc.tailBlock
+			timestamp := block.header.timestamp
+			block.header.timestamp = tt.timestamp
+			block.begin()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
+			assert.Nil(t, err)
+			fromAcc.AddBalance(balance)
+			before := fromAcc.Balance()
+
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+			if tt.wanted == ErrTransactionExpired {
+				// expired tx is rejected without charging any gas.
+				fromAcc, err = block.accState.GetOrCreateUserAccount(tx.from.address)
+				assert.Nil(t, err)
+				assert.Equal(t, before, fromAcc.Balance())
+				assert.Equal(t, uint64(0), fromAcc.Nonce())
+			}
+
+			block.rollback()
+			block.header.timestamp = timestamp
+		})
+	}
+}
+
+func TestTransaction_ExecutionDeadline(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	payload, _ := NewCallPayload("compute", "").ToBytes()
+	tx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, tx)
+	fromAcc, err = block.accState.GetOrCreateUserAccount(tx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+
+	// the compute-heavy call is cut short by the deadline in local execution.
+	nvm.busy = true
+	nvm.instructions = 5000
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = tx.LocalExecutionCtx(ctx, block)
+	assert.Equal(t, ErrExecutionDeadlineExceeded, err)
+
+	// the passed deadline never aborts the verification, the execution is the same on every node.
+	gasUsed, err := tx.VerifyExecutionCtx(ctx, block)
+	assert.Nil(t, err)
+	assert.Nil(t, nvm.executionCtx.Done())
+	baseGas, err := tx.GasCountOfTxBase()
+	assert.Nil(t, err)
+	assert.True(t, gasUsed.Cmp(baseGas) > 0)
+
+	fee, err := gasUsed.Mul(tx.gasPrice)
+	assert.Nil(t, err)
+	wanted, err := balance.Sub(fee)
+	assert.Nil(t, err)
+	fromAcc, err = block.accState.GetOrCreateUserAccount(tx.from.address)
+	assert.Nil(t, err)
+	assert.Equal(t, wanted, fromAcc.Balance())
+	txEvent, err := block.fetchExecutionResult(tx.hash)
+	assert.Nil(t, err)
+	assert.Equal(t, int8(TxExecutionSuccess), txEvent.Status)
+}
+
+func TestTransaction_LocalExecutionWithDiagnostics(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	payload, _ := NewCallPayload("save", "").ToBytes()
+	tx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+
+	// the storage-heavy call ranks storage first.
+	nvm.storageBytesWritten = 100000
+	diagnostics, err := tx.LocalExecutionWithDiagnostics(block)
+	assert.Nil(t, err)
+	assert.Equal(t, OpStorage, diagnostics.HotOps[0].Op)
+	assert.Equal(t, util.NewUint128FromUint(100000).String(), diagnostics.HotOps[0].Gas.String())
+	total := util.NewUint128()
+	for i, op := range diagnostics.HotOps {
+		if i > 0 {
+			assert.True(t, diagnostics.HotOps[i-1].Gas.Cmp(op.Gas) >= 0)
+		}
+		total, err = total.Add(op.Gas)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, diagnostics.GasUsed, total)
+
+	// the same as the gas of LocalExecution.
+	gasUsed, result, err := tx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, gasUsed, diagnostics.GasUsed)
+	assert.Equal(t, result, diagnostics.Result)
+
+	// the compute-heavy call ranks compute first.
+	nvm.storageBytesWritten = 0
+	nvm.instructions = 500000
+	diagnostics, err = tx.LocalExecutionWithDiagnostics(block)
+	assert.Nil(t, err)
+	assert.Equal(t, OpCompute, diagnostics.HotOps[0].Op)
+}
+
+func TestTransaction_LocalExecutionWithReentrancyCheck(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	deploy := func() *Address {
+		deployTx := mockDeployTransaction(bc.chainID, 1)
+		signTransaction(t, deployTx)
+		balance, _ := util.NewUint128FromString("1000000000000000000")
+		fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, fromAcc.AddBalance(balance))
+		_, err = deployTx.VerifyExecution(block)
+		assert.Nil(t, err)
+		assert.Nil(t, block.acceptTransaction(deployTx))
+		contract, err := deployTx.GenerateContractAddress()
+		assert.Nil(t, err)
+		return contract
+	}
+	bank := deploy()
+	attacker := deploy()
+
+	// nested calls made by the nvm: bank -> attacker -> bank ... up to maxDepth frames.
+	call := func(block *Block, from, to *Address, function string) {
+		payload := NewCallPayload(function, "")
+		data, _ := payload.ToBytes()
+		nested := &Transaction{
+			from:     from,
+			to:       to,
+			value:    util.NewUint128(),
+			data:     &corepb.Data{Type: TxPayloadCallType, Payload: data},
+			chainID:  bc.chainID,
+			gasPrice: TransactionGasPrice,
+			gasLimit: TransactionMaxGas,
+		}
+		_, _, err := payload.Execute(context.Background(), block, nested)
+		assert.Nil(t, err)
+	}
+	var depth, maxDepth int
+	nvm.onCall = func(block *Block) error {
+		depth++
+		switch {
+		case depth >= maxDepth:
+		case depth%2 == 1:
+			call(block, bank, attacker, "receive")
+		default:
+			call(block, attacker, bank, "withdraw")
+		}
+		return nil
+	}
+
+	withdraw, _ := NewCallPayload("withdraw", "").ToBytes()
+	tx, err := NewTransaction(bc.chainID, mockAddress(), bank, util.NewUint128(), 1, TxPayloadCallType, withdraw, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+
+	// bank calls attacker which returns without calling back.
+	depth, maxDepth = 0, 2
+	_, _, warnings, err := tx.LocalExecutionWithReentrancyCheck(block)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, depth)
+	assert.Empty(t, warnings)
+
+	// attacker re-enters bank.withdraw before the first frame returns.
+	depth, maxDepth = 0, 3
+	_, _, warnings, err = tx.LocalExecutionWithReentrancyCheck(block)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, depth)
+	assert.Equal(t, []*ReentrancyWarning{{Contract: bank.String(), Function: "withdraw", Depth: 3}}, warnings)
+
+	// the call stack is only tracked in the simulation.
+	depth, maxDepth = 0, 3
+	_, _, err = tx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.callTracer)
+}
+
+func TestTransaction_LocalExecutionWithStorageTrace(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	block.nvm = &mockNvm{storageKeys: []string{"totalSupply", "@balances[n1FF1nz6tarkDVwWQkMnnwFPuPKUaQTdptE]"}}
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	payload, _ := NewCallPayload("balanceOf", "").ToBytes()
+	tx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+
+	gasUsed, result, reads, err := tx.LocalExecutionWithStorageTrace(block)
+	assert.Nil(t, err)
+	assert.Equal(t, []*StorageRead{
+		{Contract: contract.String(), Key: "totalSupply"},
+		{Contract: contract.String(), Key: "@balances[n1FF1nz6tarkDVwWQkMnnwFPuPKUaQTdptE]"},
+	}, reads)
+
+	// the trace never affects the execution or gas.
+	wantedGas, wantedResult, err := tx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, wantedGas, gasUsed)
+	assert.Equal(t, wantedResult, result)
+	assert.Nil(t, block.callTracer)
+}
+
+func TestTransaction_SuggestGasLimit(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	payload, _ := NewCallPayload("heavy", "").ToBytes()
+	gasLimit, _ := util.NewUint128FromInt(30000)
+	tx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, payload, TransactionGasPrice, gasLimit)
+	assert.Nil(t, err)
+
+	// the call needs more instructions than the tx gasLimit allows.
+	nvm.instructions = 100000
+	_, _, err = tx.LocalExecution(block)
+	assert.Equal(t, ErrInsufficientGas, err)
+
+	// base gas is charged from the gasLimit too: 30000 -> ... -> 240000 succeeds, plus 10%.
+	suggested, err := tx.SuggestGasLimit(block, 10)
+	assert.Nil(t, err)
+	expected, _ := util.NewUint128FromInt(264000)
+	assert.Equal(t, expected, suggested)
+	assert.Equal(t, gasLimit, tx.gasLimit)
+
+	// enough gas already, suggest the tx gasLimit plus buffer.
+	nvm.instructions = 0
+	suggested, err = tx.SuggestGasLimit(block, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, gasLimit, suggested)
+
+	// never succeeds within TransactionMaxGas.
+	nvm.instructions = TransactionMaxGas.Uint64() + 1
+	_, err = tx.SuggestGasLimit(block, 10)
+	assert.Equal(t, ErrOutOfGasLimit, err)
+
+	_, err = tx.SuggestGasLimit(block, -1)
+	assert.Equal(t, ErrInvalidArgument, err)
+}
+
+func TestTransaction_RejectNoOpTransfers(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	deploy, _ := NewDeployPayload("var a = {}", "js", "").ToBytes()
+
+	tests := []struct {
+		name        string
+		value       int64
+		payloadType string
+		payload     []byte
+		policy      bool
+		wanted      error
+	}{
+		{"no-op under policy", 0, TxPayloadBinaryType, nil, true, ErrNoOpTransaction},
+		{"no-op without policy", 0, TxPayloadBinaryType, nil, false, nil},
+		{"self-transfer with value", 100, TxPayloadBinaryType, nil, true, nil},
+		{"self binary with payload", 0, TxPayloadBinaryType, []byte("memo"), true, nil},
+		{"deploy", 0, TxPayloadDeployType, deploy, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			value, _ := util.NewUint128FromInt(tt.value)
+			tx, err := NewTransaction(bc.chainID, from, from, value, 1, tt.payloadType, tt.payload, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+
+			block.begin()
+			defer block.rollback()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			SetRejectNoOpTransfers(bc.chainID, tt.policy)
+			defer SetRejectNoOpTransfers(bc.chainID, false)
+			assert.False(t, IsRejectingNoOpTransfers(bc.chainID+1))
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+		})
+	}
+}
+
+func TestTransaction_Nonceless(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	calls := 0
+	nvm := &mockNvm{calls: &calls}
+	block.nvm = nvm
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	fund := func(addr *Address) {
+		acc, err := block.accState.GetOrCreateUserAccount(addr.address)
+		assert.Nil(t, err)
+		assert.Nil(t, acc.AddBalance(balance))
+	}
+
+	block.begin()
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fund(deployTx.from)
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	wallet, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	block.commit()
+
+	execute, _ := NewCallPayload("execute", "").ToBytes()
+	tests := []struct {
+		name    string
+		to      *Address
+		callErr error
+		calls   int
+		status  int8
+	}{
+		{"accepted by wallet", wallet, nil, 2, TxExecutionSuccess},
+		{"rejected by wallet", wallet, errors.New("nonce used"), 1, TxExecutionFailed},
+		{"not a contract", mockAddress(), nil, 0, TxExecutionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, tt.to, util.NewUint128(), 42, TxPayloadCallType, execute, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			hash, err := HashTransaction(tx)
+			assert.Nil(t, err)
+			tx, err = tx.WithNonceless(true)
+			assert.Nil(t, err)
+			assert.True(t, tx.Nonceless())
+			signTransaction(t, tx)
+			assert.NotEqual(t, hash, tx.hash)
+
+			block.begin()
+			defer block.rollback()
+			fund(from)
+			calls = 0
+			nvm.callErr = tt.callErr
+			_, err = block.executeTransaction(tx)
+			assert.Nil(t, err)
+			// the wallet hook is called before the tx payload.
+			assert.Equal(t, tt.calls, calls)
+			txEvent, err := block.fetchExecutionResult(tx.hash)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.status, txEvent.Status)
+
+			// the account nonce is neither checked nor increased.
+			acc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Equal(t, uint64(0), acc.Nonce())
+			if tt.status == TxExecutionFailed {
+				// rejected nonce is charged the gas cap of the hook.
+				assert.Equal(t, ErrContractNonceRejected.Error(), txEvent.Error)
+				gasUsed, err := tx.GasCountOfTxBase()
+				assert.Nil(t, err)
+				gasUsed, err = gasUsed.Add(ContractNonceGasLimit)
+				assert.Nil(t, err)
+				fee, err := TransactionGasPrice.Mul(gasUsed)
+				assert.Nil(t, err)
+				charged, err := balance.Sub(acc.Balance())
+				assert.Nil(t, err)
+				assert.Equal(t, fee, charged)
+			}
+		})
+	}
+
+	// nonceless flag survives proto round trip.
+	tx, err := NewTransaction(bc.chainID, mockAddress(), wallet, util.NewUint128(), 7, TxPayloadCallType, execute, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	tx, err = tx.WithNonceless(true)
+	assert.Nil(t, err)
+	signTransaction(t, tx)
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	decoded := new(Transaction)
+	assert.Nil(t, decoded.FromProto(msg))
+	assert.True(t, decoded.Nonceless())
+	assert.Nil(t, decoded.VerifyIntegrity(bc.chainID))
+}
+
+func TestTransaction_Condition(t *testing.T) {
+	bc := testNeb(t).chain
+	block, err := NewBlock(bc.ChainID(), mockAddress(), bc.tailBlock)
+	assert.Nil(t, err)
+	calls := 0
+	nvm := &mockNvm{calls: &calls}
+	block.nvm = nvm
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	fund := func(addr *Address) {
+		acc, err := block.accState.GetOrCreateUserAccount(addr.address)
+		assert.Nil(t, err)
+		assert.Nil(t, acc.AddBalance(balance))
+	}
+
+	block.begin()
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fund(deployTx.from)
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	predicate, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	block.commit()
+
+	_, err = NewCondition(predicate, "price below")
+	assert.Equal(t, ErrInvalidCallFunction, err)
+	condition, err := NewCondition(predicate, "priceBelow")
+	assert.Nil(t, err)
+
+	value, _ := util.NewUint128FromInt(100)
+	tests := []struct {
+		name      string
+		result    string
+		status    int8
+		delivered *util.Uint128
+	}{
+		{"predicate returns true", "true", TxExecutionSuccess, value},
+		{"predicate returns false", "false", TxExecutionConditionNotMet, util.NewUint128()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			to := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, to, value, 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			hash, err := HashTransaction(tx)
+			assert.Nil(t, err)
+			tx, err = tx.WithCondition(condition)
+			assert.Nil(t, err)
+			assert.Equal(t, condition, tx.Condition())
+			signTransaction(t, tx)
+			assert.NotEqual(t, hash, tx.hash)
+
+			block.begin()
+			defer block.rollback()
+			fund(from)
+			calls = 0
+			nvm.result = tt.result
+			gasUsed, err := tx.VerifyExecution(block)
+			assert.Nil(t, err)
+			// the predicate is called before value transfer.
+			assert.Equal(t, 1, calls)
+
+			toAcc, err := block.accState.GetOrCreateUserAccount(to.address)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.delivered, toAcc.Balance())
+			txEvent, err := block.fetchExecutionResult(tx.hash)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.status, txEvent.Status)
+			if tt.status == TxExecutionConditionNotMet {
+				baseGas, err := tx.GasCountOfTxBase()
+				assert.Nil(t, err)
+				assert.Equal(t, baseGas, gasUsed)
+				assert.Equal(t, ErrConditionNotMet.Error(), txEvent.Error)
+			}
+		})
+	}
+
+	// condition survives proto round trip.
+	tx, err := NewTransaction(bc.chainID, mockAddress(), mockAddress(), value, 7, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	tx, err = tx.WithCondition(condition)
+	assert.Nil(t, err)
+	signTransaction(t, tx)
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	decoded := new(Transaction)
+	assert.Nil(t, decoded.FromProto(msg))
+	assert.Equal(t, predicate.String(), decoded.Condition().Contract.String())
+	assert.Equal(t, "priceBelow", decoded.Condition().Function)
+	assert.Nil(t, decoded.VerifyIntegrity(bc.chainID))
+}
+
+func TestTransaction_MinimumGasLimit(t *testing.T) {
+	deploy, _ := NewDeployPayload("var a = {}", "js", "").ToBytes()
+	call, _ := NewCallPayload("totalSupply", "").ToBytes()
+
+	tests := []struct {
+		name        string
+		payloadType string
+		payload     []byte
+		wanted      error
+	}{
+		{"binary", TxPayloadBinaryType, nil, nil},
+		{"binary with data", TxPayloadBinaryType, []byte("memo"), nil},
+		{"deploy", TxPayloadDeployType, deploy, nil},
+		{"call", TxPayloadCallType, call, nil},
+		{"unknown type", "unknown", nil, ErrInvalidTxPayloadType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := mockTransaction(1, 1, tt.payloadType, tt.payload)
+			gas, err := tx.MinimumGasLimit()
+			assert.Equal(t, tt.wanted, err)
+			if err != nil {
+				return
+			}
+			// no payload charges base gas now, the floor is the tx base gas.
+			want, _ := util.NewUint128FromInt(20000 + int64(len(tt.payload)))
+			assert.Equal(t, want, gas)
+		})
+	}
+}
+
+func TestTransaction_CanAffordDataGas(t *testing.T) {
+	huge := bytes.Repeat([]byte("x"), MaxDataPayLoadLength-100)
+	// base gas = 20000 + data length.
+	hugeGas := int64(20000 + len(huge))
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		gasLimit int64
+		wanted   bool
+	}{
+		{"no data", nil, 20000, true},
+		{"small data", []byte("memo"), 20003, false},
+		{"huge data covered", huge, hugeGas, true},
+		{"huge data one gas short", huge, hugeGas - 1, false},
+		{"huge data with default limit", huge, 200000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gasLimit, _ := util.NewUint128FromInt(tt.gasLimit)
+			tx, err := NewTransaction(1, mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, tt.payload, TransactionGasPrice, gasLimit)
+			assert.Nil(t, err)
+			ok, err := tx.CanAffordDataGas()
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wanted, ok)
+		})
+	}
+}
+
+func TestIsDeployTxProto(t *testing.T) {
+	deploy, _ := NewDeployPayload("var a = {}", "js", "").ToBytes()
+	call, _ := NewCallPayload("totalSupply", "").ToBytes()
+
+	tests := []struct {
+		name        string
+		payloadType string
+		payload     []byte
+		deploy      bool
+		call        bool
+	}{
+		{"binary", TxPayloadBinaryType, nil, false, false},
+		{"deploy", TxPayloadDeployType, deploy, true, false},
+		{"call", TxPayloadCallType, call, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := mockTransaction(1, 1, tt.payloadType, tt.payload)
+			msg, err := tx.ToProto()
+			assert.Nil(t, err)
+			pbTx := msg.(*corepb.Transaction)
+			assert.Equal(t, tt.deploy, IsDeployTxProto(pbTx))
+
+			to, ok := CallTargetFromProto(pbTx)
+			assert.Equal(t, tt.call, ok)
+			if tt.call {
+				assert.Equal(t, tx.to.Bytes(), to)
+			} else {
+				assert.Nil(t, to)
+			}
+		})
+	}
+
+	// no data at all.
+	assert.False(t, IsDeployTxProto(&corepb.Transaction{}))
+	_, ok := CallTargetFromProto(&corepb.Transaction{})
+	assert.False(t, ok)
+}
+
+func TestTransaction_VerifyTimestamp(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	drift := int64(60)
+
+	tests := []struct {
+		name   string
+		ahead  int64
+		wanted error
+	}{
+		{"behind block", -100, nil},
+		{"same as block", 0, nil},
+		{"within drift", drift, nil},
+		{"beyond drift", drift + 1, ErrTransactionTimestampTooFarAhead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			tx.timestamp = block.Timestamp() + tt.ahead
+			assert.Equal(t, tt.wanted, tx.VerifyTimestamp(block.Timestamp(), drift))
+
+			signTransaction(t, tx)
+
+			block.begin()
+			defer block.rollback()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			SetMaxTimestampDrift(bc.chainID, drift)
+			defer SetMaxTimestampDrift(bc.chainID, 0)
+			assert.Equal(t, int64(0), MaxTimestampDriftOf(bc.chainID+1))
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+		})
+	}
+}
+
+func TestDecodeTransaction(t *testing.T) {
+	from := mockAddress()
+	to := mockAddress()
+	value, _ := util.NewUint128FromInt(42)
+	payload, _ := NewCallPayload("transfer", "[\"n1\", 10]").ToBytes()
+	tx, err := NewTransaction(1, from, to, value, 7, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, tx)
+
+	msg, err := tx.ToProto()
+	assert.Nil(t, err)
+	raw, err := proto.Marshal(msg)
+	assert.Nil(t, err)
+
+	decoded, err := DecodeTransaction(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Hash(), decoded.Hash())
+	assert.Equal(t, from.String(), decoded.From().String())
+	assert.Equal(t, to.String(), decoded.To().String())
+	assert.Equal(t, value.String(), decoded.Value().String())
+	assert.Equal(t, uint64(7), decoded.Nonce())
+	assert.Equal(t, TxPayloadCallType, decoded.Type())
+	assert.Nil(t, decoded.VerifyIntegrity(1))
+
+	dump := decoded.PrettyPrint()
+	assert.Contains(t, dump, tx.Hash().String())
+	assert.Contains(t, dump, from.String())
+	assert.Contains(t, dump, "Type:      call")
+	assert.Contains(t, dump, "Function:  transfer")
+
+	_, err = DecodeTransaction([]byte{0xff, 0xff})
+	assert.NotNil(t, err)
+}
+
+func TestTransaction_CompactString(t *testing.T) {
+	from := mockAddress()
+	to := mockAddress()
+	value, _ := util.NewUint128FromInt(42)
+	payload, _ := NewCallPayload("transfer", "[\"n1\", 10]").ToBytes()
+	tx, err := NewTransaction(1, from, to, value, 7, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	hash, err := HashTransaction(tx)
+	assert.Nil(t, err)
+
+	compact, err := tx.ToCompactString()
+	assert.Nil(t, err)
+	parsed, err := ParseCompactTransaction(compact)
+	assert.Nil(t, err)
+	assert.Nil(t, parsed.Hash())
+	assert.Nil(t, parsed.sign)
+	parsedHash, err := HashTransaction(parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, parsedHash)
+
+	// the parsed tx can be signed on the other device.
+	signTransaction(t, parsed)
+	assert.Equal(t, hash, parsed.Hash())
+	assert.Nil(t, parsed.VerifyIntegrity(1))
+
+	// signature is never exported.
+	signed, err := parsed.ToCompactString()
+	assert.Nil(t, err)
+	assert.Equal(t, compact, signed)
+}
+
+func TestParseCompactTransaction_Corrupted(t *testing.T) {
+	tx, err := NewTransaction(1, mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	compact, err := tx.ToCompactString()
+	assert.Nil(t, err)
+
+	tests := []string{
+		"",
+		"0OIl",
+		compact[:len(compact)/2],
+	}
+	for _, s := range tests {
+		_, err := ParseCompactTransaction(s)
+		assert.NotNil(t, err, s)
+	}
+	_, err = ParseCompactTransaction("0OIl")
+	assert.Equal(t, ErrInvalidCompactTransaction, err)
+}
+
+func TestTransaction_VerifyExecutionTraced(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	callTx := mockCallTransaction(bc.chainID, 2, "totalSupply", "")
+	callTx.from = deployTx.from
+	callTx.to = contract
+	signTransaction(t, callTx)
+
+	gasUsed, trace, err := callTx.VerifyExecutionTraced(block)
+	assert.Nil(t, err)
+	assert.NotNil(t, gasUsed)
+
+	steps := make(map[string]string)
+	for _, step := range trace.Steps {
+		steps[step.Step] = step.Decision
+	}
+	assert.Equal(t, "checked", steps["base gas"])
+	assert.Equal(t, "transferred "+callTx.value.String(), steps["transfer"])
+	assert.Equal(t, "executed", steps["execute"])
+	assert.Equal(t, "merged", steps["merge"])
+	assert.Equal(t, "charged "+gasUsed.String(), steps["consume gas"])
+}
+
+func TestTransaction_ResultHash(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	resultHash := func(tx *Transaction) string {
+		events, err := block.FetchEvents(tx.hash)
+		assert.Nil(t, err)
+		for _, v := range events {
+			if v.Topic == TopicTransactionExecutionResult {
+				txEvent := TransactionEvent{}
+				assert.Nil(t, json.Unmarshal([]byte(v.Data), &txEvent))
+				return txEvent.ResultHash
+			}
+		}
+		return ""
+	}
+	// deploy the same contract and call it with given result.
+	call := func(result string) string {
+		deployTx := mockDeployTransaction(bc.chainID, 1)
+		signTransaction(t, deployTx)
+		fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, fromAcc.AddBalance(balance))
+		_, err = deployTx.VerifyExecution(block)
+		assert.Nil(t, err)
+		assert.Nil(t, block.acceptTransaction(deployTx))
+		contract, err := deployTx.GenerateContractAddress()
+		assert.Nil(t, err)
+
+		callTx := mockCallTransaction(bc.chainID, 2, "totalSupply", "")
+		callTx.from = deployTx.from
+		callTx.to = contract
+		signTransaction(t, callTx)
+		nvm.result = result
+		_, err = callTx.VerifyExecution(block)
+		assert.Nil(t, err)
+		return resultHash(callTx)
+	}
+
+	hash1 := call("1000000000")
+	hash2 := call("1000000000")
+	assert.Equal(t, byteutils.Hash(hash.Sha3256([]byte("1000000000"))).String(), hash1)
+	assert.Equal(t, hash1, hash2)
+	assert.NotEqual(t, hash1, call("2000000000"))
+}
+
+func TestTransaction_VerifyExecutionDeployAddress(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	emptySource, _ := NewDeployPayload("", "js", "").ToBytes()
+
+	tests := []struct {
+		name        string
+		sameTo      bool
+		emptySource bool
+		wanted      error
+	}{
+		{"from != to", false, false, ErrContractTransactionAddressNotEqual},
+		{"from == to", true, false, nil},
+		{"empty source", true, true, ErrEmptyContractSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := mockDeployTransaction(bc.chainID, 1)
+			if !tt.sameTo {
+				tx.to = mockAddress()
+			}
+			if tt.emptySource {
+				tx.data.Payload = emptySource
+			}
+			signTransaction(t, tx)
+
+			block.begin()
+			defer block.rollback()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			gasUsed, err := tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+			fromAcc, err = block.accState.GetOrCreateUserAccount(tx.from.address)
+			assert.Nil(t, err)
+			if tt.wanted != nil {
+				// rejected early, no gas charged and no result recorded.
+				assert.Nil(t, gasUsed)
+				assert.Equal(t, balance.String(), fromAcc.Balance().String())
+				events, err := block.FetchEvents(tx.hash)
+				assert.Nil(t, err)
+				assert.Equal(t, 0, len(events))
+			} else {
+				assert.NotEqual(t, balance.String(), fromAcc.Balance().String())
+			}
+		})
+	}
+}
+
+func TestTransaction_ReservedAddress(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	value := util.NewUint128FromUint(100)
+	system := mockAddress()
+
+	tests := []struct {
+		name   string
+		height uint64
+		to     *Address
+		value  *util.Uint128
+		wanted error
+	}{
+		{"value to zero address", block.Height(), GenesisCoinbase, value, ErrTransferToReservedAddress},
+		{"value to configured address", block.Height(), system, value, ErrTransferToReservedAddress},
+		{"no value to zero address", block.Height(), GenesisCoinbase, util.NewUint128(), nil},
+		{"value to normal address", block.Height(), mockAddress(), value, nil},
+		{"value to zero address before activation", block.Height() + 1, GenesisCoinbase, value, nil},
+		{"not reserved", 0, GenesisCoinbase, value, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetReservedAddresses(bc.chainID, []*Address{system}, tt.height)
+			defer SetReservedAddresses(bc.chainID, nil, 0)
+			assert.False(t, IsReservedAddress(bc.chainID+1, block.Height(), GenesisCoinbase))
+
+			from := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, tt.to, tt.value, 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+
+			block.begin()
+			defer block.rollback()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+		})
+	}
+}
+
+func TestValidatePayloadType(t *testing.T) {
+	bc := testNeb(t).chain
+	deploy, _ := NewDeployPayload("var a = 1;", "js", "").ToBytes()
+	call, _ := NewCallPayload("save", "[1]").ToBytes()
+
+	tests := []struct {
+		name        string
+		payloadType string
+		payload     []byte
+		wanted      error
+	}{
+		{"binary", TxPayloadBinaryType, []byte("data"), nil},
+		{"binary carries deploy", TxPayloadBinaryType, deploy, nil},
+		{"empty binary", TxPayloadBinaryType, nil, nil},
+		{"deploy", TxPayloadDeployType, deploy, nil},
+		{"deploy carries call", TxPayloadDeployType, call, ErrEmptyContractSource},
+		{"deploy carries bytes", TxPayloadDeployType, []byte("data"), ErrPayloadTypeMismatch},
+		{"deploy empty source", TxPayloadDeployType, []byte(`{"SourceType":"js","Source":""}`), ErrEmptyContractSource},
+		{"call", TxPayloadCallType, call, nil},
+		{"call lowercase keys", TxPayloadCallType, []byte(`{"function":"save","args":"[1]"}`), nil},
+		{"call carries deploy", TxPayloadCallType, deploy, ErrPayloadTypeMismatch},
+		{"call carries bytes", TxPayloadCallType, []byte("data"), ErrPayloadTypeMismatch},
+		{"call carries unknown keys", TxPayloadCallType, []byte(`{"Function":"save","Memo":"x"}`), nil},
+		{"unknown type", "unknown", call, ErrInvalidTxPayloadType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wanted, ValidatePayloadType(tt.payloadType, tt.payload))
+
+			// mismatched payloads are rejected by the pool, while deserialization keeps them as is.
+			tx, err := NewTransaction(bc.chainID, mockAddress(), mockAddress(), util.NewUint128(), 1, tt.payloadType, tt.payload, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+			assert.Equal(t, tt.wanted, bc.txPool.Push(tx))
+
+			msg, err := tx.ToProto()
+			assert.Nil(t, err)
+			decoded := new(Transaction)
+			assert.Nil(t, decoded.FromProto(msg))
+			assert.Equal(t, tx.data, decoded.data)
+		})
+	}
+}
+
+func TestTransaction_FeeToken(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	calls := 0
+	nvm := &mockNvm{calls: &calls}
+	block.nvm = nvm
+
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	// mock token contract, its transfer result is decided by mockNvm.
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	deployer, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, deployer.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	token, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		feeToken *Address
+		callErr  error
+		calls    int
+		status   int8
+	}{
+		{"native fee", nil, nil, 0, TxExecutionSuccess},
+		{"token fee escrowed and refunded", token, nil, 2, TxExecutionSuccess},
+		{"token transfer failed", token, errors.New("insufficient token"), 1, TxExecutionFailed},
+		{"token not contract", mockAddress(), nil, 0, TxExecutionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			tx, err = tx.WithFeeToken(tt.feeToken)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.feeToken, tx.FeeToken())
+			signTransaction(t, tx)
+
+			// fee token survives proto round trip and is signed.
+			msg, err := tx.ToProto()
+			assert.Nil(t, err)
+			ntx := new(Transaction)
+			assert.Nil(t, ntx.FromProto(msg))
+			assert.Equal(t, tx.feeToken, ntx.feeToken)
+			assert.Nil(t, ntx.VerifyIntegrity(bc.chainID))
+
+			fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			calls = 0
+			nvm.callErr = tt.callErr
+			gasUsed, err := tx.VerifyExecution(block)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.calls, calls)
+
+			// the tx whose fee token escrow fails is kept in block as failed, the payload is not executed.
+			events, err := block.FetchEvents(tx.hash)
+			assert.Nil(t, err)
+			txEvent := TransactionEvent{}
+			assert.Nil(t, json.Unmarshal([]byte(events[len(events)-1].Data), &txEvent))
+			assert.Equal(t, tt.status, txEvent.Status)
+
+			fromAcc, err = block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			charged, err := balance.Sub(fromAcc.Balance())
+			assert.Nil(t, err)
+			if tt.feeToken == nil || tt.status == TxExecutionFailed {
+				// the gas of the tx failing to pay in token is charged in native coin.
+				baseGas, err := tx.GasCountOfTxBase()
+				assert.Nil(t, err)
+				if tt.feeToken != nil {
+					assert.Equal(t, baseGas, gasUsed)
+				}
+				fee, err := TransactionGasPrice.Mul(gasUsed)
+				assert.Nil(t, err)
+				assert.True(t, fee.Cmp(util.NewUint128()) > 0)
+				assert.Equal(t, fee.String(), charged.String())
+			} else {
+				// native balance is untouched when paying in token.
+				assert.Equal(t, "0", charged.String())
+			}
+		})
+	}
+
+	// the base fee burned and the fallback fee in native coin are checked before executing the token fee tx.
+	block.header.baseFee = util.NewUint128FromUint(100)
+	defer func() { block.header.baseFee = nil }()
+	from := mockAddress()
+	tx, err := NewTransaction(bc.chainID, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	tx, err = tx.WithFeeToken(token)
+	assert.Nil(t, err)
+	signTransaction(t, tx)
+	burned, err := block.header.baseFee.Mul(TransactionMaxGas)
+	assert.Nil(t, err)
+	tip, err := TransactionGasPrice.Sub(block.header.baseFee)
+	assert.Nil(t, err)
+	baseGas, err := tx.GasCountOfTxBase()
+	assert.Nil(t, err)
+	fallback, err := tip.Mul(baseGas)
+	assert.Nil(t, err)
+	wanted, err := burned.Add(fallback)
+	assert.Nil(t, err)
+	required, err := tx.minBalanceRequiredAt(block)
+	assert.Nil(t, err)
+	assert.Equal(t, wanted.String(), required.String())
+	calls = 0
+	nvm.callErr = nil
+	_, err = tx.VerifyExecution(block)
+	assert.Equal(t, ErrInsufficientBalance, err)
+	assert.Equal(t, 0, calls)
+}
+
+func TestTransaction_DynamicFee(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	baseFee, _ := util.NewUint128FromInt(100)
+	block.header.baseFee = baseFee
+	defer func() { block.header.baseFee = nil }()
+
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	uint128 := func(v int64) *util.Uint128 {
+		u, _ := util.NewUint128FromInt(v)
+		return u
+	}
+
+	tx, _ := NewTransaction(bc.chainID, mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	_, err := tx.WithDynamicFee(uint128(100), uint128(101))
+	assert.Equal(t, ErrInvalidArgument, err)
+	_, err = tx.WithDynamicFee(nil, uint128(1))
+	assert.Equal(t, ErrNilArgument, err)
+
+	tests := []struct {
+		name        string
+		maxFee      *util.Uint128 // nil means legacy tx paying gasPrice
+		maxTip      *util.Uint128
+		tipPerGas   *util.Uint128
+		wanted      error
+		wantedPrice *util.Uint128
+	}{
+		{"full tip", uint128(2000), uint128(1000), uint128(1000), nil, uint128(1100)},
+		{"tip capped by max fee", uint128(150), uint128(100), uint128(50), nil, uint128(150)},
+		{"max fee equals base fee", uint128(100), uint128(100), uint128(0), nil, uint128(100)},
+		{"legacy tx", nil, nil, uint128(999900), nil, TransactionGasPrice},
+		{"max fee below base fee", uint128(99), uint128(10), nil, ErrMaxFeeBelowBaseFee, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := mockAddress()
+			tx, err := NewTransaction(bc.chainID, from, mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			if tt.maxFee != nil {
+				tx, err = tx.WithDynamicFee(tt.maxFee, tt.maxTip)
+				assert.Nil(t, err)
+			}
+			signTransaction(t, tx)
+
+			// dynamic fee survives proto round trip and is signed.
+			msg, err := tx.ToProto()
+			assert.Nil(t, err)
+			ntx := new(Transaction)
+			assert.Nil(t, ntx.FromProto(msg))
+			assert.Equal(t, tx.MaxFeePerGas(), ntx.MaxFeePerGas())
+			assert.Equal(t, tx.MaxPriorityFeePerGas(), ntx.MaxPriorityFeePerGas())
+			assert.Nil(t, ntx.VerifyIntegrity(bc.chainID))
+
+			price, err := tx.EffectiveGasPrice(baseFee)
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.wantedPrice, price)
+
+			fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+			coinbaseAcc, err := block.accState.GetOrCreateUserAccount(block.Coinbase().address)
+			assert.Nil(t, err)
+			coinbaseBefore := coinbaseAcc.Balance()
+
+			gasUsed, err := tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+
+			fromAcc, err = block.accState.GetOrCreateUserAccount(from.address)
+			assert.Nil(t, err)
+			charged, err := balance.Sub(fromAcc.Balance())
+			assert.Nil(t, err)
+			coinbaseAcc, err = block.accState.GetOrCreateUserAccount(block.Coinbase().address)
+			assert.Nil(t, err)
+			tipped, err := coinbaseAcc.Balance().Sub(coinbaseBefore)
+			assert.Nil(t, err)
+			if tt.wanted != nil {
+				assert.Equal(t, "0", charged.String())
+				assert.Equal(t, "0", tipped.String())
+				return
+			}
+
+			// base fee is burned, the tip goes to the coinbase.
+			burned, _ := baseFee.Mul(gasUsed)
+			tip, _ := tt.tipPerGas.Mul(gasUsed)
+			total, _ := burned.Add(tip)
+			assert.Equal(t, total.String(), charged.String())
+			assert.Equal(t, tip.String(), tipped.String())
+		})
+	}
+}
+
+func TestTransaction_VerifyExecutionCtx(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fund := func(tx *Transaction) {
+		fromAcc, err := block.accState.GetOrCreateUserAccount(tx.from.address)
+		assert.Nil(t, err)
+		assert.Nil(t, fromAcc.AddBalance(balance))
+	}
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	fund(deployTx)
+	_, err := deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	// capture the logs of failed executions.
+	logger := logging.VLog()
+	out, level, formatter := logger.Out, logger.Level, logger.Formatter
+	buf := new(bytes.Buffer)
+	logger.Out, logger.Level, logger.Formatter = buf, logrus.DebugLevel, &logrus.JSONFormatter{}
+	defer func() {
+		logger.Out, logger.Level, logger.Formatter = out, level, formatter
+	}()
+
+	callPayload, _ := NewCallPayload("transfer", "").ToBytes()
+	callTx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, callPayload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, callTx)
+	fund(callTx)
+	badTx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, TxPayloadCallType, []byte("bad"), TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, badTx)
+	fund(badTx)
+
+	nvm.callErr = errors.New("call failed")
+	ctx := WithTraceID(context.Background(), "trace-1")
+	assert.Equal(t, "trace-1", TraceID(ctx))
+	_, err = callTx.VerifyExecutionCtx(ctx, block)
+	assert.Nil(t, err)
+	_, err = badTx.VerifyExecutionCtx(ctx, block)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// contract call, payload execution and payload load failures.
+	assert.True(t, len(lines) >= 3)
+	for _, line := range lines {
+		fields := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(line), &fields))
+		assert.Equal(t, "trace-1", fields["traceID"], line)
+	}
+
+	// tx hash is the default trace ID.
+	buf.Reset()
+	_, err = callTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"traceID":"`+callTx.hash.String()+`"`)
+}
+
+func TestParseTxAmounts(t *testing.T) {
+	max := "340282366920938463463374607431768211455"
+	tests := []struct {
+		name     string
+		value    string
+		gasPrice string
+		gasLimit string
+		wanted   error
+	}{
+		{"zero", "0", "0", "0", nil},
+		{"max", max, max, max, nil},
+		{"normal", "100", "1000000", "2000000", nil},
+		{"value overflow", "340282366920938463463374607431768211456", "1", "1", ErrInvalidTxValue},
+		{"negative value", "-1", "1", "1", ErrInvalidTxValue},
+		{"empty gasPrice", "1", "", "1", ErrInvalidTxGasPrice},
+		{"hex gasPrice", "1", "0x10", "1", ErrInvalidTxGasPrice},
+		{"invalid gasLimit", "1", "1", "abc", ErrInvalidTxGasLimit},
+		{"decimal gasLimit", "1", "1", "1.5", ErrInvalidTxGasLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, gasPrice, gasLimit, err := ParseTxAmounts(tt.value, tt.gasPrice, tt.gasLimit)
+			assert.Equal(t, tt.wanted, err)
+			if err != nil {
+				return
+			}
+
+			// decimal strings round trip through the tx.
+			tx := &Transaction{value: value, gasPrice: gasPrice, gasLimit: gasLimit}
+			assert.Equal(t, tt.value, tx.ValueString())
+			assert.Equal(t, tt.gasPrice, tx.GasPriceString())
+			assert.Equal(t, tt.gasLimit, tx.GasLimitString())
+		})
+	}
+}
+
+func TestTransaction_StaticCall(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	readOnly := false
+	nvm := &mockNvm{readOnly: &readOnly}
+	block.nvm = nvm
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	getter, _ := NewCallPayload("balanceOf", "").ToBytes()
+	tests := []struct {
+		name        string
+		payloadType string
+		payload     []byte
+		result      string
+		callErr     error
+		wanted      error
+	}{
+		{"getter", TxPayloadCallType, getter, "100", nil, nil},
+		{"setter", TxPayloadCallType, getter, "", ErrReadOnlyViolation, ErrReadOnlyViolation},
+		{"not call", TxPayloadBinaryType, nil, "", nil, ErrInvalidTxPayloadType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := NewTransaction(bc.chainID, mockAddress(), contract, util.NewUint128(), 1, tt.payloadType, tt.payload, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+			stateRoot, err := block.accState.RootHash()
+			assert.Nil(t, err)
+
+			readOnly = false
+			nvm.result, nvm.callErr = tt.result, tt.callErr
+			result, err := tx.StaticCall(block)
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.result, result)
+			if tt.payloadType == TxPayloadCallType {
+				assert.True(t, readOnly)
+			}
+
+			// nothing changed in block.
+			root, err := block.accState.RootHash()
+			assert.Nil(t, err)
+			assert.Equal(t, stateRoot, root)
+			events, err := block.FetchEvents(tx.hash)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, len(events))
+		})
+	}
+}
+
+func TestTransaction_LocalExecution(t *testing.T) {
+	type testCase struct {
+		name    string
+		tx      *Transaction
+		gasUsed *util.Uint128
+		result  string
+		wanted  error
+	}
+
+	tests := []testCase{}
+
+	bc := testNeb(t).chain
+
+	normalTx := mockNormalTransaction(bc.chainID, 0)
+	normalTx.value, _ = util.NewUint128FromInt(1000000)
+	tests = append(tests, testCase{
+		name:    "normal tx",
+		tx:      normalTx,
+		gasUsed: MinGasCountPerTransaction,
+		result:  "",
+		wanted:  nil,
+	})
+
+	deployTx := mockDeployTransaction(bc.chainID, 0)
+	deployTx.value = util.NewUint128()
+	gasUsed, _ := util.NewUint128FromInt(21143)
+	tests = append(tests, testCase{
+		name:    "contract deploy tx",
+		tx:      deployTx,
+		gasUsed: gasUsed,
+		result:  "",
+		wanted:  nil,
+	})
+
+	// contract call tx
+	callTx := mockCallTransaction(bc.chainID, 1, "totalSupply", "")
+	callTx.value = util.NewUint128()
+	gasUsed, _ = util.NewUint128FromInt(20036)
+	tests = append(tests, testCase{
+		name:    "contract call tx",
+		tx:      callTx,
+		gasUsed: gasUsed,
+		result:  "",
+		wanted:  ErrContractNotFound,
+	})
+
+	block := bc.tailBlock
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fromAcc, err := block.accState.GetOrCreateUserAccount(tt.tx.from.address)
+			assert.Nil(t, err)
+			fromBefore := fromAcc.Balance()
+
+			toAcc, err := block.accState.GetOrCreateUserAccount(tt.tx.to.address)
+			assert.Nil(t, err)
+			toBefore := toAcc.Balance()
+
+			coinbaseAcc, err := block.accState.GetOrCreateUserAccount(block.header.coinbase.address)
+			assert.Nil(t, err)
+			coinbaseBefore := coinbaseAcc.Balance()
+
+			gasUsed, result, err := tt.tx.LocalExecution(block)
+
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.result, result)
+			assert.Equal(t, tt.gasUsed, gasUsed)
+
+			fromAcc, err = block.accState.GetOrCreateUserAccount(tt.tx.from.address)
+			assert.Nil(t, err)
+			assert.Equal(t, fromBefore, fromAcc.Balance())
+
+			toAcc, err = block.accState.GetOrCreateUserAccount(tt.tx.to.address)
+			assert.Nil(t, err)
+			assert.Equal(t, toBefore, toAcc.Balance())
+
+			coinbaseAcc, err = block.accState.GetOrCreateUserAccount(block.header.coinbase.address)
+			assert.Nil(t, err)
+			assert.Equal(t, coinbaseBefore, coinbaseAcc.Balance())
+		})
+	}
+}
+
+func TestTransaction_LocalExecutionCache(t *testing.T) {
+	bc := testNeb(t).chain
+	calls := 0
+	block := bc.tailBlock
+	block.nvm = &mockNvm{calls: &calls}
+
+	block.begin()
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	block.commit()
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	callTx := mockCallTransaction(bc.chainID, 2, "totalSupply", "")
+	callTx.from = deployTx.from
+	callTx.to = contract
+	signTransaction(t, callTx)
+
+	// cache disabled by default.
+	_, _, err = callTx.LocalExecution(block)
+	assert.Nil(t, err)
+	_, _, err = callTx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	assert.Nil(t, bc.EnableLocalExecutionCache(16))
+	calls = 0
+	gas, _, err := callTx.LocalExecution(block)
+	assert.Nil(t, err)
+	cachedGas, _, err := callTx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, gas, cachedGas)
+
+	// state root changes, execute again.
+	block.header.stateRoot = hash.Sha3256([]byte("new state"))
+	_, _, err = callTx.LocalExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func Test1(t *testing.T) {
+	fmt.Println(len(hash.Sha3256([]byte("abc"))))
+}
+
+func TestTransaction_Blacklist(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	blacklisted := mockAddress()
+
+	tests := []struct {
+		name      string
+		from      *Address
+		to        *Address
+		receivers bool
+		wanted    error
+	}{
+		{"blacklisted sender", blacklisted, mockAddress(), false, ErrAddressBlacklisted},
+		{"clean sender", mockAddress(), mockAddress(), false, nil},
+		{"blacklisted receiver not checked", mockAddress(), blacklisted, false, nil},
+		{"blacklisted receiver", mockAddress(), blacklisted, true, ErrAddressBlacklisted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := NewTransaction(bc.chainID, tt.from, tt.to, util.NewUint128FromUint(1), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+
+			block.begin()
+			defer block.rollback()
+			fromAcc, err := block.accState.GetOrCreateUserAccount(tt.from.address)
+			assert.Nil(t, err)
+			assert.Nil(t, fromAcc.AddBalance(balance))
+
+			SetTransactionBlacklist(bc.chainID, NewStaticAddressBlacklist(blacklisted), tt.receivers)
+			defer SetTransactionBlacklist(bc.chainID, nil, false)
+			blacklist, _ := TransactionBlacklistOf(bc.chainID + 1)
+			assert.Nil(t, blacklist)
+			_, err = tx.VerifyExecution(block)
+			assert.Equal(t, tt.wanted, err)
+
+			// no gas is charged for the rejected tx.
+			fromAcc, err = block.accState.GetOrCreateUserAccount(tt.from.address)
+			assert.Nil(t, err)
+			if tt.wanted != nil {
+				assert.Equal(t, balance.String(), fromAcc.Balance().String())
+			}
+		})
+	}
+}
+
+func TestTransaction_RevertReason(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+	fromAcc, err := block.accState.GetOrCreateUserAccount(deployTx.from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		callErr error
+		wanted  *RevertReason
+	}{
+		{"custom message", NewRevertReason(RevertCodeContractThrow, "Error: custom revert"), &RevertReason{Code: RevertCodeContractThrow, Message: "Error: custom revert"}},
+		{"out of gas", ErrInsufficientGas, &RevertReason{Code: RevertCodeOutOfGas, Message: ErrInsufficientGas.Error()}},
+		{"success", nil, nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := NewCallPayload("transfer", "").ToBytes()
+			assert.Nil(t, err)
+			tx, err := NewTransaction(bc.chainID, deployTx.from, contract, util.NewUint128(), uint64(i+2), TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+			assert.Nil(t, err)
+			signTransaction(t, tx)
+			nvm.callErr = tt.callErr
+			_, err = tx.VerifyExecution(block)
+			assert.Nil(t, err)
+
+			events, err := block.FetchEvents(tx.hash)
+			assert.Nil(t, err)
+			txEvent := TransactionEvent{}
+			assert.Nil(t, json.Unmarshal([]byte(events[len(events)-1].Data), &txEvent))
+			assert.Equal(t, tt.wanted, txEvent.Revert)
+		})
+	}
+}
+
+func TestTransaction_ReplayAt(t *testing.T) {
+	bc := testNeb(t).chain
+	from := mockAddress()
+	balance, _ := util.NewUint128FromString("1000000000000000000")
+
+	// the sender is funded in the parent block.
+	parent, err := bc.NewBlock(mockAddress())
+	assert.Nil(t, err)
+	parent.begin()
+	fromAcc, err := parent.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(balance))
+	parent.commit()
+	assert.Nil(t, parent.Seal())
+	assert.Nil(t, bc.StoreBlockToStorage(parent))
+
+	// the batch transfer fails only after the transfer before it in block.
+	half, _ := util.NewUint128FromString("500000000000000000")
+	transfer, err := NewTransaction(bc.chainID, from, mockAddress(), half, 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, transfer)
+	batch, err := NewBatchTransferPayload().AddOutput(mockAddress(), half).ToBytes()
+	assert.Nil(t, err)
+	failing, err := NewTransaction(bc.chainID, from, from, util.NewUint128(), 2, TxPayloadBatchTransferType, batch, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, failing)
+
+	coinbase := mockAddress()
+	block, err := NewBlock(bc.chainID, coinbase, parent)
+	assert.Nil(t, err)
+	block.transactions = append(block.transactions, transfer, failing)
+	assert.Nil(t, block.Seal())
+	assert.Nil(t, bc.StoreBlockToStorage(block))
+
+	_, _, err = failing.ReplayAt(bc, block.Hash())
+	assert.Equal(t, state.ErrBalanceInsufficient, err)
+	_, _, err = transfer.ReplayAt(bc, block.Hash())
+	assert.Nil(t, err)
+
+	// tx not in the block runs on the parent state, the coinbase is not rewarded.
+	outside, err := NewTransaction(bc.chainID, coinbase, mockAddress(), util.NewUint128FromUint(1), 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, outside)
+	_, _, err = outside.ReplayAt(bc, block.Hash())
+	assert.Equal(t, state.ErrBalanceInsufficient, err)
+
+	// replay never mutates the chain storage, it fails the same again.
+	_, _, err = failing.ReplayAt(bc, block.Hash())
+	assert.Equal(t, state.ErrBalanceInsufficient, err)
+	stored, err := LoadBlockFromStorage(parent.Hash(), bc)
+	assert.Nil(t, err)
+	fromAcc, err = stored.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), fromAcc.Nonce())
+	assert.Equal(t, balance.String(), fromAcc.Balance().String())
+
+	_, _, err = failing.ReplayAt(bc, hash.Sha3256([]byte("unknown")))
+	assert.Equal(t, ErrBlockNotFound, err)
+}
+
+func TestTransaction_GasCountOfTxBaseInPlace(t *testing.T) {
+	tx := mockDeployTransaction(0, 0)
+	tx.value = util.NewUint128FromUint(1000)
+
+	// the result matches the allocating arithmetic.
+	dataLen, _ := util.NewUint128FromInt(int64(tx.DataLen()))
+	dataGas, _ := dataLen.Mul(GasCountPerByte)
+	expectGas, _ := MinGasCountPerTransaction.Add(dataGas)
+	gas, err := tx.GasCountOfTxBase()
+	assert.Nil(t, err)
+	assert.Equal(t, expectGas.Bytes(), gas.Bytes())
+	assert.Equal(t, uint64(20000), MinGasCountPerTransaction.Uint64())
+
+	fee, _ := tx.gasPrice.Mul(tx.gasLimit)
+	expectBalance, _ := fee.Add(tx.value)
+	balance, err := tx.MinBalanceRequired()
+	assert.Nil(t, err)
+	assert.Equal(t, expectBalance.Bytes(), balance.Bytes())
+	assert.Equal(t, TransactionGasPrice.Bytes(), tx.gasPrice.Bytes())
+
+	max, _ := util.NewUint128FromString("340282366920938463463374607431768211455")
+	tx.value = max
+	_, err = tx.MinBalanceRequired()
+	assert.Equal(t, util.ErrUint128Overflow, err)
+}
+
+func BenchmarkTransaction_GasCountOfTxBase(b *testing.B) {
+	tx := mockDeployTransaction(0, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tx.GasCountOfTxBase()
+	}
+}
+
+func BenchmarkTransaction_MinBalanceRequired(b *testing.B) {
+	tx := mockNormalTransaction(0, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tx.MinBalanceRequired()
+	}
+}
+
+func TestTransaction_GaslessMode(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+	nvm := &mockNvm{}
+	block.nvm = nvm
+
+	SetGaslessMode(bc.chainID, true)
+	defer SetGaslessMode(bc.chainID, false)
+	assert.True(t, IsGaslessMode(bc.chainID))
+	assert.False(t, IsGaslessMode(bc.chainID+1))
+
+	balanceOf := func(addr *Address) *util.Uint128 {
+		balance, err := block.GetBalance(addr.address)
+		assert.Nil(t, err)
+		return balance
+	}
+	coinbaseBalance := balanceOf(block.Coinbase())
+
+	// the sender only holds the value, no gas fee is charged.
+	from, to := mockAddress(), mockAddress()
+	value := util.NewUint128FromUint(100)
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	assert.Nil(t, fromAcc.AddBalance(value))
+	transferTx, err := NewTransaction(bc.chainID, from, to, value, 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, transferTx)
+	assert.Nil(t, transferTx.PreCheck(block))
+	gasUsed, err := transferTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.True(t, gasUsed.Cmp(util.NewUint128()) > 0)
+	assert.Equal(t, util.NewUint128().Bytes(), balanceOf(from).Bytes())
+	assert.Equal(t, value.Bytes(), balanceOf(to).Bytes())
+	assert.Equal(t, coinbaseBalance.Bytes(), balanceOf(block.Coinbase()).Bytes())
+
+	// the contract deployer and caller hold nothing.
+	deployTx := mockDeployTransaction(bc.chainID, 1)
+	signTransaction(t, deployTx)
+	_, err = deployTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Nil(t, block.acceptTransaction(deployTx))
+	contract, err := deployTx.GenerateContractAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, util.NewUint128().Bytes(), balanceOf(deployTx.from).Bytes())
+
+	// instruction limits are still enforced.
+	nvm.instructions = TransactionMaxGas.Uint64() * 2
+	payload, err := NewCallPayload("transfer", "").ToBytes()
+	assert.Nil(t, err)
+	callTx, err := NewTransaction(bc.chainID, deployTx.from, contract, util.NewUint128(), 2, TxPayloadCallType, payload, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, callTx)
+	gasUsed, err = callTx.VerifyExecution(block)
+	assert.Nil(t, err)
+	assert.Equal(t, TransactionMaxGas.Bytes(), gasUsed.Bytes())
+	events, err := block.FetchEvents(callTx.hash)
+	assert.Nil(t, err)
+	txEvent := TransactionEvent{}
+	assert.Nil(t, json.Unmarshal([]byte(events[len(events)-1].Data), &txEvent))
+	assert.Equal(t, int8(TxExecutionFailed), txEvent.Status)
+	assert.Equal(t, util.NewUint128().Bytes(), balanceOf(deployTx.from).Bytes())
+	assert.Equal(t, coinbaseBalance.Bytes(), balanceOf(block.Coinbase()).Bytes())
+
+	// the fee is charged again once gasless mode is off.
+	SetGaslessMode(bc.chainID, false)
+	transferTx, err = NewTransaction(bc.chainID, to, from, value, 1, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	signTransaction(t, transferTx)
+	assert.Equal(t, ErrInsufficientBalance, transferTx.PreCheck(block))
+}
+
+func TestBuildTransaction(t *testing.T) {
+	bc := testNeb(t).chain
+	block := bc.tailBlock
+	block.begin()
+	defer block.rollback()
+
+	from, to := mockAddress(), mockAddress()
+	value := util.NewUint128FromUint(1)
+
+	// a fresh sender starts at nonce 1.
+	tx, err := BuildTransaction(bc, from, to, value, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), tx.Nonce())
+	assert.Equal(t, bc.ChainID(), tx.ChainID())
+
+	fromAcc, err := block.accState.GetOrCreateUserAccount(from.address)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		fromAcc.IncrNonce()
+	}
+	tx, err = BuildTransaction(bc, from, to, value, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), tx.Nonce())
+	assert.Equal(t, from, tx.From())
+	assert.Equal(t, to, tx.To())
+
+	_, err = BuildTransaction(nil, from, to, value, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Equal(t, ErrNilArgument, err)
+	_, err = BuildTransaction(bc, from, nil, value, TxPayloadBinaryType, nil, TransactionGasPrice, TransactionMaxGas)
+	assert.Equal(t, ErrInvalidArgument, err)
+}
+
+func TestTransaction_FromProtoInvalidAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  error
+		modify func(msg *corepb.Transaction)
+	}{
+		{"value", ErrInvalidTxValue, func(msg *corepb.Transaction) { msg.Value = []byte{0x01, 0x02, 0x03} }},
+		{"gasPrice", ErrInvalidTxGasPrice, func(msg *corepb.Transaction) { msg.GasPrice = []byte{0x01, 0x02, 0x03} }},
+		{"gasLimit", ErrInvalidTxGasLimit, func(msg *corepb.Transaction) { msg.GasLimit = make([]byte, 17) }},
+		{"maxFeePerGas", ErrInvalidTxMaxFeePerGas, func(msg *corepb.Transaction) { msg.MaxFeePerGas = []byte{0x01, 0x02, 0x03} }},
+		{"maxPriorityFeePerGas", ErrInvalidTxMaxPriorityFeePerGas, func(msg *corepb.Transaction) {
+			msg.MaxFeePerGas, msg.MaxPriorityFeePerGas = make([]byte, 16), make([]byte, 17)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := mockNormalTransaction(1, 1)
+			msg, err := tx.ToProto()
+			assert.Nil(t, err)
+			tt.modify(msg.(*corepb.Transaction))
+
+			err = new(Transaction).FromProto(msg)
+			fieldErr, ok := err.(*TxFieldError)
+			assert.True(t, ok)
+			assert.Equal(t, tt.field, fieldErr.Field)
+			assert.NotNil(t, fieldErr.Err)
+			assert.Contains(t, err.Error(), "invalid "+tt.name+": ")
+		})
+	}
+}
+
+func TestTransaction_MalleableSignature(t *testing.T) {
+	bc := testNeb(t).chain
+	tx := mockNormalTransaction(bc.chainID, 1)
+	signTransaction(t, tx)
+	assert.False(t, IsMalleableSignature(tx.alg, tx.sign))
+
+	// the twin signature [R || N-S || V^1] recovers the same signer.
+	high := append([]byte{}, tx.sign...)
+	s := new(big.Int).SetBytes(high[32:64])
+	s.Sub(secp256k1.S256().Params().N, s)
+	copy(high[32:64], make([]byte, 32))
+	sBytes := s.Bytes()
+	copy(high[64-len(sBytes):64], sBytes)
+	high[64] ^= 1
+	assert.True(t, IsMalleableSignature(keystore.SECP256K1, high))
+	assert.False(t, IsMalleableSignature(keystore.Algorithm(0), high))
+	malleated := *tx
+	malleated.sign = high
+	assert.Nil(t, malleated.VerifyIntegrity(bc.chainID))
+
+	// high-S signatures are accepted before the migration height.
+	defer SetLowSSignatureHeight(bc.chainID, 0)
+	assert.Nil(t, malleated.verifyLowS(100))
+	SetLowSSignatureHeight(bc.chainID, 10)
+	assert.Equal(t, uint64(0), LowSSignatureHeightOf(bc.chainID+1))
+	assert.Nil(t, malleated.verifyLowS(9))
+	assert.Equal(t, ErrMalleableSignature, malleated.verifyLowS(10))
+	assert.Nil(t, tx.verifyLowS(10))
+
+	// the tx pool checks the height of the next block.
+	SetLowSSignatureHeight(bc.chainID, bc.TailBlock().Height()+1)
+	assert.Equal(t, ErrMalleableSignature, bc.txPool.Push(&malleated))
+	assert.Nil(t, bc.txPool.Push(tx))
+}
+
+func TestTransaction_MempoolPriority(t *testing.T) {
+	now := time.Now().Unix()
+	newTx := func(gasPrice *util.Uint128, timestamp int64) *Transaction {
+		tx, err := NewTransaction(1, mockAddress(), mockAddress(), util.NewUint128(), 1, TxPayloadBinaryType, nil, gasPrice, TransactionMaxGas)
+		assert.Nil(t, err)
+		tx.timestamp = timestamp
+		return tx
+	}
+	highPrice, err := TransactionGasPrice.Mul(util.NewUint128FromUint(2))
+	assert.Nil(t, err)
+
+	base := newTx(TransactionGasPrice, now)
+	assert.Equal(t, float64(1000000), base.MempoolPriority(now))
+
+	// higher gas price, higher priority.
+	assert.True(t, newTx(highPrice, now).MempoolPriority(now) > base.MempoolPriority(now))
+
+	// older tx, higher priority.
+	older := newTx(TransactionGasPrice, now-60)
+	assert.True(t, older.MempoolPriority(now) > base.MempoolPriority(now))
+	assert.True(t, base.MempoolPriority(now+1) > base.MempoolPriority(now))
+
+	// tx from the future is as old as a new one.
+	assert.Equal(t, base.MempoolPriority(now), newTx(TransactionGasPrice, now+60).MempoolPriority(now))
+
+	// the weights trade gas price for age.
+	defer SetMempoolPriorityWeights(1, 0, 0)
+	SetMempoolPriorityWeights(1, DefaultMempoolPriorityGasPriceWeight, 0)
+	assert.Equal(t, base.MempoolPriority(now), older.MempoolPriority(now))
+	SetMempoolPriorityWeights(1, 0, 1)
+	assert.Equal(t, float64(60), older.MempoolPriority(now))
+
+	// the weights are of the chain of tx.
+	gasPriceWeight, ageWeight := MempoolPriorityWeightsOf(2)
+	assert.Equal(t, DefaultMempoolPriorityGasPriceWeight, gasPriceWeight)
+	assert.Equal(t, DefaultMempoolPriorityAgeWeight, ageWeight)
 }
@@ -0,0 +1,143 @@
+// Copyright (C) 2017 go-nebulas authors
+//
+// This file is part of the go-nebulas library.
+//
+// the go-nebulas library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// the go-nebulas library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with the go-nebulas library.  If not, see <http://www.gnu.org/licenses/>.
+//
+
+package core
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/nebulasio/go-nebulas/crypto/hash"
+	"github.com/nebulasio/go-nebulas/util/byteutils"
+)
+
+// typedDataPrefix prefix of the typed data hash, as "\x19\x01" in EIP-712.
+var typedDataPrefix = []byte{0x19, 0x01}
+
+// Default typed data domain of txs.
+const (
+	TypedDataDomainName    = "Nebulas"
+	TypedDataDomainVersion = "1"
+)
+
+// TypedDataDomain separate the typed data hash of different chains and apps,
+// so that the signed data cannot be replayed out of its domain.
+type TypedDataDomain struct {
+	Name    string
+	Version string
+	ChainID uint32
+}
+
+// DefaultTypedDataDomain the domain of typed txs on the chain
+func DefaultTypedDataDomain(chainID uint32) TypedDataDomain {
+	return TypedDataDomain{
+		Name:    TypedDataDomainName,
+		Version: TypedDataDomainVersion,
+		ChainID: chainID,
+	}
+}
+
+// Separator return the hash of domain
+func (domain TypedDataDomain) Separator() byteutils.Hash {
+	return hash.Sha3256(
+		hash.Sha3256([]byte("TypedDataDomain(string name,string version,uint32 chainId)")),
+		hash.Sha3256([]byte(domain.Name)),
+		hash.Sha3256([]byte(domain.Version)),
+		byteutils.FromUint32(domain.ChainID),
+	)
+}
+
+// TypedField a named field of typed data, the value is in the human-readable form shown to the signer.
+type TypedField struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// TypedData the structured data signed in the domain, wallets show the fields instead of an opaque hash.
+type TypedData struct {
+	Domain      TypedDataDomain
+	PrimaryType string
+	Fields      []*TypedField
+}
+
+// TypeString return the type of data, such as "Transaction(address from,address to)"
+func (data *TypedData) TypeString() string {
+	var buf bytes.Buffer
+	buf.WriteString(data.PrimaryType)
+	buf.WriteString("(")
+	for i, field := range data.Fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(field.Type)
+		buf.WriteString(" ")
+		buf.WriteString(field.Name)
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// Hash return the domain separated hash of data, sha3(prefix, domain separator, struct hash),
+// the struct hash is sha3 of the type hash and the hash of each field value.
+func (data *TypedData) Hash() byteutils.Hash {
+	fields := [][]byte{hash.Sha3256([]byte(data.TypeString()))}
+	for _, field := range data.Fields {
+		fields = append(fields, hash.Sha3256([]byte(field.Value)))
+	}
+	structHash := hash.Sha3256(fields...)
+	return hash.Sha3256(typedDataPrefix, data.Domain.Separator(), structHash)
+}
+
+// TypedData return the typed data of tx in domain. The fields not listed are covered by
+// the digest of the signing preimage, so that every signed field of tx is bound.
+func (tx *Transaction) TypedData(domain TypedDataDomain) (*TypedData, error) {
+	preimage, err := tx.SigningPreimage()
+	if err != nil {
+		return nil, err
+	}
+	payloadType, payload := "", []byte(nil)
+	if tx.data != nil {
+		payloadType, payload = tx.data.Type, tx.data.Payload
+	}
+	return &TypedData{
+		Domain:      domain,
+		PrimaryType: "Transaction",
+		Fields: []*TypedField{
+			{Name: "from", Type: "address", Value: tx.from.String()},
+			{Name: "to", Type: "address", Value: tx.to.String()},
+			{Name: "value", Type: "uint128", Value: tx.value.String()},
+			{Name: "nonce", Type: "uint64", Value: strconv.FormatUint(tx.nonce, 10)},
+			{Name: "timestamp", Type: "int64", Value: strconv.FormatInt(tx.timestamp, 10)},
+			{Name: "gasPrice", Type: "uint128", Value: tx.gasPrice.String()},
+			{Name: "gasLimit", Type: "uint128", Value: tx.gasLimit.String()},
+			{Name: "type", Type: "string", Value: payloadType},
+			{Name: "payload", Type: "bytes", Value: byteutils.Hex(payload)},
+			{Name: "digest", Type: "bytes32", Value: byteutils.Hex(hash.Sha3256(preimage))},
+		},
+	}, nil
+}
+
+// HashTypedTransaction return the domain separated hash of the typed data of tx.
+func HashTypedTransaction(tx *Transaction, domain TypedDataDomain) (byteutils.Hash, error) {
+	data, err := tx.TypedData(domain)
+	if err != nil {
+		return nil, err
+	}
+	return data.Hash(), nil
+}
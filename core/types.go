@@ -19,6 +19,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -34,11 +35,24 @@ import (
 
 // Payload Types
 const (
-	TxPayloadBinaryType = "binary"
-	TxPayloadDeployType = "deploy"
-	TxPayloadCallType   = "call"
+	TxPayloadBinaryType        = "binary"
+	TxPayloadDeployType        = "deploy"
+	TxPayloadCallType          = "call"
+	TxPayloadBatchTransferType = "batch"
 )
 
+// ContractReceiveFunction the optional contract hook called when value is transferred to the contract,
+// the transfer is rejected if the hook throws. The hook is never called by a call tx.
+const ContractReceiveFunction = "__nebulas_receive"
+
+// FeeTokenTransferFunction the function of fee token contract called to pay gas fee, as transfer(to, value) of NRC20.
+const FeeTokenTransferFunction = "transfer"
+
+// ContractNonceFunction the wallet contract hook validating the nonce of nonceless txs sent to it, called with (from, nonce).
+// The hook is called read-only and always charged ContractNonceGasLimit, the tx fails if the hook throws.
+// The called function of the wallet is responsible to mark the nonce used against replay.
+const ContractNonceFunction = "__nebulas_validate_nonce"
+
 const (
 	// TxExecutionFailed failed status for transaction execute result.
 	TxExecutionFailed = 0
@@ -48,6 +62,36 @@ const (
 
 	// TxExecutionPendding pendding status when transaction in transaction pool.
 	TxExecutionPendding = 2
+
+	// TxExecutionConditionNotMet status for conditional transaction skipped as its condition is not met.
+	TxExecutionConditionNotMet = 3
+)
+
+// Revert codes of the failed transaction execution, carried in the RevertReason of the result event.
+const (
+	// RevertCodeUnknown the failure is not classified.
+	RevertCodeUnknown = 0
+
+	// RevertCodeContractThrow the contract threw an error.
+	RevertCodeContractThrow = 1
+
+	// RevertCodeOutOfGas the execution ran out of gas.
+	RevertCodeOutOfGas = 2
+
+	// RevertCodeDeadlineExceeded the execution was aborted by the deadline.
+	RevertCodeDeadlineExceeded = 3
+
+	// RevertCodeReadOnlyViolation the read-only call mutated the state.
+	RevertCodeReadOnlyViolation = 4
+
+	// RevertCodeRejectedValue the contract rejected the transferred value.
+	RevertCodeRejectedValue = 5
+
+	// RevertCodeInsufficientBalance the balance is not enough for the value transferred.
+	RevertCodeInsufficientBalance = 6
+
+	// RevertCodeEventBufferFull the contract events exceeded the event buffer of the block.
+	RevertCodeEventBufferFull = 8
 )
 
 // Error Types
@@ -65,60 +109,134 @@ var (
 	ErrGenesisNotEqualTokenInDB                          = errors.New("Failed to check. genesis TokenDistribution not equal in db")
 	ErrGenesisNotEqualDynastyLenInDB                     = errors.New("Failed to check. genesis dynasty length not equal in db")
 	ErrGenesisNotEqualTokenLenInDB                       = errors.New("Failed to check. genesis TokenDistribution length not equal in db")
+	ErrGenesisNotEqualStakeInDB                          = errors.New("Failed to check. genesis dynasty stake not equal in db")
+	ErrGenesisNotEqualStakeLenInDB                       = errors.New("Failed to check. genesis dynasty stake length not equal in db")
+	ErrGenesisInvalidChainID                             = errors.New("genesis chainID should not be zero")
+	ErrGenesisEmptyDynasty                               = errors.New("genesis dynasty should not be empty")
+	ErrGenesisInvalidTokenAddress                        = errors.New("invalid address in genesis token distribution")
+	ErrGenesisInvalidTokenValue                          = errors.New("invalid value in genesis token distribution")
+	ErrGenesisStakeExceedDistribution                    = errors.New("genesis dynasty stake exceeds the token distribution of the validator")
+	ErrGenesisStateRootMismatch                          = errors.New("stored genesis state root not match the expected genesis")
+	ErrGenesisTxsRootMismatch                            = errors.New("stored genesis txs root not match the expected genesis")
+	ErrGenesisConsensusRootMismatch                      = errors.New("stored genesis consensus root not match the expected genesis")
+	ErrGenesisMalformedTokenCSV                          = errors.New("malformed line in genesis token distribution csv")
+	ErrGenesisTokenValueOverflow                         = errors.New("genesis token distribution value overflow")
+	ErrCoinbaseInDistribution                            = errors.New("genesis coinbase cannot be in genesis token distribution")
 
 	ErrLinkToWrongParentBlock = errors.New("link the block to a block who is not its parent")
 	ErrMissingParentBlock     = errors.New("cannot find the block's parent block in storage")
 	ErrInvalidBlockHash       = errors.New("invalid block hash")
 	ErrDuplicatedBlock        = errors.New("duplicated block")
+	ErrBlockOutOfGas          = errors.New("block gas limit exceeded")
 
 	ErrInvalidChainID           = errors.New("invalid transaction chainID")
 	ErrInvalidTransactionSigner = errors.New("transaction recover public key address not equal to from")
 	ErrInvalidTransactionHash   = errors.New("invalid transaction hash")
 	ErrInvalidTxPayloadType     = errors.New("invalid transaction data payload type")
+	ErrPayloadTypeMismatch      = errors.New("transaction data payload does not match its type")
+	ErrInvalidMemoHMACPayload   = errors.New("binary payload does not carry a memo hmac")
+
+	ErrUnsupportedSignatureAlgorithm   = errors.New("unsupported signature algorithm")
+	ErrTransactionSigned               = errors.New("transaction is already signed")
+	ErrUnsupportedTxHasher             = errors.New("unsupported transaction hasher")
+	ErrTransactionNotYetValid          = errors.New("transaction is not yet valid before its notBefore time")
+	ErrTransactionExpired              = errors.New("transaction is expired after its expiry time")
+	ErrTransactionTimestampTooFarAhead = errors.New("transaction timestamp is too far ahead of block timestamp")
+	ErrFeeTokenTransferFailed          = errors.New("failed to transfer gas fee in fee token")
+	ErrMaxFeeBelowBaseFee              = errors.New("transaction max fee per gas is below block base fee")
+
+	ErrInvalidTxValue                = errors.New("invalid value")
+	ErrInvalidTxGasPrice             = errors.New("invalid gasPrice")
+	ErrInvalidTxGasLimit             = errors.New("invalid gasLimit")
+	ErrInvalidTxMaxFeePerGas         = errors.New("invalid maxFeePerGas")
+	ErrInvalidTxMaxPriorityFeePerGas = errors.New("invalid maxPriorityFeePerGas")
 
 	ErrInsufficientBalance                = errors.New("insufficient balance")
 	ErrBelowGasPrice                      = errors.New("below the gas price")
 	ErrGasLimitLessOrEqualToZero          = errors.New("gas limit less or equal to 0")
 	ErrOutOfGasLimit                      = errors.New("out of gas limit")
 	ErrContractCheckFailed                = errors.New("contract check failed")
+	ErrTransferToReservedAddress          = errors.New("cannot transfer value to reserved address")
+	ErrNoOpTransaction                    = errors.New("transaction to self with zero value and empty payload is a no-op")
+	ErrVerificationBudgetExceeded         = errors.New("verification failures of the source exceed the budget")
+	ErrTransactionResultEventNotFound     = errors.New("transaction result event not found")
+	ErrTransactionReceiptNotFound         = errors.New("transaction receipt not found")
+	ErrInvalidReceiptProof                = errors.New("invalid transaction receipt proof")
+	ErrContractRejectedValue              = errors.New("contract rejected the transferred value")
+	ErrReceiveHookNotCallable             = errors.New("receive hook is only called on value transfer")
+	ErrContractNonceRejected              = errors.New("contract rejected the nonce of nonceless transaction")
+	ErrUnauthorizedUpgrade                = errors.New("only the contract owner can upgrade the contract")
+	ErrContractNotFound                   = errors.New("contract not found")
+	ErrContractAddressCollision           = errors.New("contract already exists at the generated address")
+	ErrConditionNotMet                    = errors.New("transaction condition not met")
+	ErrEmptyContractSource                = errors.New("contract source is empty")
+	ErrContractSourceTooLarge             = errors.New("decompressed contract source is too large")
+	ErrUnsupportedCompression             = errors.New("unsupported contract source compression")
+	ErrInvalidCallFunction                = errors.New("call function is not a valid identifier")
+	ErrInvalidCallArgument                = errors.New("call argument cannot be encoded")
+	ErrInvalidCallArgs                    = errors.New("call args is not a json array")
+	ErrReadOnlyViolation                  = errors.New("state mutation is not allowed in read-only call")
+	ErrExecutionDeadlineExceeded          = errors.New("contract execution is aborted as the deadline exceeded")
+	ErrInsufficientGas                    = errors.New("insufficient gas")
 	ErrContractTransactionAddressNotEqual = errors.New("contract transaction from-address not equal to to-address")
+	ErrEmptyBatchTransfer                 = errors.New("batch transfer has no output")
+	ErrTooManyBatchTransferOutputs        = errors.New("batch transfer has too many outputs")
+	ErrBatchTransferToContract            = errors.New("batch transfer cannot send value to contract")
+	ErrAddressBlacklisted                 = errors.New("address is blacklisted")
+	ErrEventBufferFull                    = errors.New("contract events exceed the event buffer of block")
+	ErrMalleableSignature                 = errors.New("malleable signature with high S")
 
 	ErrDuplicatedTransaction = errors.New("duplicated transaction")
 	ErrSmallTransactionNonce = errors.New("cannot accept a transaction with smaller nonce")
 	ErrLargeTransactionNonce = errors.New("cannot accept a transaction with too bigger nonce")
+	ErrNonceNotContiguous    = errors.New("nonces of the sender are not contiguous")
 
 	ErrInvalidAddress           = errors.New("address: invalid address")
 	ErrInvalidAddressDataLength = errors.New("address: invalid address data length")
+	ErrWrongNetworkAddress      = errors.New("address: address of wrong network")
+	ErrAmbiguousNetworkPrefix   = errors.New("address: ambiguous network prefix")
 
 	ErrCloneWorldState           = errors.New("Failed to clone world state")
 	ErrCloneAccountState         = errors.New("Failed to clone account state")
 	ErrCloneTxsState             = errors.New("Failed to clone txs state")
 	ErrCloneEventsState          = errors.New("Failed to clone events state")
+	ErrCloneReceiptsState        = errors.New("Failed to clone receipts state")
 	ErrInvalidBlockStateRoot     = errors.New("invalid block state root hash")
 	ErrInvalidBlockTxsRoot       = errors.New("invalid block txs root hash")
+	ErrTxsRootMismatch           = errors.New("txs root recomputed from block txs not match the header")
 	ErrInvalidBlockEventsRoot    = errors.New("invalid block events root hash")
+	ErrInvalidBlockReceiptsRoot  = errors.New("invalid block receipts root hash")
 	ErrInvalidBlockConsensusRoot = errors.New("invalid block consensus root hash")
 	ErrInvalidProtoToBlock       = errors.New("protobuf message cannot be converted into Block")
 	ErrInvalidProtoToBlockHeader = errors.New("protobuf message cannot be converted into BlockHeader")
 	ErrInvalidBlockToProto       = errors.New("block cannot be converted into proto")
 
+	ErrBlockNotFound       = errors.New("block not found")
 	ErrCannotRevertLIB     = errors.New("cannot revert latest irreversible block")
 	ErrCannotLoadTailBlock = errors.New("cannot load latest irreversible block from storage")
 
 	ErrNoTimeToPackTransactions    = errors.New("no time left to pack transactions in a block")
+	ErrGasAccumulationOverflow     = errors.New("accumulated gas used of block overflows")
 	ErrTxDataPayLoadOutOfMaxLength = errors.New("data's payload is out of max data length")
 	ErrNilArgument                 = errors.New("argument(s) is nil")
 	ErrInvalidArgument             = errors.New("invalid argument(s)")
 
-	ErrInvalidTransactionData   = errors.New("invalid data in tx from Proto")
-	ErrCannotConvertTransaction = errors.New("proto message cannot be converted into Transaction")
+	ErrInvalidTransactionData    = errors.New("invalid data in tx from Proto")
+	ErrCannotConvertTransaction  = errors.New("proto message cannot be converted into Transaction")
+	ErrInvalidCompactTransaction = errors.New("invalid compact transaction string")
 )
 
 // TxPayload stored in tx
 type TxPayload interface {
 	ToBytes() ([]byte, error)
 	BaseGasCount() *util.Uint128
-	Execute(block *Block, tx *Transaction) (*util.Uint128, string, error)
+	Execute(ctx context.Context, block *Block, tx *Transaction) (*util.Uint128, string, error)
+}
+
+// AddressBlacklist the addresses which txs are rejected from, it is consulted with the block
+// being executed, so that it can be backed by a file or the state of a governance contract.
+type AddressBlacklist interface {
+	IsBlacklisted(block *Block, addr *Address) bool
 }
 
 // MessageType
@@ -185,9 +303,18 @@ type AccountManager interface {
 type Engine interface {
 	CreateEngine(block *Block, tx *Transaction, owner, contract state.Account, state state.AccountState) error
 	SetEngineExecutionLimits(limitsOfExecutionInstructions uint64) error
+	SetEngineExecutionContext(ctx context.Context) error
 	DeployAndInitEngine(source, sourceType, args string) (string, error)
 	CallEngine(source, sourceType, function, args string) (string, error)
+	ReceiveEngine(source, sourceType string) (string, error)
+	LoadEngine(source, sourceType string) (string, error)
 	ExecutionInstructions() (uint64, error)
+	StorageBytesWritten() (uint64, error)
+	Savepoint(name string) error
+	RollbackToSavepoint(name string) error
+	SetEngineReadOnly(readOnly bool) error
+	SetEngineStorageReadTrace(enabled bool) error
+	StorageKeysRead() ([]string, error)
 	DisposeEngine()
 	Clone() Engine
 }
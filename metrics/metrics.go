@@ -151,6 +151,11 @@ func NewGauge(name string) metrics.Gauge {
 	return metrics.GetOrRegisterGauge(name, metrics.DefaultRegistry)
 }
 
+// Unregister remove the metric of the name from registry
+func Unregister(name string) {
+	metrics.DefaultRegistry.Unregister(name)
+}
+
 // NewHistogramWithUniformSample create a new metrics History with Uniform Sample algorithm.
 func NewHistogramWithUniformSample(name string, reservoirSize int) metrics.Histogram {
 	if !enable {
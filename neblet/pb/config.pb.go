@@ -186,6 +186,33 @@ type ChainConfig struct {
 	GasLimit string `protobuf:"bytes,25,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit"`
 	// Supported signature cipher list. ["ECC_SECP256K1"]
 	SignatureCiphers []string `protobuf:"bytes,26,rep,name=signature_ciphers,json=signatureCiphers" json:"signature_ciphers"`
+	// Transaction hash algorithm. ["SHA3256", "SHA256"], default is SHA3256.
+	TxHasher string `protobuf:"bytes,27,opt,name=tx_hasher,json=txHasher,proto3" json:"tx_hasher"`
+	// Zero-fee execution for permissioned chains, txs are not charged any gas fee.
+	GaslessMode bool `protobuf:"varint,28,opt,name=gasless_mode,json=gaslessMode,proto3" json:"gasless_mode"`
+	// Reject the binary txs sent to self with zero value and empty payload.
+	RejectNoopTransfers bool `protobuf:"varint,29,opt,name=reject_noop_transfers,json=rejectNoopTransfers,proto3" json:"reject_noop_transfers"`
+	// Addresses which txs are rejected from.
+	Blacklist []string `protobuf:"bytes,30,rep,name=blacklist" json:"blacklist"`
+	// Reject txs to the blacklisted addresses as well.
+	BlacklistReceivers bool `protobuf:"varint,31,opt,name=blacklist_receivers,json=blacklistReceivers,proto3" json:"blacklist_receivers"`
+	// Height from which high-S secp256k1 signatures are rejected, 0 means never.
+	LowSSignatureHeight uint64 `protobuf:"varint,32,opt,name=low_s_signature_height,json=lowSSignatureHeight,proto3" json:"low_s_signature_height"`
+	// Weights of gasPrice and tx age in seconds in mempool priority, both 0 means the default 1 and 10000.
+	MempoolPriorityGasPriceWeight float64 `protobuf:"fixed64,33,opt,name=mempool_priority_gas_price_weight,json=mempoolPriorityGasPriceWeight,proto3" json:"mempool_priority_gas_price_weight"`
+	MempoolPriorityAgeWeight      float64 `protobuf:"fixed64,34,opt,name=mempool_priority_age_weight,json=mempoolPriorityAgeWeight,proto3" json:"mempool_priority_age_weight"`
+	// Max contract events and their bytes recorded in a block, 0 means the default 100000 and 32MiB.
+	MaxEventsPerBlock     uint32 `protobuf:"varint,35,opt,name=max_events_per_block,json=maxEventsPerBlock,proto3" json:"max_events_per_block"`
+	MaxEventBytesPerBlock uint32 `protobuf:"varint,36,opt,name=max_event_bytes_per_block,json=maxEventBytesPerBlock,proto3" json:"max_event_bytes_per_block"`
+	// Max gas any single call to the contract consumes. ["<contract address>:<gas>"]
+	ContractGasCaps []string `protobuf:"bytes,37,rep,name=contract_gas_caps,json=contractGasCaps" json:"contract_gas_caps"`
+	// Max seconds of tx timestamp ahead of block timestamp, 0 means not checked.
+	MaxTxTimestampDrift uint64 `protobuf:"varint,38,opt,name=max_tx_timestamp_drift,json=maxTxTimestampDrift,proto3" json:"max_tx_timestamp_drift"`
+	// Addresses which txs cannot transfer value to, with the zero address, from the height on, 0 means never.
+	ReservedAddresses       []string `protobuf:"bytes,39,rep,name=reserved_addresses,json=reservedAddresses" json:"reserved_addresses"`
+	ReservedAddressesHeight uint64   `protobuf:"varint,40,opt,name=reserved_addresses_height,json=reservedAddressesHeight,proto3" json:"reserved_addresses_height"`
+	// Human-readable address prefix of the chain, mixed into the address checksum, empty means the legacy address.
+	AddressPrefix string `protobuf:"bytes,41,opt,name=address_prefix,json=addressPrefix,proto3" json:"address_prefix"`
 }
 
 func (m *ChainConfig) Reset()                    { *m = ChainConfig{} }
@@ -270,6 +297,111 @@ func (m *ChainConfig) GetSignatureCiphers() []string {
 	return nil
 }
 
+func (m *ChainConfig) GetTxHasher() string {
+	if m != nil {
+		return m.TxHasher
+	}
+	return ""
+}
+
+func (m *ChainConfig) GetGaslessMode() bool {
+	if m != nil {
+		return m.GaslessMode
+	}
+	return false
+}
+
+func (m *ChainConfig) GetRejectNoopTransfers() bool {
+	if m != nil {
+		return m.RejectNoopTransfers
+	}
+	return false
+}
+
+func (m *ChainConfig) GetBlacklist() []string {
+	if m != nil {
+		return m.Blacklist
+	}
+	return nil
+}
+
+func (m *ChainConfig) GetBlacklistReceivers() bool {
+	if m != nil {
+		return m.BlacklistReceivers
+	}
+	return false
+}
+
+func (m *ChainConfig) GetLowSSignatureHeight() uint64 {
+	if m != nil {
+		return m.LowSSignatureHeight
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetMempoolPriorityGasPriceWeight() float64 {
+	if m != nil {
+		return m.MempoolPriorityGasPriceWeight
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetMempoolPriorityAgeWeight() float64 {
+	if m != nil {
+		return m.MempoolPriorityAgeWeight
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetMaxEventsPerBlock() uint32 {
+	if m != nil {
+		return m.MaxEventsPerBlock
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetMaxEventBytesPerBlock() uint32 {
+	if m != nil {
+		return m.MaxEventBytesPerBlock
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetContractGasCaps() []string {
+	if m != nil {
+		return m.ContractGasCaps
+	}
+	return nil
+}
+
+func (m *ChainConfig) GetMaxTxTimestampDrift() uint64 {
+	if m != nil {
+		return m.MaxTxTimestampDrift
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetReservedAddresses() []string {
+	if m != nil {
+		return m.ReservedAddresses
+	}
+	return nil
+}
+
+func (m *ChainConfig) GetReservedAddressesHeight() uint64 {
+	if m != nil {
+		return m.ReservedAddressesHeight
+	}
+	return 0
+}
+
+func (m *ChainConfig) GetAddressPrefix() string {
+	if m != nil {
+		return m.AddressPrefix
+	}
+	return ""
+}
+
 type RPCConfig struct {
 	// RPC listen addresses.
 	RpcListen []string `protobuf:"bytes,1,rep,name=rpc_listen,json=rpcListen" json:"rpc_listen"`
@@ -542,59 +674,80 @@ func init() {
 func init() { proto.RegisterFile("config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 858 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x55, 0x5f, 0x6f, 0xdb, 0x36,
-	0x10, 0x9f, 0x9d, 0x7f, 0xd6, 0xd9, 0x71, 0x5d, 0x36, 0x6d, 0xd8, 0x16, 0x6b, 0x33, 0x01, 0x01,
-	0x0c, 0x14, 0x30, 0xb0, 0x6c, 0xaf, 0x7b, 0x28, 0x0c, 0x0c, 0x08, 0x92, 0x0c, 0x81, 0xb6, 0x3d,
-	0x0b, 0xb4, 0x74, 0x96, 0x89, 0xc8, 0x12, 0x41, 0xd2, 0x69, 0x83, 0xbd, 0xec, 0x0b, 0xec, 0x03,
-	0xec, 0x3b, 0xed, 0x2b, 0x0d, 0x18, 0xee, 0x44, 0x59, 0x8e, 0xb1, 0x37, 0xdd, 0xef, 0xf7, 0xe3,
-	0x91, 0xbc, 0xfb, 0x1d, 0x05, 0xa3, 0xac, 0xae, 0x96, 0xba, 0x98, 0x19, 0x5b, 0xfb, 0x5a, 0x0c,
-	0x2a, 0x5c, 0x94, 0xe8, 0xcd, 0x22, 0xfe, 0xab, 0x0f, 0xc7, 0x73, 0xa6, 0xc4, 0xf7, 0x70, 0x52,
-	0xa1, 0xff, 0x52, 0xdb, 0x07, 0xd9, 0xbb, 0xe8, 0x4d, 0x87, 0x57, 0xe7, 0xb3, 0x56, 0x36, 0xfb,
-	0xa5, 0x21, 0x1a, 0x65, 0xd2, 0xea, 0xc4, 0x27, 0x38, 0xca, 0x56, 0x4a, 0x57, 0xb2, 0xcf, 0x0b,
-	0x5e, 0x77, 0x0b, 0xe6, 0x04, 0x07, 0x79, 0xa3, 0x11, 0x97, 0x70, 0x60, 0x4d, 0x26, 0x0f, 0x58,
-	0xfa, 0xaa, 0x93, 0x26, 0xf7, 0xf3, 0x20, 0x24, 0x9e, 0x72, 0x3a, 0xaf, 0xbc, 0x93, 0xf9, 0x7e,
-	0xce, 0x5f, 0x09, 0x6e, 0x73, 0xb2, 0x46, 0x4c, 0xe1, 0x70, 0xad, 0x5d, 0x26, 0x91, 0xb5, 0x67,
-	0x9d, 0xf6, 0x4e, 0xbb, 0x2c, 0x48, 0x59, 0x41, 0xbb, 0x2b, 0x63, 0xe4, 0x72, 0x7f, 0xf7, 0xcf,
-	0xc6, 0xb4, 0xbb, 0x2b, 0x63, 0xe2, 0x3f, 0xe0, 0xf4, 0xd9, 0x5d, 0x85, 0x80, 0x43, 0x87, 0x98,
-	0xcb, 0xde, 0xc5, 0xc1, 0x34, 0x4a, 0xf8, 0x5b, 0xbc, 0x81, 0xe3, 0x52, 0x3b, 0x8f, 0x74, 0x6f,
-	0x42, 0x43, 0x24, 0x3e, 0xc2, 0xd0, 0x58, 0xfd, 0xa8, 0x3c, 0xa6, 0x0f, 0xf8, 0xc4, 0x37, 0x8d,
-	0x12, 0x08, 0xd0, 0x0d, 0x3e, 0x89, 0x6f, 0x01, 0x42, 0xe9, 0x52, 0x9d, 0xcb, 0xc3, 0x8b, 0xde,
-	0xf4, 0x34, 0x89, 0x02, 0x72, 0x9d, 0xc7, 0xff, 0xf4, 0x61, 0xb8, 0x53, 0x38, 0xf1, 0x16, 0x06,
-	0x5c, 0x3a, 0x12, 0xf7, 0x58, 0x7c, 0xc2, 0xf1, 0x75, 0x2e, 0x24, 0x9c, 0x14, 0x58, 0xa1, 0xd3,
-	0x8e, 0x6b, 0x1f, 0x25, 0x6d, 0x48, 0x4c, 0xae, 0xbc, 0xca, 0xb5, 0x95, 0xc3, 0x86, 0x09, 0x21,
-	0x1d, 0xfb, 0x01, 0x9f, 0x88, 0x18, 0x31, 0x11, 0x22, 0x3a, 0x95, 0xf3, 0xca, 0xfa, 0x74, 0xad,
-	0x2b, 0x94, 0x67, 0x17, 0xbd, 0xe9, 0x20, 0x89, 0x18, 0xb9, 0xd3, 0x15, 0x8a, 0x77, 0x30, 0xc8,
-	0x6a, 0x5d, 0x2d, 0x94, 0x43, 0xf9, 0x9a, 0x17, 0x6e, 0x63, 0x71, 0x06, 0x47, 0xb4, 0xc8, 0xca,
-	0x37, 0x4c, 0x34, 0x81, 0xf8, 0x00, 0x60, 0x94, 0x73, 0x66, 0x65, 0x69, 0xcd, 0x79, 0x28, 0xc3,
-	0x16, 0x11, 0xef, 0x21, 0x2a, 0x94, 0x4b, 0x8d, 0xd5, 0x19, 0x4a, 0xd9, 0xa4, 0x2c, 0x94, 0xbb,
-	0xa7, 0xb8, 0x25, 0x4b, 0xbd, 0xd6, 0x5e, 0xbe, 0xdd, 0x92, 0xb7, 0x14, 0x8b, 0x4f, 0xf0, 0xd2,
-	0xe9, 0xa2, 0x52, 0x7e, 0x63, 0x31, 0xcd, 0xb4, 0x59, 0xa1, 0x75, 0xf2, 0x1d, 0x37, 0x61, 0xb2,
-	0x25, 0xe6, 0x0d, 0x1e, 0xff, 0xdd, 0x83, 0x68, 0x6b, 0x2e, 0xba, 0xa5, 0x35, 0x59, 0x1a, 0x1a,
-	0xd7, 0xb4, 0x33, 0xb2, 0x26, 0xbb, 0xdd, 0xf6, 0x6e, 0xe5, 0xbd, 0x49, 0x9f, 0x35, 0x16, 0x08,
-	0xda, 0x13, 0xac, 0xeb, 0x7c, 0x53, 0xa2, 0x3c, 0xe8, 0x04, 0x77, 0x8c, 0xd0, 0xd9, 0xb2, 0xba,
-	0xaa, 0x30, 0xf3, 0xba, 0xae, 0x9a, 0xf3, 0x3b, 0xee, 0xf1, 0x51, 0x32, 0xe9, 0x08, 0xbe, 0x87,
-	0x8b, 0xff, 0xed, 0x41, 0xb4, 0xb5, 0x1e, 0xdd, 0xb9, 0xac, 0x8b, 0xb4, 0xc4, 0x47, 0x2c, 0xb9,
-	0xd3, 0x51, 0x32, 0x28, 0xeb, 0xe2, 0x96, 0x62, 0x72, 0x01, 0x91, 0x4b, 0x5d, 0x62, 0xdb, 0xeb,
-	0xb2, 0x2e, 0x7e, 0xd6, 0x25, 0x8a, 0x73, 0xa0, 0xcf, 0x54, 0x15, 0xc8, 0x66, 0x3b, 0x4d, 0x8e,
-	0xcb, 0xba, 0xf8, 0x5c, 0xa0, 0x98, 0xc1, 0x2b, 0xac, 0xd4, 0xa2, 0xc4, 0x34, 0xb3, 0xca, 0xad,
-	0x52, 0x8b, 0xa6, 0xb6, 0x9e, 0x4f, 0x33, 0x48, 0x5e, 0x36, 0xd4, 0x9c, 0x98, 0x84, 0x09, 0x31,
-	0x85, 0xc9, 0xae, 0x30, 0xdd, 0xd8, 0x52, 0x1e, 0xf1, 0x5e, 0xe3, 0xac, 0x93, 0xfd, 0x6e, 0x4b,
-	0x1a, 0x4f, 0x63, 0x6c, 0xbd, 0x94, 0xc7, 0xfb, 0xe3, 0x79, 0x4f, 0x70, 0x3b, 0x9e, 0xac, 0x21,
-	0x2f, 0x3e, 0xa2, 0x75, 0xba, 0xae, 0x78, 0x9a, 0xa3, 0xa4, 0x0d, 0xe3, 0x0a, 0x86, 0x3b, 0xfa,
-	0xfd, 0xea, 0x37, 0x25, 0xd8, 0xad, 0xfe, 0x07, 0x80, 0xcc, 0x6c, 0x68, 0x45, 0x57, 0x86, 0x1d,
-	0x84, 0xf8, 0x35, 0xae, 0x5b, 0x3e, 0x4c, 0x5e, 0x87, 0xc4, 0x37, 0x00, 0xdd, 0x93, 0x20, 0x7e,
-	0x82, 0xf7, 0x39, 0x2e, 0xd5, 0xa6, 0xf4, 0x34, 0xa8, 0xce, 0xd7, 0x16, 0xb9, 0xbe, 0x64, 0x29,
-	0xb4, 0x61, 0x7b, 0x19, 0x24, 0x37, 0x41, 0x41, 0x15, 0x9f, 0x13, 0x1f, 0xff, 0xd9, 0x87, 0xe1,
-	0xce, 0x63, 0x24, 0x2e, 0x61, 0x1c, 0xaa, 0xbd, 0x46, 0x6f, 0x75, 0xe6, 0x38, 0xc3, 0x20, 0x39,
-	0x6d, 0xd0, 0xbb, 0x06, 0x14, 0xf7, 0x30, 0x69, 0xca, 0xab, 0xab, 0xa2, 0xb5, 0x11, 0xf9, 0x6c,
-	0x7c, 0x75, 0xf9, 0xbf, 0x8f, 0xdc, 0x2c, 0x69, 0xd5, 0x8d, 0xc3, 0x92, 0x17, 0xf6, 0x39, 0x20,
-	0x7e, 0x84, 0x81, 0xae, 0x96, 0xe5, 0xe6, 0x6b, 0xbe, 0xe0, 0x61, 0x1f, 0x5e, 0xc9, 0x2e, 0xd3,
-	0x75, 0x60, 0x42, 0x4b, 0xb6, 0x4a, 0xf1, 0x1d, 0x8c, 0xc2, 0x39, 0x53, 0xaf, 0x0a, 0x27, 0x47,
-	0x6c, 0xe5, 0x61, 0xc0, 0x7e, 0x53, 0x85, 0x8b, 0x3f, 0xc2, 0x8b, 0xbd, 0xcd, 0xc5, 0x08, 0x06,
-	0x6d, 0xc6, 0xc9, 0x37, 0xf1, 0x57, 0x18, 0x3f, 0xcf, 0x4f, 0x0f, 0xe5, 0xaa, 0x76, 0x3e, 0x14,
-	0x8f, 0xbf, 0x09, 0x63, 0xdf, 0xf5, 0xd9, 0x9c, 0xfc, 0x2d, 0xc6, 0xd0, 0xcf, 0x17, 0xa1, 0x43,
-	0xfd, 0x7c, 0x41, 0x9a, 0x8d, 0x43, 0xcb, 0xde, 0x8c, 0x12, 0xfe, 0xa6, 0x27, 0x87, 0x9e, 0x8b,
-	0x2f, 0xb5, 0xcd, 0x83, 0x0d, 0xb7, 0xf1, 0xe2, 0x98, 0x7f, 0x61, 0x3f, 0xfc, 0x17, 0x00, 0x00,
-	0xff, 0xff, 0xcd, 0xeb, 0x41, 0xdc, 0xd2, 0x06, 0x00, 0x00,
+	// 1193 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6d, 0x56, 0xdb, 0x6e, 0xe3, 0x36,
+	0x10, 0x6d, 0xee, 0x36, 0x9d, 0x2b, 0x73, 0x63, 0x36, 0x9b, 0xcd, 0xc6, 0x6d, 0xda, 0xb4, 0x45,
+	0xb3, 0x68, 0xb6, 0x0f, 0x45, 0x81, 0x3e, 0x64, 0xdd, 0x4b, 0x16, 0x49, 0x16, 0x86, 0x92, 0x45,
+	0x1f, 0x09, 0x59, 0xa2, 0x6d, 0x36, 0xb2, 0x24, 0x88, 0x74, 0xe2, 0xa0, 0x2f, 0xfd, 0x81, 0x7e,
+	0x40, 0xbf, 0xa1, 0xdf, 0x58, 0xa0, 0x33, 0x43, 0x4a, 0x76, 0x9c, 0xbe, 0x69, 0xce, 0x39, 0x33,
+	0x24, 0x87, 0xc3, 0x19, 0xb1, 0xe5, 0x28, 0x4b, 0xbb, 0xba, 0x77, 0x9a, 0x17, 0x99, 0xcd, 0x78,
+	0x2d, 0x55, 0x9d, 0x44, 0xd9, 0xbc, 0xd3, 0xfc, 0x6b, 0x96, 0x2d, 0xb6, 0x88, 0xe2, 0xdf, 0xb2,
+	0xa5, 0x54, 0xd9, 0x87, 0xac, 0xb8, 0x13, 0x33, 0xaf, 0x67, 0x4e, 0x1a, 0x67, 0xbb, 0xa7, 0xa5,
+	0xec, 0xf4, 0x83, 0x23, 0x9c, 0x32, 0x28, 0x75, 0xfc, 0x6b, 0xb6, 0x10, 0xf5, 0x43, 0x9d, 0x8a,
+	0x59, 0x72, 0xd8, 0x1e, 0x3b, 0xb4, 0x10, 0xf6, 0x72, 0xa7, 0xe1, 0xc7, 0x6c, 0xae, 0xc8, 0x23,
+	0x31, 0x47, 0xd2, 0xcd, 0xb1, 0x34, 0x68, 0xb7, 0xbc, 0x10, 0x79, 0x8c, 0x69, 0x6c, 0x68, 0x8d,
+	0x88, 0xa7, 0x63, 0xde, 0x20, 0x5c, 0xc6, 0x24, 0x0d, 0x3f, 0x61, 0xf3, 0x03, 0x6d, 0x22, 0xa1,
+	0x48, 0xbb, 0x35, 0xd6, 0x5e, 0x03, 0xea, 0xa5, 0xa4, 0xc0, 0xd5, 0xc3, 0x3c, 0x17, 0xdd, 0xe9,
+	0xd5, 0xcf, 0xf3, 0xbc, 0x5c, 0x1d, 0xf8, 0xe6, 0x1f, 0x6c, 0xe5, 0xc9, 0x59, 0x39, 0x67, 0xf3,
+	0x46, 0xa9, 0x18, 0x52, 0x32, 0x77, 0x52, 0x0f, 0xe8, 0x9b, 0xef, 0xb0, 0xc5, 0x44, 0x1b, 0xab,
+	0xf0, 0xdc, 0x88, 0x7a, 0x8b, 0x1f, 0xb2, 0x46, 0x5e, 0xe8, 0xfb, 0xd0, 0x2a, 0x79, 0xa7, 0x1e,
+	0xe9, 0xa4, 0xf5, 0x80, 0x79, 0xe8, 0x52, 0x3d, 0xf2, 0x03, 0xc6, 0x7c, 0xea, 0xa4, 0x8e, 0xc5,
+	0x3c, 0xf0, 0x2b, 0x41, 0xdd, 0x23, 0xef, 0xe3, 0xe6, 0x3f, 0x35, 0xd6, 0x98, 0x48, 0x1c, 0xdf,
+	0x63, 0x35, 0x4a, 0x1d, 0x8a, 0x67, 0x48, 0xbc, 0x44, 0xf6, 0xfb, 0x98, 0x0b, 0xb6, 0xd4, 0x53,
+	0xa9, 0x32, 0xda, 0x50, 0xee, 0xeb, 0x41, 0x69, 0x22, 0x13, 0x87, 0x36, 0x8c, 0x75, 0x21, 0x1a,
+	0x8e, 0xf1, 0x26, 0x6e, 0x1b, 0xb6, 0x85, 0xc4, 0x32, 0x11, 0xde, 0xc2, 0x5d, 0x41, 0x36, 0x0b,
+	0x2b, 0x07, 0x3a, 0x55, 0x62, 0x0b, 0xb8, 0x5a, 0x50, 0x27, 0xe4, 0x1a, 0x00, 0xfe, 0x02, 0x76,
+	0x91, 0xe9, 0xb4, 0x13, 0x1a, 0x25, 0xb6, 0xc9, 0xb1, 0xb2, 0xf9, 0x16, 0x5b, 0x40, 0xa7, 0x42,
+	0xec, 0x10, 0xe1, 0x0c, 0xfe, 0x8a, 0xb1, 0x3c, 0x34, 0x26, 0xef, 0x17, 0xe8, 0xb3, 0xeb, 0xd3,
+	0x50, 0x21, 0x7c, 0x9f, 0xd5, 0x7b, 0xa1, 0x91, 0x90, 0x98, 0x48, 0x09, 0xe1, 0x42, 0x02, 0xd0,
+	0x46, 0xbb, 0x24, 0x13, 0x3d, 0xd0, 0x56, 0xec, 0x55, 0xe4, 0x15, 0xda, 0x50, 0x1c, 0x1b, 0x46,
+	0xf7, 0xd2, 0xd0, 0x0e, 0x0b, 0x25, 0x23, 0x9d, 0xf7, 0x55, 0x61, 0xc4, 0x0b, 0xba, 0x84, 0xf5,
+	0x8a, 0x68, 0x39, 0x1c, 0x23, 0xd9, 0x91, 0xec, 0x87, 0x06, 0x2c, 0xb1, 0xef, 0x22, 0xd9, 0xd1,
+	0x05, 0xd9, 0xfc, 0x88, 0x2d, 0x43, 0xd4, 0x44, 0x19, 0x23, 0x07, 0x59, 0xac, 0xc4, 0x4b, 0x3a,
+	0x76, 0xc3, 0x63, 0xd7, 0x00, 0xf1, 0x33, 0xb6, 0x5d, 0xa8, 0xdf, 0x55, 0x64, 0x65, 0x9a, 0x65,
+	0xb9, 0xb4, 0x45, 0x98, 0x9a, 0x2e, 0x2e, 0x78, 0x40, 0xda, 0x4d, 0x47, 0x7e, 0x00, 0xee, 0xb6,
+	0xa4, 0xf8, 0x4b, 0x56, 0xef, 0x24, 0x61, 0x74, 0x87, 0x15, 0x21, 0x5e, 0xd1, 0xc6, 0xc6, 0x00,
+	0x7f, 0xc3, 0x36, 0x2b, 0x43, 0x16, 0x2a, 0x52, 0xfa, 0x1e, 0xe3, 0x1d, 0x52, 0x3c, 0x5e, 0x51,
+	0x41, 0xc9, 0xf0, 0xb7, 0x6c, 0x27, 0xc9, 0x1e, 0xa4, 0x91, 0xe3, 0x53, 0xf7, 0x95, 0xee, 0xf5,
+	0xad, 0x78, 0x0d, 0x3e, 0xf3, 0xc1, 0x26, 0xb0, 0x37, 0x37, 0x25, 0x77, 0x41, 0x14, 0xbf, 0x60,
+	0x47, 0x03, 0x35, 0xc8, 0xb3, 0x2c, 0xc1, 0x14, 0x67, 0x85, 0xb6, 0x8f, 0xb2, 0xca, 0xb7, 0x7c,
+	0x70, 0xfe, 0x47, 0xe0, 0x3f, 0x13, 0x1c, 0x78, 0x61, 0xdb, 0xeb, 0x7e, 0xf5, 0xb7, 0xf0, 0x9b,
+	0x8b, 0xf4, 0x23, 0xdb, 0x7f, 0x16, 0x29, 0xec, 0x55, 0x31, 0x9a, 0x14, 0x43, 0x4c, 0xc5, 0x38,
+	0xef, 0x95, 0xee, 0x6f, 0xd8, 0xd6, 0x20, 0x1c, 0x49, 0x75, 0xaf, 0x52, 0x0b, 0xcb, 0xab, 0x42,
+	0x76, 0x92, 0x2c, 0xba, 0x13, 0x9f, 0x52, 0x2d, 0x6f, 0x00, 0xf7, 0x33, 0x51, 0x6d, 0x55, 0xbc,
+	0x43, 0x82, 0x7f, 0xcf, 0xf6, 0x2a, 0x07, 0xd9, 0x79, 0xb4, 0x6a, 0xd2, 0xeb, 0x33, 0xf2, 0xda,
+	0x2e, 0xbd, 0xde, 0x21, 0x5d, 0x79, 0x7e, 0xc5, 0x36, 0xa0, 0xc3, 0xc1, 0x15, 0xc1, 0x6d, 0xe1,
+	0x59, 0xa3, 0x30, 0x37, 0xe2, 0x98, 0xf2, 0xbf, 0x56, 0x12, 0x70, 0xb8, 0x16, 0xc0, 0x98, 0x54,
+	0x5c, 0x05, 0x6a, 0xc3, 0xea, 0x81, 0x82, 0x42, 0x1f, 0xe4, 0x32, 0x2e, 0x74, 0xd7, 0x8a, 0xcf,
+	0x5d, 0x52, 0x81, 0xbd, 0x1d, 0xdd, 0x96, 0xdc, 0x4f, 0x48, 0xf1, 0x6f, 0x18, 0x2f, 0x94, 0x51,
+	0xc5, 0xbd, 0x8a, 0x65, 0x18, 0xc7, 0xf0, 0x6d, 0x94, 0x11, 0x5f, 0xd0, 0x0a, 0x1b, 0x25, 0x73,
+	0x5e, 0x12, 0xfc, 0x07, 0xb6, 0xf7, 0x5c, 0x5e, 0xde, 0xdd, 0x09, 0x2d, 0xb3, 0xfb, 0xcc, 0xcb,
+	0xdf, 0xdf, 0x31, 0x5b, 0xf5, 0x2e, 0x90, 0x75, 0xd5, 0xd5, 0x23, 0xf1, 0x25, 0x15, 0xef, 0x8a,
+	0x47, 0xdb, 0x04, 0x36, 0xff, 0x9e, 0x61, 0xf5, 0xaa, 0x77, 0xe2, 0x23, 0x86, 0xee, 0x29, 0x7d,
+	0x5f, 0x72, 0xdd, 0xaa, 0x0e, 0xc8, 0x55, 0xd5, 0x9a, 0xfa, 0xd6, 0xe6, 0xf2, 0x49, 0xdf, 0x62,
+	0x08, 0x4d, 0x09, 0xe0, 0x31, 0x0c, 0x13, 0x05, 0xbd, 0xab, 0x12, 0x5c, 0x13, 0x82, 0x4f, 0x0f,
+	0x12, 0x99, 0x42, 0xc5, 0xeb, 0x2c, 0x75, 0xcf, 0xd3, 0x50, 0x0b, 0x5b, 0x08, 0xd6, 0xc7, 0x04,
+	0x3d, 0x53, 0xd3, 0xfc, 0x17, 0xf6, 0x56, 0x75, 0x56, 0x7c, 0x88, 0x49, 0xd6, 0x93, 0x09, 0xdc,
+	0x6b, 0x42, 0x8d, 0x0c, 0x1e, 0x22, 0x00, 0x57, 0x68, 0x63, 0x93, 0x43, 0xb2, 0xab, 0x61, 0x55,
+	0xdf, 0xca, 0xc0, 0xfe, 0x05, 0x4c, 0xbe, 0xcb, 0xf0, 0x13, 0x2b, 0x8e, 0x7a, 0xe9, 0x0a, 0x34,
+	0xda, 0xac, 0x07, 0xe5, 0xc5, 0x4f, 0xd9, 0xa6, 0x4a, 0x43, 0xe8, 0xe0, 0x32, 0x82, 0x86, 0xd2,
+	0x87, 0xa7, 0x94, 0x67, 0x85, 0xa5, 0xdd, 0xd4, 0x82, 0x0d, 0x47, 0xb5, 0x90, 0x09, 0x88, 0x80,
+	0x31, 0xb1, 0x3e, 0x29, 0x94, 0xc3, 0x22, 0x11, 0x0b, 0xb4, 0xd6, 0x6a, 0x34, 0x96, 0x7d, 0x2c,
+	0x12, 0x9c, 0x3e, 0x39, 0xcc, 0xc8, 0xae, 0x58, 0x9c, 0x9e, 0x3e, 0x6d, 0x84, 0xcb, 0xe9, 0x43,
+	0x1a, 0x6c, 0xb5, 0xf8, 0x4a, 0xe1, 0xd8, 0x34, 0xac, 0x60, 0xe7, 0xde, 0x6c, 0xa6, 0xac, 0x31,
+	0xa1, 0x9f, 0xce, 0xbe, 0x4b, 0xc1, 0x64, 0xf6, 0xa1, 0x63, 0x46, 0xf9, 0x10, 0x3d, 0xc6, 0x69,
+	0x98, 0x40, 0x90, 0xc7, 0x57, 0xe6, 0x79, 0x3f, 0x58, 0xc6, 0x48, 0xf3, 0x92, 0xb1, 0xf1, 0xc4,
+	0xc3, 0x67, 0x1b, 0xab, 0x6e, 0x38, 0x4c, 0x2c, 0xce, 0x21, 0x63, 0x33, 0x68, 0x1b, 0x28, 0xc3,
+	0x8e, 0x09, 0xad, 0xd0, 0x2d, 0x2f, 0xbc, 0xe4, 0xd2, 0x2b, 0x30, 0xe3, 0x2d, 0xe4, 0x9b, 0x7f,
+	0xce, 0xb2, 0xc6, 0xc4, 0xac, 0xc5, 0x7a, 0xf4, 0xd9, 0x1e, 0x28, 0x0b, 0xdd, 0xc1, 0x50, 0x84,
+	0x5a, 0xb0, 0xe2, 0xd0, 0x6b, 0x07, 0xf2, 0x36, 0x5b, 0x77, 0xe9, 0xd5, 0x69, 0xaf, 0x2c, 0x23,
+	0xac, 0xb3, 0xd5, 0xb3, 0xe3, 0xff, 0x9d, 0xe1, 0xa7, 0x41, 0xa9, 0x76, 0x15, 0x16, 0xac, 0x15,
+	0x4f, 0x01, 0xfe, 0x1d, 0xab, 0xe9, 0xb4, 0x9b, 0x0c, 0x47, 0x71, 0x87, 0x66, 0x59, 0xe3, 0x4c,
+	0x8c, 0x23, 0xbd, 0xf7, 0x8c, 0xbf, 0x92, 0x4a, 0x89, 0x9d, 0xdd, 0xef, 0x53, 0xda, 0xb0, 0x67,
+	0x60, 0xd8, 0x61, 0x29, 0x37, 0x3c, 0x76, 0x0b, 0x50, 0xf3, 0x90, 0xad, 0x4d, 0x2d, 0xce, 0x97,
+	0x59, 0xad, 0x8c, 0xb8, 0xfe, 0x49, 0x73, 0xc4, 0x56, 0x9f, 0xc6, 0xc7, 0xff, 0x80, 0x7e, 0x06,
+	0x3d, 0xdd, 0x25, 0x8f, 0xbe, 0x11, 0xa3, 0xba, 0x9b, 0xa5, 0xe2, 0xa4, 0x6f, 0xbe, 0xca, 0x66,
+	0x61, 0xb7, 0xee, 0x86, 0xe0, 0x0b, 0x35, 0x43, 0x78, 0xe6, 0x54, 0x9b, 0xe0, 0x87, 0xdf, 0x38,
+	0x51, 0x71, 0x1a, 0xc2, 0xd4, 0x8f, 0x7d, 0x19, 0x56, 0x76, 0x67, 0x91, 0xfe, 0xd0, 0xde, 0xfe,
+	0x07, 0xd8, 0x70, 0x2c, 0x6e, 0xb1, 0x09, 0x00, 0x00,
 }
@@ -103,6 +103,13 @@ func TransferFunc(handler unsafe.Pointer, to *C.char, v *C.char) int {
 		logging.VLog().Error("get engine failed!")
 		return TransferGetEngineErr
 	}
+	if !engine.checkWritable() {
+		logging.VLog().WithFields(logrus.Fields{
+			"handler": uint64(uintptr(handler)),
+			"to":      C.GoString(to),
+		}).Debug("TransferFunc transfer in read-only execution.")
+		return TransferReadOnlyErr
+	}
 
 	addr, err := core.AddressParse(C.GoString(to))
 	if err != nil {
@@ -165,3 +172,44 @@ func VerifyAddressFunc(handler unsafe.Pointer, address *C.char) int {
 	}
 	return 0
 }
+
+// SavepointFunc create a named savepoint of the contract storage
+//export SavepointFunc
+func SavepointFunc(handler unsafe.Pointer, name *C.char) int {
+	engine, _ := getEngineByStorageHandler(uint64(uintptr(handler)))
+	if engine == nil || engine.ctx.contract == nil {
+		logging.VLog().Error("get engine failed!")
+		return SavepointGetEngineErr
+	}
+	if err := engine.Savepoint(C.GoString(name)); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"handler": uint64(uintptr(handler)),
+			"name":    C.GoString(name),
+			"err":     err,
+		}).Debug("SavepointFunc create savepoint failed.")
+		return SavepointFailedErr
+	}
+	return SavepointFuncSuccess
+}
+
+// RollbackToSavepointFunc undo the contract storage changes after the named savepoint
+//export RollbackToSavepointFunc
+func RollbackToSavepointFunc(handler unsafe.Pointer, name *C.char) int {
+	engine, _ := getEngineByStorageHandler(uint64(uintptr(handler)))
+	if engine == nil || engine.ctx.contract == nil {
+		logging.VLog().Error("get engine failed!")
+		return SavepointGetEngineErr
+	}
+	if err := engine.RollbackToSavepoint(C.GoString(name)); err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"handler": uint64(uintptr(handler)),
+			"name":    C.GoString(name),
+			"err":     err,
+		}).Debug("RollbackToSavepointFunc roll back failed.")
+		if err == ErrSavepointNotFound {
+			return SavepointNotFoundErr
+		}
+		return SavepointFailedErr
+	}
+	return SavepointFuncSuccess
+}
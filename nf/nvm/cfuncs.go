@@ -37,6 +37,8 @@ char *GetTxByHashFunc(void *handler, const char *hash);
 char *GetAccountStateFunc(void *handler, const char *address);
 int TransferFunc(void *handler, const char *to, const char *value);
 int VerifyAddressFunc(void *handler, const char *address);
+int SavepointFunc(void *handler, const char *name);
+int RollbackToSavepointFunc(void *handler, const char *name);
 
 // event.
 void EventTriggerFunc(void *handler, const char *topic, const char *data);
@@ -72,6 +74,12 @@ int TransferFunc_cgo(void *handler, const char *to, const char *value) {
 int VerifyAddressFunc_cgo(void *handler, const char *address) {
 	return VerifyAddressFunc(handler, address);
 };
+int SavepointFunc_cgo(void *handler, const char *name) {
+	return SavepointFunc(handler, name);
+};
+int RollbackToSavepointFunc_cgo(void *handler, const char *name) {
+	return RollbackToSavepointFunc(handler, name);
+};
 
 void EventTriggerFunc_cgo(void *handler, const char *topic, const char *data) {
 	EventTriggerFunc(handler, topic, data);
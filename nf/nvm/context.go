@@ -21,6 +21,9 @@ package nvm
 const (
 	// DefaultLimitsOfTotalMemorySize default limits of total memory size
 	DefaultLimitsOfTotalMemorySize uint64 = 40 * 1000 * 1000 // TODO: check the value ok and out of limit do
+
+	// DefaultEnginePoolSize default max count of idle v8 engines kept for reuse
+	DefaultEnginePoolSize = 16
 )
 
 // SerializableAccount serializable account state
@@ -34,6 +37,7 @@ type SerializableBlock struct {
 	Timestamp int64  `json:"timestamp"`
 	Hash      string `json:"hash"`
 	Height    uint64 `json:"height"`
+	Seed      string `json:"seed"` // seed of Math.random for the tx, identical across nodes
 }
 
 // SerializableTransaction serializable transaction
@@ -80,11 +84,12 @@ func toSerializableAccount(acc Account) *SerializableAccount {
 	return sAcc
 }
 
-func toSerializableBlock(block Block) *SerializableBlock {
+func toSerializableBlock(block Block, tx Transaction) *SerializableBlock {
 	sBlock := &SerializableBlock{
 		Timestamp: block.Timestamp(),
 		Hash:      block.Hash().String(),
 		Height:    block.Height(),
+		Seed:      block.RandomSeed(tx.Hash()).String(),
 	}
 	return sBlock
 }
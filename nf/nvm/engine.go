@@ -19,6 +19,8 @@
 package nvm
 
 import (
+	"context"
+
 	"github.com/nebulasio/go-nebulas/core"
 	"github.com/nebulasio/go-nebulas/core/state"
 )
@@ -26,14 +28,27 @@ import (
 // NebulasVM type of NebulasVM
 type NebulasVM struct {
 	engine *V8Engine
+	pool   *enginePool
 }
 
 // NewNebulasVM create new NebulasVM
 func NewNebulasVM() core.Engine {
-	nvm := &NebulasVM{}
+	nvm := &NebulasVM{
+		pool: newEnginePool(DefaultEnginePoolSize),
+	}
 	return nvm
 }
 
+// SetEnginePoolSize set the max count of idle engines kept for reuse, 0 disables reuse.
+// The pool is shared by the NebulasVM and its clones.
+func (nvm *NebulasVM) SetEnginePoolSize(size int) {
+	if nvm.pool == nil {
+		nvm.pool = newEnginePool(size)
+		return
+	}
+	nvm.pool.setSize(size)
+}
+
 // CreateEngine start engine
 func (nvm *NebulasVM) CreateEngine(block *core.Block, tx *core.Transaction, owner, contract state.Account, state state.AccountState) error {
 	if nvm.engine != nil {
@@ -44,10 +59,19 @@ func (nvm *NebulasVM) CreateEngine(block *core.Block, tx *core.Transaction, owne
 	if err != nil {
 		return err
 	}
-	nvm.engine = NewV8Engine(ctx)
+	nvm.startEngine(ctx)
 	return nil
 }
 
+// startEngine check out an engine from pool for ctx, create a new one if not pooled.
+func (nvm *NebulasVM) startEngine(ctx *Context) {
+	if nvm.pool != nil {
+		nvm.engine = nvm.pool.get(ctx)
+	} else {
+		nvm.engine = NewV8Engine(ctx)
+	}
+}
+
 // SetEngineExecutionLimits set limits of execution instructions
 func (nvm *NebulasVM) SetEngineExecutionLimits(limitsOfExecutionInstructions uint64) error {
 	if nvm.engine == nil {
@@ -56,12 +80,23 @@ func (nvm *NebulasVM) SetEngineExecutionLimits(limitsOfExecutionInstructions uin
 	return nvm.engine.SetExecutionLimits(limitsOfExecutionInstructions, DefaultLimitsOfTotalMemorySize)
 }
 
+// SetEngineExecutionContext set the context of engine execution, the execution aborts
+// with ErrExecutionDeadlineExceeded once ctx is done
+func (nvm *NebulasVM) SetEngineExecutionContext(ctx context.Context) error {
+	if nvm.engine == nil {
+		return ErrEngineNotStart
+	}
+	nvm.engine.SetExecutionContext(ctx)
+	return nil
+}
+
 // DeployAndInitEngine deploy and init source
 func (nvm *NebulasVM) DeployAndInitEngine(source, sourceType, args string) (string, error) {
 	if nvm.engine == nil {
 		return "", ErrEngineNotStart
 	}
-	return nvm.engine.DeployAndInit(source, sourceType, args)
+	result, err := nvm.engine.DeployAndInit(source, sourceType, args)
+	return result, nvm.revertReason(err)
 }
 
 // CallEngine run source function
@@ -69,7 +104,34 @@ func (nvm *NebulasVM) CallEngine(source, sourceType, function, args string) (str
 	if nvm.engine == nil {
 		return "", ErrEngineNotStart
 	}
-	return nvm.engine.Call(source, sourceType, function, args)
+	result, err := nvm.engine.Call(source, sourceType, function, args)
+	return result, nvm.revertReason(err)
+}
+
+// ReceiveEngine run the receive hook of source on value transfer
+func (nvm *NebulasVM) ReceiveEngine(source, sourceType string) (string, error) {
+	if nvm.engine == nil {
+		return "", ErrEngineNotStart
+	}
+	result, err := nvm.engine.Receive(source, sourceType)
+	return result, nvm.revertReason(err)
+}
+
+// LoadEngine load and instantiate the contract of source without calling any function
+func (nvm *NebulasVM) LoadEngine(source, sourceType string) (string, error) {
+	if nvm.engine == nil {
+		return "", ErrEngineNotStart
+	}
+	result, err := nvm.engine.Load(source, sourceType)
+	return result, nvm.revertReason(err)
+}
+
+// revertReason return the exception thrown by the contract as the revert reason of the failed execution.
+func (nvm *NebulasVM) revertReason(err error) error {
+	if err == ErrExecutionFailed && len(nvm.engine.exceptionMessage) > 0 {
+		return core.NewRevertReason(core.RevertCodeContractThrow, nvm.engine.exceptionMessage)
+	}
+	return err
 }
 
 // ExecutionInstructions returns instructions count
@@ -80,16 +142,72 @@ func (nvm *NebulasVM) ExecutionInstructions() (uint64, error) {
 	return nvm.engine.ExecutionInstructions(), nil
 }
 
+// StorageBytesWritten returns bytes written to storage
+func (nvm *NebulasVM) StorageBytesWritten() (uint64, error) {
+	if nvm.engine == nil {
+		return 0, ErrEngineNotStart
+	}
+	return nvm.engine.StorageBytesWritten(), nil
+}
+
+// Savepoint create a named savepoint of the contract storage, savepoints can be nested
+func (nvm *NebulasVM) Savepoint(name string) error {
+	if nvm.engine == nil {
+		return ErrEngineNotStart
+	}
+	return nvm.engine.Savepoint(name)
+}
+
+// RollbackToSavepoint undo the contract storage changes after the named savepoint
+func (nvm *NebulasVM) RollbackToSavepoint(name string) error {
+	if nvm.engine == nil {
+		return ErrEngineNotStart
+	}
+	return nvm.engine.RollbackToSavepoint(name)
+}
+
+// SetEngineReadOnly set the read-only mode of engine, any state mutation fails with ErrReadOnlyViolation
+func (nvm *NebulasVM) SetEngineReadOnly(readOnly bool) error {
+	if nvm.engine == nil {
+		return ErrEngineNotStart
+	}
+	nvm.engine.SetReadOnly(readOnly)
+	return nil
+}
+
+// SetEngineStorageReadTrace set the storage read trace mode of engine, it's diagnostic only
+func (nvm *NebulasVM) SetEngineStorageReadTrace(enabled bool) error {
+	if nvm.engine == nil {
+		return ErrEngineNotStart
+	}
+	nvm.engine.SetStorageReadTrace(enabled)
+	return nil
+}
+
+// StorageKeysRead returns the storage keys read in trace mode
+func (nvm *NebulasVM) StorageKeysRead() ([]string, error) {
+	if nvm.engine == nil {
+		return nil, ErrEngineNotStart
+	}
+	return nvm.engine.StorageKeysRead(), nil
+}
+
 // DisposeEngine dispose engine
 func (nvm *NebulasVM) DisposeEngine() {
 	if nvm.engine != nil {
-		nvm.engine.Dispose()
+		if nvm.pool != nil {
+			nvm.pool.put(nvm.engine)
+		} else {
+			nvm.engine.Dispose()
+		}
 		nvm.engine = nil
 	}
 }
 
 // Clone clone a new engine
 func (nvm *NebulasVM) Clone() core.Engine {
-	n := &NebulasVM{}
+	n := &NebulasVM{
+		pool: nvm.pool,
+	}
 	return n
 }
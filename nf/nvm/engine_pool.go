@@ -0,0 +1,84 @@
+// Copyright (C) 2017 go-nebulas authors
+//
+// This file is part of the go-nebulas library.
+//
+// the go-nebulas library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// the go-nebulas library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with the go-nebulas library.  If not, see <http://www.gnu.org/licenses/>.
+//
+
+package nvm
+
+import "sync"
+
+// enginePool keeps idle v8 engines to reuse their isolates between transactions.
+type enginePool struct {
+	lock    sync.Mutex
+	size    int
+	engines []*V8Engine
+}
+
+func newEnginePool(size int) *enginePool {
+	return &enginePool{size: size}
+}
+
+// get check out an idle engine reset to ctx, or create a new one if none idle.
+func (pool *enginePool) get(ctx *Context) *V8Engine {
+	pool.lock.Lock()
+	n := len(pool.engines)
+	if n == 0 {
+		pool.lock.Unlock()
+		return NewV8Engine(ctx)
+	}
+	engine := pool.engines[n-1]
+	pool.engines = pool.engines[:n-1]
+	pool.lock.Unlock()
+
+	engine.reset(ctx)
+	return engine
+}
+
+// put return the engine to pool, dispose it if the pool is full.
+func (pool *enginePool) put(engine *V8Engine) {
+	pool.lock.Lock()
+	if len(pool.engines) < pool.size {
+		// drop the references to the finished execution.
+		engine.ctx = nil
+		engine.modules = nil
+		pool.engines = append(pool.engines, engine)
+		pool.lock.Unlock()
+		return
+	}
+	pool.lock.Unlock()
+
+	engine.Dispose()
+}
+
+// setSize change the max count of idle engines, dispose the overflows.
+func (pool *enginePool) setSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+
+	pool.lock.Lock()
+	pool.size = size
+	var overflows []*V8Engine
+	if len(pool.engines) > size {
+		overflows = pool.engines[size:]
+		pool.engines = pool.engines[:size]
+	}
+	pool.lock.Unlock()
+
+	for _, engine := range overflows {
+		engine.Dispose()
+	}
+}
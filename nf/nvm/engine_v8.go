@@ -38,22 +38,27 @@ char *GetTxByHashFunc_cgo(void *handler, const char *hash);
 char *GetAccountStateFunc_cgo(void *handler, const char *address);
 int TransferFunc_cgo(void *handler, const char *to, const char *value);
 int VerifyAddressFunc_cgo(void *handler, const char *address);
+int SavepointFunc_cgo(void *handler, const char *name);
+int RollbackToSavepointFunc_cgo(void *handler, const char *name);
 
 void EventTriggerFunc_cgo(void *handler, const char *topic, const char *data);
 
 */
 import "C"
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"encoding/json"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/nebulasio/go-nebulas/core"
 	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
 	"github.com/nebulasio/go-nebulas/util/logging"
@@ -70,6 +75,7 @@ var (
 	enginesLock           = sync.RWMutex{}
 	publicFuncNameChecker = regexp.MustCompile("^[a-zA-Z$][A-Za-z0-9_$]*$")
 	sourceModuleCache, _  = lru.New(4096)
+	v8enginesCreated      = uint64(0)
 )
 
 // V8Engine v8 engine.
@@ -82,8 +88,22 @@ type V8Engine struct {
 	limitsOfTotalMemorySize            uint64
 	actualCountOfExecutionInstructions uint64
 	actualTotalMemorySize              uint64
+	actualCountOfStorageBytesWritten   uint64
 	lcsHandler                         uint64
 	gcsHandler                         uint64
+	savepoints                         []*savepoint
+	readOnly                           bool
+	readOnlyViolated                   bool
+	eventErr                           error
+	traceStorageReads                  bool
+	storageKeysRead                    []string
+	executionCtx                       context.Context
+	exceptionMessage                   string
+}
+
+type savepoint struct {
+	name string
+	id   int
 }
 
 type sourceModuleItem struct {
@@ -107,7 +127,7 @@ func InitV8Engine() {
 	C.InitializeStorage((C.StorageGetFunc)(unsafe.Pointer(C.StorageGetFunc_cgo)), (C.StoragePutFunc)(unsafe.Pointer(C.StoragePutFunc_cgo)), (C.StorageDelFunc)(unsafe.Pointer(C.StorageDelFunc_cgo)))
 
 	// Blockchain.
-	C.InitializeBlockchain((C.GetTxByHashFunc)(unsafe.Pointer(C.GetTxByHashFunc_cgo)), (C.GetAccountStateFunc)(unsafe.Pointer(C.GetAccountStateFunc_cgo)), (C.TransferFunc)(unsafe.Pointer(C.TransferFunc_cgo)), (C.VerifyAddressFunc)(unsafe.Pointer(C.VerifyAddressFunc_cgo)))
+	C.InitializeBlockchain((C.GetTxByHashFunc)(unsafe.Pointer(C.GetTxByHashFunc_cgo)), (C.GetAccountStateFunc)(unsafe.Pointer(C.GetAccountStateFunc_cgo)), (C.TransferFunc)(unsafe.Pointer(C.TransferFunc_cgo)), (C.VerifyAddressFunc)(unsafe.Pointer(C.VerifyAddressFunc_cgo)), (C.SavepointFunc)(unsafe.Pointer(C.SavepointFunc_cgo)), (C.RollbackToSavepointFunc)(unsafe.Pointer(C.RollbackToSavepointFunc_cgo)))
 
 	// Event.
 	C.InitializeEvent((C.EventTriggerFunc)(unsafe.Pointer(C.EventTriggerFunc_cgo)))
@@ -123,6 +143,7 @@ func NewV8Engine(ctx *Context) *V8Engine {
 	v8engineOnce.Do(func() {
 		InitV8Engine()
 	})
+	atomic.AddUint64(&v8enginesCreated, 1)
 
 	engine := &V8Engine{
 		ctx:                                ctx,
@@ -133,6 +154,7 @@ func NewV8Engine(ctx *Context) *V8Engine {
 		limitsOfTotalMemorySize:            0,
 		actualCountOfExecutionInstructions: 0,
 		actualTotalMemorySize:              0,
+		actualCountOfStorageBytesWritten:   0,
 	}
 
 	(func() {
@@ -141,19 +163,59 @@ func NewV8Engine(ctx *Context) *V8Engine {
 		engines[engine.v8engine] = engine
 	})()
 
-	(func() {
-		storagesLock.Lock()
-		defer storagesLock.Unlock()
+	engine.registerStorages()
+	return engine
+}
 
-		storagesIdx++
-		engine.lcsHandler = storagesIdx
-		storagesIdx++
-		engine.gcsHandler = storagesIdx
+func (e *V8Engine) registerStorages() {
+	storagesLock.Lock()
+	defer storagesLock.Unlock()
 
-		storages[engine.lcsHandler] = engine
-		storages[engine.gcsHandler] = engine
-	})()
-	return engine
+	storagesIdx++
+	e.lcsHandler = storagesIdx
+	storagesIdx++
+	e.gcsHandler = storagesIdx
+
+	storages[e.lcsHandler] = e
+	storages[e.gcsHandler] = e
+}
+
+func (e *V8Engine) unregisterStorages() {
+	storagesLock.Lock()
+	defer storagesLock.Unlock()
+
+	delete(storages, e.lcsHandler)
+	delete(storages, e.gcsHandler)
+}
+
+// reset clear all the states of last execution and bind the engine to ctx,
+// the v8 isolate is kept, every script runs in a new v8 context of it.
+func (e *V8Engine) reset(ctx *Context) {
+	e.ctx = ctx
+	e.modules = NewModules()
+	e.enableLimits = true
+	e.limitsOfExecutionInstructions = 0
+	e.limitsOfTotalMemorySize = 0
+	e.actualCountOfExecutionInstructions = 0
+	e.actualTotalMemorySize = 0
+	e.actualCountOfStorageBytesWritten = 0
+	e.savepoints = nil
+	e.readOnly = false
+	e.readOnlyViolated = false
+	e.eventErr = nil
+	e.traceStorageReads = false
+	e.storageKeysRead = nil
+	e.executionCtx = nil
+
+	e.v8engine.limits_of_executed_instructions = 0
+	e.v8engine.limits_of_total_memory_size = 0
+	e.v8engine.is_requested_terminate_execution = 0
+	e.v8engine.testing = 0
+	e.v8engine.stats = C.V8EngineStats{}
+
+	// storage handlers of last execution are no longer valid.
+	e.unregisterStorages()
+	e.registerStorages()
 }
 
 // SetEnableLimit eval switch
@@ -163,10 +225,7 @@ func (e *V8Engine) SetEnableLimit(isLimit bool) {
 
 // Dispose dispose all resources.
 func (e *V8Engine) Dispose() {
-	storagesLock.Lock()
-	delete(storages, e.lcsHandler)
-	delete(storages, e.gcsHandler)
-	storagesLock.Unlock()
+	e.unregisterStorages()
 
 	enginesLock.Lock()
 	delete(engines, e.v8engine)
@@ -175,6 +234,50 @@ func (e *V8Engine) Dispose() {
 	C.DeleteEngine(e.v8engine)
 }
 
+// SetReadOnly set the read-only mode, storage writes, transfers and events fail in read-only execution.
+func (e *V8Engine) SetReadOnly(readOnly bool) {
+	e.readOnly = readOnly
+}
+
+// checkWritable return false and mark the execution violated if engine is read-only.
+func (e *V8Engine) checkWritable() bool {
+	if e.readOnly {
+		e.readOnlyViolated = true
+		return false
+	}
+	return true
+}
+
+// SetStorageReadTrace set the storage read trace mode, the storage keys read are recorded for debugging.
+func (e *V8Engine) SetStorageReadTrace(enabled bool) {
+	e.traceStorageReads = enabled
+}
+
+// SetExecutionContext set the context of execution, the execution is terminated once ctx is done.
+func (e *V8Engine) SetExecutionContext(ctx context.Context) {
+	e.executionCtx = ctx
+}
+
+// executionDone return the done channel of execution context, nil if not set.
+func (e *V8Engine) executionDone() <-chan struct{} {
+	if e.executionCtx == nil {
+		return nil
+	}
+	return e.executionCtx.Done()
+}
+
+// StorageKeysRead returns the storage keys read during execution in order, only recorded in trace mode.
+func (e *V8Engine) StorageKeysRead() []string {
+	return e.storageKeysRead
+}
+
+// traceStorageRead record the storage key read in trace mode.
+func (e *V8Engine) traceStorageRead(key string) {
+	if e.traceStorageReads {
+		e.storageKeysRead = append(e.storageKeysRead, key)
+	}
+}
+
 // Context returns engine context
 func (e *V8Engine) Context() *Context {
 	return e.ctx
@@ -219,6 +322,37 @@ func (e *V8Engine) ExecutionInstructions() uint64 {
 	return e.actualCountOfExecutionInstructions
 }
 
+// StorageBytesWritten returns the bytes written to storage during execution
+func (e *V8Engine) StorageBytesWritten() uint64 {
+	return e.actualCountOfStorageBytesWritten
+}
+
+// Savepoint create a named savepoint of the contract storage, savepoints can be nested
+func (e *V8Engine) Savepoint(name string) error {
+	id, err := e.ctx.contract.Savepoint()
+	if err != nil {
+		return err
+	}
+	e.savepoints = append(e.savepoints, &savepoint{name: name, id: id})
+	return nil
+}
+
+// RollbackToSavepoint undo the contract storage changes after the named savepoint,
+// savepoints created after it are released.
+func (e *V8Engine) RollbackToSavepoint(name string) error {
+	for i := len(e.savepoints) - 1; i >= 0; i-- {
+		if e.savepoints[i].name != name {
+			continue
+		}
+		if err := e.ctx.contract.RollbackToSavepoint(e.savepoints[i].id); err != nil {
+			return err
+		}
+		e.savepoints = e.savepoints[:i+1]
+		return nil
+	}
+	return ErrSavepointNotFound
+}
+
 // TranspileTypeScript transpile typescript to javascript and return it.
 func (e *V8Engine) TranspileTypeScript(source string) (string, int, error) {
 	cSource := C.CString(source)
@@ -261,6 +395,7 @@ func (e *V8Engine) CollectTracingStats() {
 
 // RunScriptSource run js source.
 func (e *V8Engine) RunScriptSource(source string, sourceLineOffset int) (result string, err error) {
+	e.exceptionMessage = ""
 	if e.enableLimits {
 		traceableSource, traceableSourceLineOffset, err := e.InjectTracingInstructions(source)
 		if err != nil {
@@ -270,6 +405,11 @@ func (e *V8Engine) RunScriptSource(source string, sourceLineOffset int) (result
 		sourceLineOffset += traceableSourceLineOffset
 	}
 
+	// the deadline is passed before execution starts.
+	if e.executionCtx != nil && e.executionCtx.Err() != nil {
+		return "", ErrExecutionDeadlineExceeded
+	}
+
 	cSource := C.CString(source)
 	defer C.free(unsafe.Pointer(cSource))
 
@@ -293,11 +433,25 @@ func (e *V8Engine) RunScriptSource(source string, sourceLineOffset int) (result
 	case <-done:
 		if ret != 0 {
 			err = ErrExecutionFailed
+			// the result carries the exception thrown by the script.
+			if cJSONResult != nil {
+				e.exceptionMessage = C.GoString(cJSONResult)
+				C.free(unsafe.Pointer(cJSONResult))
+				cJSONResult = nil
+			}
 		}
 	case <-time.After(2 * time.Second):
 		C.TerminateExecution(e.v8engine) //ToDo TerminateExecution can kill RunScriptSource
 		err = ErrExecutionTimeout
 
+		// wait for C.RunScriptSource() returns.
+		select {
+		case <-done:
+		}
+	case <-e.executionDone():
+		C.TerminateExecution(e.v8engine)
+		err = ErrExecutionDeadlineExceeded
+
 		// wait for C.RunScriptSource() returns.
 		select {
 		case <-done:
@@ -317,6 +471,12 @@ func (e *V8Engine) RunScriptSource(source string, sourceLineOffset int) (result
 	if e.actualCountOfExecutionInstructions > e.limitsOfExecutionInstructions || err == ErrExceedMemoryLimits { //ToDo ErrExceedMemoryLimits value is same in each linux
 		e.actualCountOfExecutionInstructions = e.limitsOfExecutionInstructions //ToDo memory pass whether exhaust ?
 	}
+	if e.readOnlyViolated {
+		err = ErrReadOnlyViolation
+	}
+	if e.eventErr != nil {
+		err = e.eventErr
+	}
 
 	return "", err
 }
@@ -338,6 +498,16 @@ func (e *V8Engine) Call(source, sourceType, function, args string) (string, erro
 	return e.RunContractScript(source, sourceType, function, args)
 }
 
+// Receive run the receive hook in a script on value transfer, the hook is private and only called by chain.
+func (e *V8Engine) Receive(source, sourceType string) (string, error) {
+	return e.RunContractScript(source, sourceType, core.ContractReceiveFunction, "")
+}
+
+// Load the contract in a script without calling any function, the source of contract upgrade is checked by it.
+func (e *V8Engine) Load(source, sourceType string) (string, error) {
+	return e.RunContractScript(source, sourceType, "", "")
+}
+
 // RunContractScript execute script in Smart Contract's way.
 func (e *V8Engine) RunContractScript(source, sourceType, function, args string) (string, error) {
 	var runnableSource string
@@ -416,7 +586,7 @@ func (e *V8Engine) prepareRunnableContractScript(source, function, args string)
 	}
 
 	// prepare for execute.
-	block := toSerializableBlock(e.ctx.block)
+	block := toSerializableBlock(e.ctx.block, e.ctx.tx)
 	blockJSON, err := json.Marshal(block)
 	if err != nil {
 		return "", 0, err
@@ -443,12 +613,21 @@ func (e *V8Engine) prepareRunnableContractScript(source, function, args string)
 		argsInput[0] = '['
 		argsInput[1] = ']'
 	}
+	call := fmt.Sprintf(`__instance["%s"].apply(__instance, JSON.parse("%s"));`, function, formatArgs(string(argsInput)))
+	if function == core.ContractReceiveFunction {
+		// receive hook is optional, contract without it accepts the value.
+		call = fmt.Sprintf(`if (typeof __instance["%s"] === "function") { %s }`, function, call)
+	}
+	if len(function) == 0 {
+		// the contract is only loaded and instantiated.
+		call = ""
+	}
 	runnableSource = fmt.Sprintf(`var __contract = require("%s");
 				var __instance = new __contract();
 				Blockchain.blockParse("%s");
 				Blockchain.transactionParse("%s");
-				__instance["%s"].apply(__instance, JSON.parse("%s"));`,
-		ModuleID, formatArgs(string(blockJSON)), formatArgs(string(txJSON)), function, formatArgs(string(argsInput)))
+				%s`,
+		ModuleID, formatArgs(string(blockJSON)), formatArgs(string(txJSON)), call)
 	return runnableSource, 0, nil
 }
 
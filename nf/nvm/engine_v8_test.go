@@ -21,19 +21,23 @@ package nvm
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"encoding/json"
 
 	"github.com/nebulasio/go-nebulas/core"
 	"github.com/nebulasio/go-nebulas/core/state"
 	"github.com/nebulasio/go-nebulas/crypto"
+	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/crypto/keystore"
 	"github.com/nebulasio/go-nebulas/crypto/keystore/secp256k1"
 	"github.com/nebulasio/go-nebulas/storage"
@@ -77,10 +81,20 @@ func (block *testBlock) RecordEvent(txHash byteutils.Hash, topic, data string) e
 	return nil
 }
 
+// RecordContractEvent mock
+func (block *testBlock) RecordContractEvent(txHash byteutils.Hash, contract *core.Address, topic, data string) error {
+	return nil
+}
+
 func (block *testBlock) Timestamp() int64 {
 	return int64(0)
 }
 
+// RandomSeed mock
+func (block *testBlock) RandomSeed(txHash byteutils.Hash) byteutils.Hash {
+	return hash.Sha3256(block.Hash(), txHash)
+}
+
 func mockBlock() Block {
 	block := &testBlock{}
 	return block
@@ -281,6 +295,40 @@ func TestRunScriptSourceTimeout(t *testing.T) {
 	}
 }
 
+func TestRunScriptSourceDeadline(t *testing.T) {
+	data, err := ioutil.ReadFile("test/test_infinite_loop.js")
+	assert.Nil(t, err, "filepath read error")
+
+	mem, _ := storage.NewMemoryStorage()
+	accState, _ := state.NewAccountState(nil, mem)
+	owner, err := accState.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+	contract, _ := accState.CreateContractAccount([]byte("account2"), nil)
+	ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, accState)
+	assert.Nil(t, err)
+
+	// the compute-heavy script is terminated at the deadline, well before the execution timeout.
+	engine := NewV8Engine(ctx)
+	engine.SetExecutionLimits(1000000000000, 10000000)
+	deadline, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	engine.SetExecutionContext(deadline)
+	start := time.Now()
+	_, err = engine.RunScriptSource(string(data), 0)
+	assert.Equal(t, ErrExecutionDeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, engine.ExecutionInstructions() > 0)
+	engine.Dispose()
+
+	// the script never runs once the deadline is passed.
+	engine = NewV8Engine(ctx)
+	engine.SetExecutionContext(deadline)
+	_, err = engine.RunScriptSource(string(data), 0)
+	assert.Equal(t, ErrExecutionDeadlineExceeded, err)
+	assert.Equal(t, uint64(0), engine.ExecutionInstructions())
+	engine.Dispose()
+}
+
 func TestDeployAndInitAndCall(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -443,6 +491,357 @@ func TestFunctionNameCheck(t *testing.T) {
 	}
 }
 
+func TestStorageBytesWritten(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		wanted uint64
+	}{
+		{"write nothing", "console.log('running.');", 0},
+		// value is serialized as json string, 98 chars and 2 quotes.
+		{"write 100 bytes", "LocalContractStorage.put('k', new Array(99).join('x'));", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem, _ := storage.NewMemoryStorage()
+			context, _ := state.NewAccountState(nil, mem)
+			owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+			assert.Nil(t, err)
+			contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+			ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+
+			engine := NewV8Engine(ctx)
+			engine.SetExecutionLimits(900000, 10000000)
+			_, err = engine.RunScriptSource(tt.source, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wanted, engine.StorageBytesWritten())
+			engine.Dispose()
+		})
+	}
+}
+
+func TestStorageReadTrace(t *testing.T) {
+	mem, _ := storage.NewMemoryStorage()
+	context, _ := state.NewAccountState(nil, mem)
+	owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+	contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+	ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+	assert.Nil(t, err)
+
+	source := "LocalContractStorage.get('a'); LocalContractStorage.get('b');"
+
+	engine := NewV8Engine(ctx)
+	engine.SetExecutionLimits(900000, 10000000)
+	_, err = engine.RunScriptSource(source, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, engine.StorageKeysRead())
+	engine.Dispose()
+
+	engine = NewV8Engine(ctx)
+	engine.SetExecutionLimits(900000, 10000000)
+	engine.SetStorageReadTrace(true)
+	_, err = engine.RunScriptSource(source, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, engine.StorageKeysRead())
+	engine.Dispose()
+}
+
+func TestReceiveHook(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{"without hook", `var C = function(){}; C.prototype = {}; module.exports = C;`, false},
+		{"accepting hook", `var C = function(){}; C.prototype = {__nebulas_receive: function(){}}; module.exports = C;`, false},
+		{"rejecting hook", `var C = function(){}; C.prototype = {__nebulas_receive: function(){ throw new Error("reject"); }}; module.exports = C;`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem, _ := storage.NewMemoryStorage()
+			context, _ := state.NewAccountState(nil, mem)
+			owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+			assert.Nil(t, err)
+			contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+			ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+
+			engine := NewV8Engine(ctx)
+			engine.SetExecutionLimits(10000, 10000000)
+			_, err = engine.Receive(tt.source, "js")
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			// the hook is private to calls.
+			_, err = engine.Call(tt.source, "js", core.ContractReceiveFunction, "")
+			assert.Equal(t, ErrDisallowCallNotStandardFunction, err)
+			engine.Dispose()
+		})
+	}
+}
+
+func TestRevertReason(t *testing.T) {
+	source := `var C = function(){}; C.prototype = {init: function(){}, fail: function(){ throw new Error("custom revert"); }, loop: function(){ while(true){} }}; module.exports = C;`
+	tests := []struct {
+		name     string
+		function string
+		limit    uint64
+		wanted   error
+	}{
+		{"custom message", "fail", 10000, core.NewRevertReason(core.RevertCodeContractThrow, "Error: custom revert")},
+		{"out of gas", "loop", 1000, ErrInsufficientGas},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem, _ := storage.NewMemoryStorage()
+			context, _ := state.NewAccountState(nil, mem)
+			owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+			assert.Nil(t, err)
+			contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+			ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+			assert.Nil(t, err)
+
+			nvm := &NebulasVM{engine: NewV8Engine(ctx)}
+			nvm.engine.SetExecutionLimits(tt.limit, 10000000)
+			_, err = nvm.CallEngine(source, "js", tt.function, "")
+			assert.Equal(t, tt.wanted, err)
+			assert.Equal(t, tt.wanted.Error(), core.RevertReasonOf(err).Message)
+			nvm.engine.Dispose()
+		})
+	}
+}
+
+func TestSeededRandom(t *testing.T) {
+	source := `var C = function(){};
+C.prototype = {
+	roll: function(){ return [Math.random(), Math.random()]; }
+};
+module.exports = C;`
+
+	mem, _ := storage.NewMemoryStorage()
+	context, _ := state.NewAccountState(nil, mem)
+	owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+	contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+
+	roll := func(block Block, tx Transaction) []float64 {
+		ctx, err := NewContext(block, tx, owner, contract, context)
+		assert.Nil(t, err)
+		engine := NewV8Engine(ctx)
+		defer engine.Dispose()
+		engine.SetExecutionLimits(100000, 10000000)
+		result, err := engine.Call(source, "js", "roll", "")
+		assert.Nil(t, err)
+		var values []float64
+		assert.Nil(t, json.Unmarshal([]byte(result), &values))
+		return values
+	}
+
+	// same tx in same block rolls the same values.
+	block := mockBlock()
+	tx := mockTransaction()
+	values := roll(block, tx)
+	assert.Equal(t, 2, len(values))
+	assert.NotEqual(t, values[0], values[1])
+	for _, v := range values {
+		assert.True(t, v >= 0 && v < 1)
+	}
+	assert.Equal(t, values, roll(block, tx))
+
+	// another tx rolls others.
+	assert.NotEqual(t, values, roll(block, mockTransaction()))
+}
+
+func TestReadOnly(t *testing.T) {
+	source := `var C = function(){};
+C.prototype = {
+	get: function(key){ return LocalContractStorage.get(key); },
+	set: function(key, value){ LocalContractStorage.put(key, value); },
+	del: function(key){ LocalContractStorage.del(key); },
+	pay: function(to){ Blockchain.transfer(to, 1); },
+	notify: function(){ Event.Trigger("read", {}); }
+};
+module.exports = C;`
+
+	tests := []struct {
+		name     string
+		function string
+		args     string
+		want     string
+		wantErr  error
+	}{
+		{"getter", "get", `["key"]`, `"value"`, nil},
+		{"setter", "set", `["key", "changed"]`, "", ErrReadOnlyViolation},
+		{"delete", "del", `["key"]`, "", ErrReadOnlyViolation},
+		{"transfer", "pay", `["n1FkntVUMPAsESuCAAPK711omQk19JotBjM"]`, "", ErrReadOnlyViolation},
+		{"event", "notify", "", "", ErrReadOnlyViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem, _ := storage.NewMemoryStorage()
+			context, _ := state.NewAccountState(nil, mem)
+			owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+			assert.Nil(t, err)
+			contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+			contract.AddBalance(newUint128FromIntWrapper(100))
+			assert.Nil(t, contract.Put(hashStorageKey("key"), []byte(`"value"`)))
+			ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+			assert.Nil(t, err)
+
+			engine := NewV8Engine(ctx)
+			engine.SetExecutionLimits(100000, 10000000)
+			engine.SetReadOnly(true)
+			defer engine.Dispose()
+
+			result, err := engine.Call(source, "js", tt.function, tt.args)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, result)
+			}
+
+			// state is untouched.
+			value, err := contract.Get(hashStorageKey("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, `"value"`, string(value))
+			assert.Equal(t, "100", contract.Balance().String())
+		})
+	}
+}
+
+func TestSavepoint(t *testing.T) {
+	mem, _ := storage.NewMemoryStorage()
+	context, _ := state.NewAccountState(nil, mem)
+	// savepoints work in batch task, as contracts executed in block.
+	context.Begin()
+	owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+	contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+	ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+
+	engine := NewV8Engine(ctx)
+	engine.SetExecutionLimits(100000, 10000000)
+	defer engine.Dispose()
+
+	assert.Equal(t, ErrSavepointNotFound, engine.RollbackToSavepoint("write"))
+
+	_, err = engine.RunScriptSource("LocalContractStorage.put('first', 1);", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, engine.Savepoint("write"))
+	_, err = engine.RunScriptSource("LocalContractStorage.put('second', 2);", 0)
+	assert.Nil(t, err)
+
+	// roll back the second write only.
+	assert.Nil(t, engine.RollbackToSavepoint("write"))
+	_, err = contract.Get(hashStorageKey("first"))
+	assert.Nil(t, err)
+	_, err = contract.Get(hashStorageKey("second"))
+	assert.NotNil(t, err)
+}
+
+func TestSavepointFromContract(t *testing.T) {
+	data, err := ioutil.ReadFile("test/contract_savepoint.js")
+	assert.Nil(t, err)
+	source := string(data)
+
+	mem, _ := storage.NewMemoryStorage()
+	context, _ := state.NewAccountState(nil, mem)
+	context.Begin()
+	owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+	contract, _ := context.CreateContractAccount([]byte("account2"), nil)
+	ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+	assert.Nil(t, err)
+
+	engine := NewV8Engine(ctx)
+	engine.SetExecutionLimits(100000, 10000000)
+	_, err = engine.DeployAndInit(source, "js", "")
+	assert.Nil(t, err)
+	engine.Dispose()
+
+	// the contract rolls back its own write after the savepoint.
+	engine = NewV8Engine(ctx)
+	engine.SetExecutionLimits(100000, 10000000)
+	_, err = engine.Call(source, "js", "tryWrite", "[\"1\"]")
+	assert.Nil(t, err)
+	engine.Dispose()
+	_, err = contract.Get(hashStorageKey("kept"))
+	assert.Nil(t, err)
+	_, err = contract.Get(hashStorageKey("dropped"))
+	assert.NotNil(t, err)
+
+	// rolling back to an unknown savepoint throws in the contract.
+	engine = NewV8Engine(ctx)
+	engine.SetExecutionLimits(100000, 10000000)
+	_, err = engine.Call(source, "js", "rollbackUnknown", "")
+	assert.Equal(t, ErrExecutionFailed, err)
+	engine.Dispose()
+}
+
+func TestEnginePool(t *testing.T) {
+	mem, _ := storage.NewMemoryStorage()
+	context, _ := state.NewAccountState(nil, mem)
+	context.Begin()
+	owner, err := context.GetOrCreateUserAccount([]byte("account1"))
+	assert.Nil(t, err)
+
+	nvm := NewNebulasVM().(*NebulasVM)
+	nvm.SetEnginePoolSize(1)
+	created := atomic.LoadUint64(&v8enginesCreated)
+
+	var contracts []state.Account
+	for i := 0; i < 20; i++ {
+		// each execution runs with its own contract clone, as txs in block.
+		contract, err := context.CreateContractAccount([]byte(fmt.Sprintf("contract%d", i)), nil)
+		assert.Nil(t, err)
+		contracts = append(contracts, contract)
+
+		ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contract, context)
+		assert.Nil(t, err)
+		engine := nvm.Clone().(*NebulasVM)
+		engine.startEngine(ctx)
+
+		// reused engine starts with clean state.
+		instructions, err := engine.ExecutionInstructions()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(0), instructions)
+		written, err := engine.StorageBytesWritten()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(0), written)
+		assert.Equal(t, ErrSavepointNotFound, engine.RollbackToSavepoint("last"))
+
+		assert.Nil(t, engine.SetEngineExecutionLimits(100000))
+		assert.Nil(t, engine.Savepoint("last"))
+		_, err = engine.engine.RunScriptSource(fmt.Sprintf("if (typeof leaked !== 'undefined') throw new Error('leaked'); var leaked = %d; LocalContractStorage.put('key%d', %d);", i, i, i), 0)
+		assert.Nil(t, err)
+		engine.DisposeEngine()
+	}
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&v8enginesCreated)-created)
+
+	for i, contract := range contracts {
+		for j := range contracts {
+			_, err := contract.Get(hashStorageKey(fmt.Sprintf("key%d", j)))
+			if i == j {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		}
+	}
+
+	// pool size 0 disables reuse.
+	nvm.SetEnginePoolSize(0)
+	created = atomic.LoadUint64(&v8enginesCreated)
+	ctx, err := NewContext(mockBlock(), mockTransaction(), owner, contracts[0], context)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		nvm.startEngine(ctx)
+		nvm.DisposeEngine()
+	}
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&v8enginesCreated)-created)
+}
+
 func TestMultiEngine(t *testing.T) {
 	mem, _ := storage.NewMemoryStorage()
 	context, _ := state.NewAccountState(nil, mem)
@@ -22,6 +22,7 @@ import "C"
 import (
 	"unsafe"
 
+	"github.com/nebulasio/go-nebulas/core"
 	"github.com/nebulasio/go-nebulas/util/logging"
 	"github.com/sirupsen/logrus"
 )
@@ -42,6 +43,29 @@ func EventTriggerFunc(handler unsafe.Pointer, topic, data *C.char) {
 		return
 	}
 
+	if !e.checkWritable() {
+		logging.VLog().WithFields(logrus.Fields{
+			"topic": gTopic,
+		}).Debug("Event.Trigger in read-only execution.")
+		return
+	}
+
+	contract, err := core.AddressParseFromBytes(e.ctx.contract.Address())
+	if err != nil {
+		logging.VLog().WithFields(logrus.Fields{
+			"topic": gTopic,
+			"err":   err,
+		}).Error("Event.Trigger parse contract address failed.")
+		return
+	}
+
 	contractTopic := EventNameSpaceContract + "." + gTopic
-	e.ctx.block.RecordEvent(e.ctx.tx.Hash(), contractTopic, gData)
+	if err := e.ctx.block.RecordContractEvent(e.ctx.tx.Hash(), contract, contractTopic, gData); err != nil {
+		// the emitting call fails once the event is not recorded, such as the event buffer of block is full.
+		logging.VLog().WithFields(logrus.Fields{
+			"topic": gTopic,
+			"err":   err,
+		}).Debug("Event.Trigger record event failed.")
+		e.eventErr = err
+	}
 }
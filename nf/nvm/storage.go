@@ -57,11 +57,12 @@ func hashStorageKey(key string) []byte {
 // StorageGetFunc export StorageGetFunc
 //export StorageGetFunc
 func StorageGetFunc(handler unsafe.Pointer, key *C.char) *C.char {
-	_, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
+	engine, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
 	if storage == nil {
 		logging.VLog().Error("get storage failed!")
 		return nil
 	}
+	engine.traceStorageRead(C.GoString(key))
 
 	val, err := storage.Get([]byte(hashStorageKey(C.GoString(key))))
 	if err != nil {
@@ -81,12 +82,20 @@ func StorageGetFunc(handler unsafe.Pointer, key *C.char) *C.char {
 // StoragePutFunc export StoragePutFunc
 //export StoragePutFunc
 func StoragePutFunc(handler unsafe.Pointer, key *C.char, value *C.char) int {
-	_, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
+	engine, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
 	if storage == nil {
 		return 1
 	}
+	if !engine.checkWritable() {
+		logging.VLog().WithFields(logrus.Fields{
+			"handler": uint64(uintptr(handler)),
+			"key":     C.GoString(key),
+		}).Debug("StoragePutFunc put key in read-only execution.")
+		return 1
+	}
 
-	err := storage.Put([]byte(hashStorageKey(C.GoString(key))), []byte(C.GoString(value)))
+	val := []byte(C.GoString(value))
+	err := storage.Put([]byte(hashStorageKey(C.GoString(key))), val)
 	if err != nil && err != ErrKeyNotFound {
 		logging.VLog().WithFields(logrus.Fields{
 			"handler": uint64(uintptr(handler)),
@@ -95,16 +104,24 @@ func StoragePutFunc(handler unsafe.Pointer, key *C.char, value *C.char) int {
 		}).Error("StoragePutFunc put key failed.")
 		return 1
 	}
+	engine.actualCountOfStorageBytesWritten += uint64(len(val))
 	return 0
 }
 
 // StorageDelFunc export StorageDelFunc
 //export StorageDelFunc
 func StorageDelFunc(handler unsafe.Pointer, key *C.char) int {
-	_, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
+	engine, storage := getEngineByStorageHandler(uint64(uintptr(handler)))
 	if storage == nil {
 		return 1
 	}
+	if !engine.checkWritable() {
+		logging.VLog().WithFields(logrus.Fields{
+			"handler": uint64(uintptr(handler)),
+			"key":     C.GoString(key),
+		}).Debug("StorageDelFunc del key in read-only execution.")
+		return 1
+	}
 
 	err := storage.Del([]byte(hashStorageKey(C.GoString(key))))
 
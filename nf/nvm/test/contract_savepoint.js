@@ -0,0 +1,38 @@
+// Copyright (C) 2017 go-nebulas authors
+//
+// This file is part of the go-nebulas library.
+//
+// the go-nebulas library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// the go-nebulas library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with the go-nebulas library.  If not, see <http://www.gnu.org/licenses/>.
+//
+
+'use strict';
+
+var SavepointContract = function () {
+};
+
+SavepointContract.prototype = {
+    init: function () {
+    },
+    tryWrite: function (value) {
+        LocalContractStorage.put("kept", value);
+        Blockchain.savepoint("attempt");
+        LocalContractStorage.put("dropped", value);
+        Blockchain.rollbackToSavepoint("attempt");
+    },
+    rollbackUnknown: function () {
+        Blockchain.rollbackToSavepoint("unknown");
+    }
+};
+
+module.exports = SavepointContract;
@@ -19,7 +19,7 @@ var (
 	ErrExecutionFailed                 = errors.New("execution failed")
 	ErrDisallowCallPrivateFunction     = errors.New("disallow call private function")
 	ErrExecutionTimeout                = errors.New("execution timeout")
-	ErrInsufficientGas                 = errors.New("insufficient gas")
+	ErrInsufficientGas                 = core.ErrInsufficientGas
 	ErrExceedMemoryLimits              = errors.New("exceed memory limits")
 	ErrInjectTracingInstructionFailed  = errors.New("inject tracing instructions failed")
 	ErrTranspileTypeScriptFailed       = errors.New("transpile TypeScript failed")
@@ -28,11 +28,14 @@ var (
 	ErrLimitHasEmpty                   = errors.New("limit args has empty")
 	ErrSetMemorySmall                  = errors.New("set memory small than v8 limit")
 	ErrDisallowCallNotStandardFunction = errors.New("disallow call not standard function")
+	ErrSavepointNotFound               = errors.New("savepoint not found")
+	ErrReadOnlyViolation               = core.ErrReadOnlyViolation
+	ErrExecutionDeadlineExceeded       = core.ErrExecutionDeadlineExceeded
 )
 
 //define
 var (
-	EventNameSpaceContract = "chain.contract" //ToRefine: move to core
+	EventNameSpaceContract = core.TopicContractEventNameSpace
 )
 
 //common err
@@ -55,6 +58,15 @@ const (
 	TransferStringToBigIntErr
 	TransferSubBalance
 	TransferAddBalance
+	TransferReadOnlyErr
+)
+
+//savepoint err code enum
+const (
+	SavepointFuncSuccess = iota
+	SavepointGetEngineErr
+	SavepointNotFoundErr
+	SavepointFailedErr
 )
 
 // Block interface breaks cycle import dependency and hides unused services.
@@ -62,8 +74,10 @@ type Block interface {
 	Hash() byteutils.Hash
 	Height() uint64 // ToAdd: timestamp interface
 	Timestamp() int64
+	RandomSeed(txHash byteutils.Hash) byteutils.Hash
 	GetTransaction(hash byteutils.Hash) (*core.Transaction, error)
 	RecordEvent(txHash byteutils.Hash, topic, data string) error
+	RecordContractEvent(txHash byteutils.Hash, contract *core.Address, topic, data string) error
 }
 
 // Transaction interface breaks cycle import dependency and hides unused services.
@@ -80,6 +94,7 @@ type Transaction interface {
 
 // Account interface breaks cycle import dependency and hides unused services.
 type Account interface {
+	Address() byteutils.Hash
 	Balance() *util.Uint128
 	Nonce() uint64
 	AddBalance(value *util.Uint128) error
@@ -87,6 +102,8 @@ type Account interface {
 	Put(key []byte, value []byte) error
 	Get(key []byte) ([]byte, error)
 	Del(key []byte) error
+	Savepoint() (int, error)
+	RollbackToSavepoint(savepoint int) error
 }
 
 // WorldState interface breaks cycle import dependency and hides unused services.
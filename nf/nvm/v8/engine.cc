@@ -132,6 +132,15 @@ int ExecuteSourceDataDelegate(char **result, Isolate *isolate,
   MaybeLocal<Value> ret = script.ToLocalChecked()->Run(context);
   if (ret.IsEmpty()) {
     PrintException(context, trycatch);
+
+    // set the thrown exception as result, which is the revert reason.
+    if (result != NULL && trycatch.HasCaught()) {
+      String::Utf8Value exception(trycatch.Exception());
+      if (*exception != NULL) {
+        *result = (char *)malloc(exception.length() + 1);
+        strcpy(*result, *exception);
+      }
+    }
     return 1;
   }
 
@@ -63,11 +63,15 @@ typedef char *(*GetTxByHashFunc)(void *handler, const char *hash);
 typedef char *(*GetAccountStateFunc)(void *handler, const char *address);
 typedef int (*TransferFunc)(void *handler, const char *to, const char *value);
 typedef int (*VerifyAddressFunc)(void *handler, const char *address);
+typedef int (*SavepointFunc)(void *handler, const char *name);
+typedef int (*RollbackToSavepointFunc)(void *handler, const char *name);
 
 EXPORT void InitializeBlockchain(GetTxByHashFunc getTx,
                                  GetAccountStateFunc getAccount,
                                  TransferFunc transfer,
-                                 VerifyAddressFunc verifyAddress);
+                                 VerifyAddressFunc verifyAddress,
+                                 SavepointFunc savepoint,
+                                 RollbackToSavepointFunc rollbackToSavepoint);
 
 // version
 EXPORT char *GetV8Version();
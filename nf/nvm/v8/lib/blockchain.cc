@@ -24,14 +24,20 @@ static GetTxByHashFunc sGetTxByHash = NULL;
 static GetAccountStateFunc sGetAccountState = NULL;
 static TransferFunc sTransfer = NULL;
 static VerifyAddressFunc sVerifyAddress = NULL;
+static SavepointFunc sSavepoint = NULL;
+static RollbackToSavepointFunc sRollbackToSavepoint = NULL;
 
 void InitializeBlockchain(GetTxByHashFunc getTx,
                           GetAccountStateFunc getAccount,
-                          TransferFunc transfer, VerifyAddressFunc verifyAddress) {
+                          TransferFunc transfer, VerifyAddressFunc verifyAddress,
+                          SavepointFunc savepoint,
+                          RollbackToSavepointFunc rollbackToSavepoint) {
   sGetTxByHash = getTx;
   sGetAccountState = getAccount;
   sTransfer = transfer;
   sVerifyAddress = verifyAddress;
+  sSavepoint = savepoint;
+  sRollbackToSavepoint = rollbackToSavepoint;
 }
 
 void NewBlockchainInstance(Isolate *isolate, Local<Context> context,
@@ -59,6 +65,16 @@ void NewBlockchainInstance(Isolate *isolate, Local<Context> context,
                 static_cast<PropertyAttribute>(PropertyAttribute::DontDelete |
                                                PropertyAttribute::ReadOnly));
 
+  blockTpl->Set(String::NewFromUtf8(isolate, "savepoint"),
+                FunctionTemplate::New(isolate, SavepointCallback),
+                static_cast<PropertyAttribute>(PropertyAttribute::DontDelete |
+                                               PropertyAttribute::ReadOnly));
+
+  blockTpl->Set(String::NewFromUtf8(isolate, "rollbackToSavepoint"),
+                FunctionTemplate::New(isolate, RollbackToSavepointCallback),
+                static_cast<PropertyAttribute>(PropertyAttribute::DontDelete |
+                                               PropertyAttribute::ReadOnly));
+
   Local<Object> instance = blockTpl->NewInstance(context).ToLocalChecked();
   instance->SetInternalField(0, External::New(isolate, handler));
 
@@ -177,3 +193,50 @@ void VerifyAddressCallback(const FunctionCallbackInfo<Value> &info) {
   int ret = sVerifyAddress(handler->Value(), *String::Utf8Value(address->ToString()));
   info.GetReturnValue().Set(ret);
 }
+
+// SavepointCallback
+void SavepointCallback(const FunctionCallbackInfo<Value> &info) {
+  Isolate *isolate = info.GetIsolate();
+  Local<Object> thisArg = info.Holder();
+  Local<External> handler = Local<External>::Cast(thisArg->GetInternalField(0));
+
+  if (info.Length() != 1) {
+    isolate->ThrowException(
+        String::NewFromUtf8(isolate, "Blockchain.savepoint() requires 1 arguments"));
+    return;
+  }
+
+  Local<Value> name = info[0];
+  if (!name->IsString()) {
+    isolate->ThrowException(
+        String::NewFromUtf8(isolate, "savepoint name must be string"));
+    return;
+  }
+
+  int ret = sSavepoint(handler->Value(), *String::Utf8Value(name->ToString()));
+  info.GetReturnValue().Set(ret);
+}
+
+// RollbackToSavepointCallback
+void RollbackToSavepointCallback(const FunctionCallbackInfo<Value> &info) {
+  Isolate *isolate = info.GetIsolate();
+  Local<Object> thisArg = info.Holder();
+  Local<External> handler = Local<External>::Cast(thisArg->GetInternalField(0));
+
+  if (info.Length() != 1) {
+    isolate->ThrowException(String::NewFromUtf8(
+        isolate, "Blockchain.rollbackToSavepoint() requires 1 arguments"));
+    return;
+  }
+
+  Local<Value> name = info[0];
+  if (!name->IsString()) {
+    isolate->ThrowException(
+        String::NewFromUtf8(isolate, "savepoint name must be string"));
+    return;
+  }
+
+  int ret = sRollbackToSavepoint(handler->Value(),
+                                 *String::Utf8Value(name->ToString()));
+  info.GetReturnValue().Set(ret);
+}
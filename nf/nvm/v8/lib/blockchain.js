@@ -18,6 +18,32 @@
 
 'use strict';
 
+// seededRandom return a Math.random replacement, the xoshiro128** generator seeded by the
+// hex seed of block, all nodes executing the same tx in the same block get the same sequence.
+var seededRandom = function (seed) {
+    var s = [0, 0, 0, 0];
+    for (var i = 0; i < 4; i++) {
+        s[i] = parseInt(seed.substr(i * 8, 8), 16) | 0;
+    }
+    if ((s[0] | s[1] | s[2] | s[3]) === 0) {
+        s[0] = 1;
+    }
+    var rotl = function (x, k) {
+        return (x << k) | (x >>> (32 - k));
+    };
+    return function () {
+        var result = Math.imul(rotl(Math.imul(s[1], 5), 7), 9);
+        var t = s[1] << 9;
+        s[2] ^= s[0];
+        s[3] ^= s[1];
+        s[1] ^= s[2];
+        s[0] ^= s[3];
+        s[2] ^= t;
+        s[3] = rotl(s[3], 11);
+        return (result >>> 0) / 4294967296;
+    };
+};
+
 var Blockchain = function () {
     this.nativeBlockchain = _native_blockchain;
 };
@@ -27,6 +53,9 @@ Blockchain.prototype = {
         var block = JSON.parse(str);
         if (block != null) {
             this.block = block;
+            if (typeof block.seed === "string" && block.seed.length >= 32) {
+                Math.random = seededRandom(block.seed);
+            }
         }
     },
     transactionParse: function (str) {
@@ -49,6 +78,16 @@ Blockchain.prototype = {
     },
     verifyAddress: function (address) {
         return this.nativeBlockchain.verifyAddress(address);
+    },
+    savepoint: function (name) {
+        if (this.nativeBlockchain.savepoint(name) !== 0) {
+            throw new Error("failed to create savepoint " + name);
+        }
+    },
+    rollbackToSavepoint: function (name) {
+        if (this.nativeBlockchain.rollbackToSavepoint(name) !== 0) {
+            throw new Error("failed to roll back to savepoint " + name);
+        }
     }
 };
 
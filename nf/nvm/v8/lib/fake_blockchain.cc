@@ -49,3 +49,7 @@ char *GetAccountState(void *handler, const char *address) {
 int Transfer(void *handler, const char *to, const char *value) { return 1; }
 
 int VerifyAddress(void *handler, const char *address) { return 1; }
+
+int Savepoint(void *handler, const char *name) { return 0; }
+
+int RollbackToSavepoint(void *handler, const char *name) { return 0; }
@@ -24,5 +24,7 @@ char *GetTxByHash(void *handler, const char *hash);
 char *GetAccountState(void *handler, const char *address);
 int Transfer(void *handler, const char *to, const char *value);
 int VerifyAddress(void *handler, const char *address);
+int Savepoint(void *handler, const char *name);
+int RollbackToSavepoint(void *handler, const char *name);
 
 #endif //_NEBULAS_NF_NVM_V8_LIB_FAKE_BLOCKCHAIN_H_
@@ -31,7 +31,6 @@ import (
 	"github.com/nebulasio/go-nebulas/crypto/hash"
 	"github.com/nebulasio/go-nebulas/net"
 	"github.com/nebulasio/go-nebulas/rpc/pb"
-	"github.com/nebulasio/go-nebulas/util"
 	"github.com/nebulasio/go-nebulas/util/byteutils"
 	"golang.org/x/net/context"
 )
@@ -179,26 +178,19 @@ func (s *APIService) sendTransaction(req *rpcpb.TransactionRequest) (*rpcpb.Send
 }
 
 func parseTransaction(neb core.Neblet, reqTx *rpcpb.TransactionRequest) (*core.Transaction, error) {
-	fromAddr, err := core.AddressParse(reqTx.From)
+	prefix := core.NetworkAddressPrefix(neb.BlockChain().ChainID())
+	fromAddr, err := core.AddressParseWithPrefix(reqTx.From, prefix)
 	if err != nil {
 		return nil, err
 	}
-	toAddr, err := core.AddressParse(reqTx.To)
+	toAddr, err := core.AddressParseWithPrefix(reqTx.To, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	value, err := util.NewUint128FromString(reqTx.Value)
+	value, gasPrice, gasLimit, err := core.ParseTxAmounts(reqTx.Value, reqTx.GasPrice, reqTx.GasLimit)
 	if err != nil {
-		return nil, errors.New("invalid value")
-	}
-	gasPrice, err := util.NewUint128FromString(reqTx.GasPrice)
-	if err != nil {
-		return nil, errors.New("invalid gasPrice")
-	}
-	gasLimit, err := util.NewUint128FromString(reqTx.GasLimit)
-	if err != nil {
-		return nil, errors.New("invalid gasLimit")
+		return nil, err
 	}
 	var (
 		payloadType string
@@ -227,6 +219,9 @@ func parseTransaction(neb core.Neblet, reqTx *rpcpb.TransactionRequest) (*core.T
 	if err != nil {
 		return nil, err
 	}
+	if err := core.ValidatePayloadType(payloadType, payload); err != nil {
+		return nil, err
+	}
 
 	tx, err := core.NewTransaction(neb.BlockChain().ChainID(), fromAddr, toAddr, value, reqTx.Nonce, payloadType, payload, gasPrice, gasLimit)
 	if err != nil {
@@ -253,7 +248,7 @@ func handleTransactionResponse(neb core.Neblet, tx *core.Transaction) (resp *rpc
 	}
 
 	if tx.Type() == core.TxPayloadDeployType {
-		if !tx.From().Equals(tx.To()) {
+		if !tx.From().Equals(tx.To()) && !isContractUpgrade(tx) {
 			return nil, core.ErrContractTransactionAddressNotEqual
 		}
 	} else if tx.Type() == core.TxPayloadCallType {
@@ -268,7 +263,9 @@ func handleTransactionResponse(neb core.Neblet, tx *core.Transaction) (resp *rpc
 	}
 
 	var contract string
-	if tx.Type() == core.TxPayloadDeployType {
+	if isContractUpgrade(tx) {
+		contract = tx.To().String()
+	} else if tx.Type() == core.TxPayloadDeployType {
 		addr, err := core.NewContractAddressFromHash(hash.Sha3256(tx.From().Bytes(), byteutils.FromUint64(tx.Nonce())))
 		if err != nil {
 			return nil, err
@@ -279,6 +276,19 @@ func handleTransactionResponse(neb core.Neblet, tx *core.Transaction) (resp *rpc
 	return &rpcpb.SendTransactionResponse{Txhash: tx.Hash().String(), ContractAddress: contract}, nil
 }
 
+// isContractUpgrade return if tx upgrades the code of the existing contract at tx.to
+func isContractUpgrade(tx *core.Transaction) bool {
+	if tx.Type() != core.TxPayloadDeployType {
+		return false
+	}
+	payload, err := tx.LoadPayload()
+	if err != nil {
+		return false
+	}
+	deploy, ok := payload.(*core.DeployPayload)
+	return ok && deploy.Upgrade
+}
+
 // SendRawTransaction submit the signed transaction raw data to txpool
 func (s *APIService) SendRawTransaction(ctx context.Context, req *rpcpb.SendRawTransactionRequest) (*rpcpb.SendTransactionResponse, error) {
 
@@ -429,18 +439,20 @@ func (s *APIService) toTransactionResponse(tx *core.Transaction) (*rpcpb.Transac
 		Hash:      tx.Hash().String(),
 		From:      tx.From().String(),
 		To:        tx.To().String(),
-		Value:     tx.Value().String(),
+		Value:     tx.ValueString(),
 		Nonce:     tx.Nonce(),
 		Timestamp: tx.Timestamp(),
 		Type:      tx.Type(),
 		Data:      tx.Data(),
-		GasPrice:  tx.GasPrice().String(),
-		GasLimit:  tx.GasLimit().String(),
+		GasPrice:  tx.GasPriceString(),
+		GasLimit:  tx.GasLimitString(),
 		Status:    status,
 		GasUsed:   gasUsed,
 	}
 
-	if tx.Type() == core.TxPayloadDeployType {
+	if isContractUpgrade(tx) {
+		resp.ContractAddress = tx.To().String()
+	} else if tx.Type() == core.TxPayloadDeployType {
 		contractAddr, err := tx.GenerateContractAddress()
 		if err != nil {
 			return nil, err
@@ -0,0 +1,73 @@
+// Copyright (C) 2017 go-nebulas authors
+//
+// This file is part of the go-nebulas library.
+//
+// the go-nebulas library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// the go-nebulas library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with the go-nebulas library.  If not, see <http://www.gnu.org/licenses/>.
+//
+
+package storage
+
+import (
+	"sync"
+
+	"github.com/nebulasio/go-nebulas/util/byteutils"
+)
+
+// OverlayStorage read through to the base storage, while the writes are kept in memory
+// and never reach the base, so that state can be forked without mutating the base.
+type OverlayStorage struct {
+	base    Storage
+	data    *sync.Map
+	deleted *sync.Map
+}
+
+// NewOverlayStorage on the base storage
+func NewOverlayStorage(base Storage) (*OverlayStorage, error) {
+	if base == nil {
+		return nil, ErrNilBaseStorage
+	}
+	return &OverlayStorage{
+		base:    base,
+		data:    new(sync.Map),
+		deleted: new(sync.Map),
+	}, nil
+}
+
+// Get return value to the key in the overlay, or in the base if not written
+func (db *OverlayStorage) Get(key []byte) ([]byte, error) {
+	k := byteutils.Hex(key)
+	if entry, ok := db.data.Load(k); ok {
+		return entry.([]byte), nil
+	}
+	if _, ok := db.deleted.Load(k); ok {
+		return nil, ErrKeyNotFound
+	}
+	return db.base.Get(key)
+}
+
+// Put put the key-value entry to the overlay
+func (db *OverlayStorage) Put(key []byte, value []byte) error {
+	k := byteutils.Hex(key)
+	db.data.Store(k, value)
+	db.deleted.Delete(k)
+	return nil
+}
+
+// Del delete the key in the overlay, the base is untouched.
+func (db *OverlayStorage) Del(key []byte) error {
+	k := byteutils.Hex(key)
+	db.data.Delete(k)
+	db.deleted.Store(k, true)
+	return nil
+}
@@ -22,7 +22,8 @@ import "errors"
 
 // const
 var (
-	ErrKeyNotFound = errors.New("not found")
+	ErrKeyNotFound    = errors.New("not found")
+	ErrNilBaseStorage = errors.New("base storage is nil")
 )
 
 // Storage interface of Storage.
@@ -205,6 +205,20 @@ func (u *Uint128) Exp(x *Uint128) (*Uint128, error) {
 	return obj, nil
 }
 
+// AddInPlace sets u to u + x without allocating a new Uint128, for the hot gas math.
+// On error u holds the out of range sum and must be discarded.
+func (u *Uint128) AddInPlace(x *Uint128) error {
+	u.Int.Add(u.Int, x.Int)
+	return u.Validate()
+}
+
+// MulInPlace sets u to u * x without allocating a new Uint128, for the hot gas math.
+// On error u holds the out of range product and must be discarded.
+func (u *Uint128) MulInPlace(x *Uint128) error {
+	u.Int.Mul(u.Int, x.Int)
+	return u.Validate()
+}
+
 //DeepCopy returns a deep copy of u
 func (u *Uint128) DeepCopy() *Uint128 {
 	z := new(big.Int)
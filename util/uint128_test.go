@@ -137,3 +137,61 @@ func TestUint128Operation(t *testing.T) {
 	assert.Equal(t, b.Cmp(a), -1)
 	assert.Equal(t, a.Cmp(a), 0)
 }
+
+func TestUint128InPlaceOperation(t *testing.T) {
+	a, _ := NewUint128FromInt(10)
+	b, _ := NewUint128FromInt(9)
+
+	sumExpect, _ := a.Add(b)
+	sum := a.DeepCopy()
+	assert.Nil(t, sum.AddInPlace(b))
+	assert.Equal(t, sumExpect.Bytes(), sum.Bytes())
+
+	productExpect, _ := a.Mul(b)
+	product := a.DeepCopy()
+	assert.Nil(t, product.MulInPlace(b))
+	assert.Equal(t, productExpect.Bytes(), product.Bytes())
+
+	// the operands are untouched.
+	assert.Equal(t, uint64(10), a.Uint64())
+	assert.Equal(t, uint64(9), b.Uint64())
+
+	max, _ := NewUint128FromBigInt(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)))
+	one := NewUint128FromUint(1)
+	_, err := max.DeepCopy().Add(one)
+	assert.Equal(t, err, max.DeepCopy().AddInPlace(one))
+	_, err = max.DeepCopy().Mul(b)
+	assert.Equal(t, err, max.DeepCopy().MulInPlace(b))
+}
+
+func BenchmarkUint128Add(b *testing.B) {
+	x, y := NewUint128FromUint(20000), NewUint128FromUint(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x.Add(y)
+	}
+}
+
+func BenchmarkUint128AddInPlace(b *testing.B) {
+	x, y := NewUint128FromUint(20000), NewUint128FromUint(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x.AddInPlace(y)
+	}
+}
+
+func BenchmarkUint128Mul(b *testing.B) {
+	x, y := NewUint128FromUint(20000), NewUint128FromUint(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x.Mul(y)
+	}
+}
+
+func BenchmarkUint128MulInPlace(b *testing.B) {
+	x, y := NewUint128FromUint(20000), NewUint128FromUint(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x.MulInPlace(y)
+	}
+}